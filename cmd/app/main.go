@@ -1,13 +1,15 @@
 package main
 
 import (
+	"os"
+
 	_ "github.com/Ra1nz0r/effective_mobile-1/docs"
-	"github.com/Ra1nz0r/effective_mobile-1/internal/server"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/cli"
 )
 
+// Запускает сервер или выполняет одну из команд: миграции, импорт, выгрузку, заполнение
+// библиотеки, проверку настроек. Список команд: go run ./cmd/app help
+
 // @title Music Library API
 // @version 1.0
 // @description REST API для управления онлайн-библиотекой песен. Включает функции добавления, обновления, удаления и поиска песен, а также взаимодействие с внешними сервисами для получения дополнительной информации о композициях.
@@ -19,6 +21,16 @@ import (
 
 // @host localhost:7654
 // @BasePath /
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API ключ. Роль reader даёт чтение, editor - изменение библиотеки, admin - управление ключами.
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT от SSO или API ключ в виде "Bearer <токен>". Роль определяется полем JWT_ROLES_CLAIM.
 func main() {
-	server.Run()
+	os.Exit(cli.Main(os.Args[1:], os.Stdout, os.Stderr))
 }
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"os"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/cli"
+)
+
+// Выгружает библиотеку песен в файл или в стандартный вывод, то же, что и команда export.
+// Пример: go run ./cmd/export -format csv -gzip -o library.csv.gz -group Muse
+func main() {
+	os.Exit(cli.Main(append([]string{"export"}, os.Args[1:]...), os.Stdout, os.Stderr))
+}
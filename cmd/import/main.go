@@ -0,0 +1,14 @@
+package main
+
+import (
+	"os"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/cli"
+)
+
+// Импортирует песни по тегам MP3 и FLAC файлов из указанных папок и выводит отчёт
+// в формате JSON, то же, что и команда import.
+// Пример: go run ./cmd/import -dry-run ~/Music
+func main() {
+	os.Exit(cli.Main(append([]string{"import"}, os.Args[1:]...), os.Stdout, os.Stderr))
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "audio";
\ No newline at end of file
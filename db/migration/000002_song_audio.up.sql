@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS "audio" (
+    "song_id" int PRIMARY KEY,
+    "storage_key" varchar NOT NULL,
+    "content_type" varchar NOT NULL,
+    "size" bigint NOT NULL,
+    "sha256" varchar NOT NULL,
+    "uploaded_at" timestamptz NOT NULL DEFAULT now(),
+    FOREIGN KEY ("song_id") REFERENCES "library" ("id") ON DELETE CASCADE
+);
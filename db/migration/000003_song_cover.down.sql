@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "cover";
\ No newline at end of file
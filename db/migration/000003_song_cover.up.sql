@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS "cover" (
+    "song_id" int NOT NULL,
+    "size" int NOT NULL,
+    "storage_key" varchar NOT NULL,
+    "content_type" varchar NOT NULL,
+    "width" int NOT NULL,
+    "height" int NOT NULL,
+    "sha256" varchar NOT NULL,
+    "uploaded_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("song_id", "size"),
+    FOREIGN KEY ("song_id") REFERENCES "library" ("id") ON DELETE CASCADE
+);
+COMMENT ON COLUMN "cover"."size" IS 'Thumbnail bounding box in pixels, 0 for the original image';
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "api_key" (
+    "id" serial PRIMARY KEY,
+    "name" varchar NOT NULL,
+    "role" varchar NOT NULL CHECK ("role" IN ('reader', 'editor', 'admin')),
+    "prefix" varchar NOT NULL,
+    "key_hash" varchar NOT NULL UNIQUE,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "revoked_at" timestamptz
+);
+COMMENT ON COLUMN "api_key"."key_hash" IS 'SHA-256 of the key, the key itself is never stored';
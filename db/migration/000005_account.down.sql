@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "rating";
+DROP TABLE IF EXISTS "favorite";
+ALTER TABLE "api_key" DROP COLUMN IF EXISTS "account_id";
+DROP TABLE IF EXISTS "account";
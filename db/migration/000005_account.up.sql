@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS "account" (
+    "id" serial PRIMARY KEY,
+    "name" varchar NOT NULL,
+    "subject" varchar UNIQUE,
+    "created_at" timestamptz NOT NULL DEFAULT now()
+);
+COMMENT ON COLUMN "account"."subject" IS 'SSO subject (sub claim), NULL for accounts created by an admin';
+ALTER TABLE "api_key"
+ADD COLUMN "account_id" int REFERENCES "account" ("id") ON DELETE SET NULL;
+CREATE TABLE IF NOT EXISTS "favorite" (
+    "account_id" int NOT NULL,
+    "song_id" int NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("account_id", "song_id"),
+    FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("song_id") REFERENCES "library" ("id") ON DELETE CASCADE
+);
+CREATE TABLE IF NOT EXISTS "rating" (
+    "account_id" int NOT NULL,
+    "song_id" int NOT NULL,
+    "stars" smallint NOT NULL CHECK (
+        "stars" BETWEEN 1 AND 5
+    ),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("account_id", "song_id"),
+    FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("song_id") REFERENCES "library" ("id") ON DELETE CASCADE
+);
+CREATE INDEX ON "favorite" ("song_id");
+CREATE INDEX ON "rating" ("song_id");
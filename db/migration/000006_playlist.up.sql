@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS "playlist" (
+    "id" serial PRIMARY KEY,
+    "account_id" int NOT NULL,
+    "name" varchar NOT NULL,
+    "is_public" boolean NOT NULL DEFAULT false,
+    "share_token" varchar UNIQUE,
+    "version" int NOT NULL DEFAULT 1,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE CASCADE
+);
+COMMENT ON COLUMN "playlist"."version" IS 'Incremented on every change, used for optimistic concurrency';
+CREATE TABLE IF NOT EXISTS "playlist_entry" (
+    "id" serial PRIMARY KEY,
+    "playlist_id" int NOT NULL,
+    "song_id" int NOT NULL,
+    "position" int NOT NULL,
+    "added_at" timestamptz NOT NULL DEFAULT now(),
+    FOREIGN KEY ("playlist_id") REFERENCES "playlist" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("song_id") REFERENCES "library" ("id") ON DELETE CASCADE
+);
+COMMENT ON COLUMN "playlist_entry"."position" IS 'Sort key within the playlist, gaps are allowed after song deletion';
+CREATE INDEX ON "playlist" ("account_id");
+CREATE INDEX ON "playlist_entry" ("playlist_id", "position");
+CREATE INDEX ON "playlist_entry" ("song_id");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "song_daily_stats";
+DROP TABLE IF EXISTS "play_event";
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS "play_event" (
+    "id" bigserial PRIMARY KEY,
+    "song_id" int NOT NULL,
+    "account_id" int,
+    "kind" varchar NOT NULL CHECK ("kind" IN ('play', 'view', 'couplet')),
+    "played_at" timestamptz NOT NULL DEFAULT now(),
+    FOREIGN KEY ("song_id") REFERENCES "library" ("id") ON DELETE CASCADE,
+    FOREIGN KEY ("account_id") REFERENCES "account" ("id") ON DELETE CASCADE
+);
+CREATE INDEX ON "play_event" ("account_id", "played_at");
+CREATE TABLE IF NOT EXISTS "song_daily_stats" (
+    "song_id" int NOT NULL,
+    "day" date NOT NULL,
+    "plays" int NOT NULL DEFAULT 0,
+    "views" int NOT NULL DEFAULT 0,
+    PRIMARY KEY ("song_id", "day"),
+    FOREIGN KEY ("song_id") REFERENCES "library" ("id") ON DELETE CASCADE
+);
+COMMENT ON COLUMN "song_daily_stats"."views" IS 'Song page and couplet page views';
+CREATE INDEX ON "song_daily_stats" ("day");
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS "song_tag" (
+    "song_id" int NOT NULL,
+    "tag" varchar NOT NULL,
+    PRIMARY KEY ("song_id", "tag"),
+    FOREIGN KEY ("song_id") REFERENCES "library" ("id") ON DELETE CASCADE
+);
+CREATE INDEX ON "song_tag" ("tag");
@@ -0,0 +1,14 @@
+-- Songs in the trash cannot be kept without "deleted_at", and dropping them silently would
+-- lose data, so the rollback refuses to run until they are restored or purged.
+DO $$ BEGIN IF EXISTS (
+    SELECT 1
+    FROM "library"
+    WHERE "deleted_at" IS NOT NULL
+) THEN RAISE EXCEPTION 'library has songs in the trash: restore them or purge them with a short TRASH_RETENTION before rolling back';
+END IF;
+END $$;
+DROP INDEX IF EXISTS library_deleted_at;
+DROP INDEX IF EXISTS unique_group_song;
+ALTER TABLE "library"
+ADD CONSTRAINT unique_group_song UNIQUE (group_id, song);
+ALTER TABLE "library" DROP COLUMN IF EXISTS "deleted_at";
@@ -0,0 +1,8 @@
+ALTER TABLE "library"
+ADD COLUMN IF NOT EXISTS "deleted_at" timestamptz;
+COMMENT ON COLUMN "library"."deleted_at" IS 'Time the song was moved to the trash, NULL for active songs';
+ALTER TABLE "library" DROP CONSTRAINT IF EXISTS unique_group_song;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_group_song ON "library" ("group_id", "song")
+WHERE "deleted_at" IS NULL;
+CREATE INDEX IF NOT EXISTS library_deleted_at ON "library" ("deleted_at")
+WHERE "deleted_at" IS NOT NULL;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS "audit_log" (
+    "id" bigserial PRIMARY KEY,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    "actor" varchar NOT NULL,
+    "role" varchar NOT NULL DEFAULT '',
+    "api_key_id" int,
+    "account_id" int,
+    "request_id" varchar NOT NULL DEFAULT '',
+    "entity" varchar NOT NULL,
+    "entity_id" int NOT NULL,
+    "action" varchar NOT NULL,
+    "before" jsonb NOT NULL DEFAULT 'null',
+    "after" jsonb NOT NULL DEFAULT 'null'
+);
+COMMENT ON COLUMN "audit_log"."actor" IS 'user:<SSO subject>, key:<API key name> or system';
+COMMENT ON COLUMN "audit_log"."before" IS 'Entity state before the change, null for created entities';
+COMMENT ON COLUMN "audit_log"."after" IS 'Entity state after the change, null for deleted entities';
+CREATE INDEX IF NOT EXISTS audit_log_entity ON "audit_log" ("entity", "entity_id");
+CREATE INDEX IF NOT EXISTS audit_log_created_at ON "audit_log" ("created_at");
+CREATE INDEX IF NOT EXISTS audit_log_actor ON "audit_log" ("actor");
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$ BEGIN RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER audit_log_no_update BEFORE
+UPDATE
+    OR DELETE ON "audit_log" FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON "audit_log" FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
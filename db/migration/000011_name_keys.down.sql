@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS library_song_key;
+DROP INDEX IF EXISTS artist_by_group_key;
+ALTER TABLE "library" DROP COLUMN IF EXISTS "song_key";
+ALTER TABLE "artist" DROP COLUMN IF EXISTS "group_key";
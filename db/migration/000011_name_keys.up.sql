@@ -0,0 +1,9 @@
+ALTER TABLE "artist"
+ADD COLUMN IF NOT EXISTS "group_key" varchar NOT NULL DEFAULT '';
+ALTER TABLE "library"
+ADD COLUMN IF NOT EXISTS "song_key" varchar NOT NULL DEFAULT '';
+COMMENT ON COLUMN "artist"."group_key" IS 'Group name for uniqueness checks: NFKC, case folded, whitespace trimmed and collapsed. Empty keys are filled by the application, SQL lower() does not match Unicode case folding';
+COMMENT ON COLUMN "library"."song_key" IS 'Song title for uniqueness checks: NFKC, case folded, whitespace trimmed and collapsed. Empty keys are filled by the application, SQL lower() does not match Unicode case folding';
+CREATE INDEX IF NOT EXISTS artist_by_group_key ON "artist" ("group_key");
+CREATE INDEX IF NOT EXISTS library_song_key ON "library" ("group_id", "song_key")
+WHERE "deleted_at" IS NULL;
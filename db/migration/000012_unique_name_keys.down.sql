@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS unique_group_song;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_group_song ON "library" ("group_id", "song")
+WHERE "deleted_at" IS NULL;
+CREATE INDEX IF NOT EXISTS library_song_key ON "library" ("group_id", "song_key")
+WHERE "deleted_at" IS NULL;
+DROP INDEX IF EXISTS unique_group_key;
+CREATE INDEX IF NOT EXISTS artist_by_group_key ON "artist" ("group_key");
+ALTER TABLE "artist"
+ADD CONSTRAINT artist_group_key UNIQUE ("group");
@@ -0,0 +1,11 @@
+ALTER TABLE "artist" DROP CONSTRAINT IF EXISTS artist_group_key;
+DROP INDEX IF EXISTS artist_by_group_key;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_group_key ON "artist" ("group_key")
+WHERE "group_key" <> '';
+DROP INDEX IF EXISTS library_song_key;
+DROP INDEX IF EXISTS unique_group_song;
+CREATE UNIQUE INDEX IF NOT EXISTS unique_group_song ON "library" ("group_id", "song_key")
+WHERE "deleted_at" IS NULL
+    AND "song_key" <> '';
+COMMENT ON INDEX unique_group_key IS 'Empty keys are not filled yet or belong to duplicates left for merging';
+COMMENT ON INDEX unique_group_song IS 'Empty keys are not filled yet or belong to duplicates left for merging';
@@ -0,0 +1,10 @@
+// Package migration содержит миграции схемы базы данных. Файлы встраиваются в программу,
+// поэтому для миграций не нужна папка рядом с исполняемым файлом.
+package migration
+
+import "embed"
+
+// FS файлы миграций NNNNNN_name.up.sql и NNNNNN_name.down.sql.
+//
+//go:embed *.sql
+var FS embed.FS
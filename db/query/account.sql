@@ -0,0 +1,47 @@
+-- name: AddFavorite :execrows
+INSERT INTO favorite (account_id, song_id)
+VALUES ($1, $2) ON CONFLICT DO NOTHING;
+-- name: CreateAccount :one
+INSERT INTO account (name)
+VALUES ($1)
+RETURNING *;
+-- name: DeleteFavorite :execrows
+DELETE FROM favorite
+WHERE account_id = $1
+    AND song_id = $2;
+-- name: DeleteRating :execrows
+DELETE FROM rating
+WHERE account_id = $1
+    AND song_id = $2;
+-- name: GetAccount :one
+SELECT *
+FROM account
+WHERE id = $1
+LIMIT 1;
+-- name: GetRating :one
+SELECT stars
+FROM rating
+WHERE account_id = $1
+    AND song_id = $2
+LIMIT 1;
+-- name: GetSongRating :one
+SELECT COALESCE(AVG(stars), 0)::float8 AS rating,
+    COUNT(*)::int AS rating_count
+FROM rating
+WHERE song_id = $1;
+-- name: ListAccounts :many
+SELECT *
+FROM account
+ORDER BY id;
+-- name: SetRating :exec
+INSERT INTO rating (account_id, song_id, stars)
+VALUES ($1, $2, $3) ON CONFLICT (account_id, song_id) DO
+UPDATE
+SET stars = EXCLUDED.stars,
+    updated_at = now();
+-- name: UpsertSubjectAccount :one
+INSERT INTO account (name, subject)
+VALUES ($1, $2) ON CONFLICT (subject) DO
+UPDATE
+SET name = EXCLUDED.name
+RETURNING *;
@@ -0,0 +1,24 @@
+-- name: CreateAPIKey :one
+INSERT INTO api_key (name, role, prefix, key_hash, account_id)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+-- name: GetActiveAPIKeyByHash :one
+SELECT *
+FROM api_key
+WHERE key_hash = $1
+    AND revoked_at IS NULL
+LIMIT 1;
+-- name: GetAPIKey :one
+SELECT *
+FROM api_key
+WHERE id = $1
+LIMIT 1;
+-- name: ListAPIKeys :many
+SELECT *
+FROM api_key
+ORDER BY id;
+-- name: RevokeAPIKey :execrows
+UPDATE api_key
+SET revoked_at = now()
+WHERE id = $1
+    AND revoked_at IS NULL;
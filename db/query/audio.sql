@@ -0,0 +1,26 @@
+-- name: DeleteAudio :exec
+DELETE FROM audio
+WHERE song_id = $1;
+-- name: GetAudio :one
+SELECT audio.*
+FROM audio
+    JOIN library ON audio.song_id = library.id
+WHERE audio.song_id = $1
+    AND library.deleted_at IS NULL
+LIMIT 1;
+-- name: UpsertAudio :one
+INSERT INTO audio (
+        song_id,
+        storage_key,
+        content_type,
+        size,
+        sha256
+    )
+VALUES ($1, $2, $3, $4, $5) ON CONFLICT (song_id) DO
+UPDATE
+SET storage_key = EXCLUDED.storage_key,
+    content_type = EXCLUDED.content_type,
+    size = EXCLUDED.size,
+    sha256 = EXCLUDED.sha256,
+    uploaded_at = now()
+RETURNING *;
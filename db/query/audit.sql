@@ -0,0 +1,52 @@
+-- name: AddAuditEvent :exec
+INSERT INTO audit_log (
+        actor,
+        role,
+        api_key_id,
+        account_id,
+        request_id,
+        entity,
+        entity_id,
+        action,
+        "before",
+        "after"
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+-- name: ListAuditEvents :many
+SELECT id,
+    created_at,
+    actor,
+    role,
+    api_key_id,
+    account_id,
+    request_id,
+    entity,
+    entity_id,
+    action,
+    "before"::text AS "before",
+    "after"::text AS "after"
+FROM audit_log
+WHERE (
+        $1::varchar = ''
+        OR entity = $1
+    )
+    AND (
+        $2::int = 0
+        OR entity_id = $2
+    )
+    AND (
+        $3::varchar = ''
+        OR action = $3
+    )
+    AND (
+        $4::varchar = ''
+        OR actor = $4
+    )
+    AND (
+        $5::varchar = ''
+        OR request_id = $5
+    )
+    AND created_at >= $6
+    AND created_at < $7
+ORDER BY id DESC
+LIMIT $8 OFFSET $9;
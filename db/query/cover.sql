@@ -0,0 +1,33 @@
+-- name: AddCover :exec
+INSERT INTO cover (
+        song_id,
+        size,
+        storage_key,
+        content_type,
+        width,
+        height,
+        sha256
+    )
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+-- name: DeleteCovers :exec
+DELETE FROM cover
+WHERE song_id = $1;
+-- name: GetCover :one
+SELECT cover.*
+FROM cover
+    JOIN library ON cover.song_id = library.id
+WHERE cover.song_id = $1
+    AND cover.size = $2
+    AND library.deleted_at IS NULL
+LIMIT 1;
+-- name: ListCovers :many
+SELECT *
+FROM cover
+WHERE song_id = $1
+ORDER BY size;
+-- name: ListCoversBySongIDs :many
+SELECT *
+FROM cover
+WHERE song_id = ANY($1::int [])
+ORDER BY song_id,
+    size;
@@ -0,0 +1,137 @@
+-- name: DeleteArtist :exec
+DELETE FROM artist
+WHERE id = $1;
+-- name: DeleteMergedSong :exec
+DELETE FROM library
+WHERE id = $1;
+-- name: FillSongDetails :exec
+UPDATE library
+SET "text" = COALESCE(NULLIF(library."text", ''), source."text"),
+    link = COALESCE(NULLIF(library.link, ''), source.link)
+FROM library AS source
+WHERE source.id = $1
+    AND library.id = $2::int;
+-- name: GetArtist :one
+SELECT *
+FROM artist
+WHERE id = $1
+LIMIT 1;
+-- name: ListArtistNames :many
+SELECT artist.id,
+    artist."group",
+    COUNT(library.id)::int AS songs
+FROM artist
+    LEFT JOIN library ON library.group_id = artist.id
+    AND library.deleted_at IS NULL
+GROUP BY artist.id
+ORDER BY artist.id;
+-- name: ListArtistSongs :many
+SELECT *
+FROM library
+WHERE group_id = $1
+ORDER BY id;
+-- name: ListArtistsWithoutKey :many
+SELECT id,
+    "group"
+FROM artist
+WHERE group_key = ''
+ORDER BY id;
+-- name: ListSongNames :many
+SELECT library.id,
+    library.group_id,
+    artist."group",
+    library.song
+FROM library
+    JOIN artist ON library.group_id = artist.id
+WHERE library.deleted_at IS NULL
+ORDER BY library.id;
+-- name: ListSongsWithoutKey :many
+SELECT id,
+    song
+FROM library
+WHERE song_key = ''
+ORDER BY id;
+-- name: MergeDailyStats :exec
+INSERT INTO song_daily_stats (song_id, day, plays, views)
+SELECT $2::int,
+    day,
+    plays,
+    views
+FROM song_daily_stats
+WHERE song_id = $1 ON CONFLICT (song_id, day) DO
+UPDATE
+SET plays = song_daily_stats.plays + EXCLUDED.plays,
+    views = song_daily_stats.views + EXCLUDED.views;
+-- name: MergeFavorites :exec
+INSERT INTO favorite (account_id, song_id, created_at)
+SELECT account_id,
+    $2::int,
+    created_at
+FROM favorite
+WHERE song_id = $1 ON CONFLICT (account_id, song_id) DO NOTHING;
+-- name: MergeRatings :exec
+INSERT INTO rating (account_id, song_id, stars, updated_at)
+SELECT account_id,
+    $2::int,
+    stars,
+    updated_at
+FROM rating
+WHERE song_id = $1 ON CONFLICT (account_id, song_id) DO
+UPDATE
+SET stars = EXCLUDED.stars,
+    updated_at = EXCLUDED.updated_at
+WHERE rating.updated_at < EXCLUDED.updated_at;
+-- name: MergeSongTags :exec
+INSERT INTO song_tag (song_id, tag)
+SELECT $2::int,
+    tag
+FROM song_tag
+WHERE song_id = $1 ON CONFLICT DO NOTHING;
+-- name: MoveAudio :exec
+UPDATE audio
+SET song_id = $2::int
+WHERE song_id = $1
+    AND NOT EXISTS (
+        SELECT 1
+        FROM audio AS target
+        WHERE target.song_id = $2::int
+    );
+-- name: MoveCovers :exec
+UPDATE cover
+SET song_id = $2::int
+WHERE song_id = $1
+    AND NOT EXISTS (
+        SELECT 1
+        FROM cover AS target
+        WHERE target.song_id = $2::int
+    );
+-- name: MovePlayEvents :exec
+UPDATE play_event
+SET song_id = $2::int
+WHERE song_id = $1;
+-- name: MovePlaylistEntries :exec
+WITH moved AS (
+    UPDATE playlist_entry
+    SET song_id = $2::int
+    WHERE song_id = $1
+    RETURNING playlist_id
+)
+UPDATE playlist
+SET version = version + 1,
+    updated_at = now()
+WHERE id IN (
+        SELECT playlist_id
+        FROM moved
+    );
+-- name: MoveSong :exec
+UPDATE library
+SET group_id = $2
+WHERE id = $1;
+-- name: SetArtistKey :exec
+UPDATE artist
+SET group_key = $2
+WHERE id = $1;
+-- name: SetSongKey :exec
+UPDATE library
+SET song_key = $2
+WHERE id = $1;
@@ -0,0 +1,93 @@
+-- name: AddPlaylistEntry :one
+INSERT INTO playlist_entry (playlist_id, song_id, position)
+VALUES (
+        $1,
+        $2,
+        (
+            SELECT COALESCE(MAX(position), -1) + 1
+            FROM playlist_entry
+            WHERE playlist_id = $1
+        )
+    )
+RETURNING *;
+-- name: BumpPlaylistVersion :exec
+UPDATE playlist
+SET version = version + 1,
+    updated_at = now()
+WHERE id = $1;
+-- name: CreatePlaylist :one
+INSERT INTO playlist (account_id, name, is_public)
+VALUES ($1, $2, $3)
+RETURNING *;
+-- name: DeletePlaylist :exec
+DELETE FROM playlist
+WHERE id = $1;
+-- name: DeletePlaylistEntry :execrows
+DELETE FROM playlist_entry
+WHERE playlist_id = $1
+    AND id = $2;
+-- name: GetPlaylist :one
+SELECT *
+FROM playlist
+WHERE id = $1
+LIMIT 1;
+-- name: GetPlaylistByShareToken :one
+SELECT *
+FROM playlist
+WHERE share_token = $1
+LIMIT 1;
+-- name: ListPlaylistEntries :many
+SELECT playlist_entry.id,
+    playlist_entry.song_id,
+    artist."group",
+    library.song,
+    playlist_entry.added_at
+FROM playlist_entry
+    JOIN library ON playlist_entry.song_id = library.id
+    JOIN artist ON library.group_id = artist.id
+WHERE playlist_entry.playlist_id = $1
+    AND library.deleted_at IS NULL
+ORDER BY playlist_entry.position,
+    playlist_entry.id;
+-- name: ListPlaylistEntryIDs :many
+SELECT id
+FROM playlist_entry
+WHERE playlist_id = $1
+ORDER BY position,
+    id;
+-- name: ListPlaylistTracks :many
+SELECT library.id,
+    artist."group",
+    library.song,
+    library.link
+FROM playlist_entry
+    JOIN library ON playlist_entry.song_id = library.id
+    JOIN artist ON library.group_id = artist.id
+WHERE playlist_entry.playlist_id = $1
+    AND library.deleted_at IS NULL
+ORDER BY playlist_entry.position,
+    playlist_entry.id;
+-- name: ListPlaylists :many
+SELECT *
+FROM playlist
+WHERE account_id = $1
+ORDER BY id;
+-- name: LockPlaylist :one
+SELECT *
+FROM playlist
+WHERE id = $1 FOR
+UPDATE;
+-- name: SetPlaylistEntryPosition :exec
+UPDATE playlist_entry
+SET position = $3
+WHERE playlist_id = $1
+    AND id = $2;
+-- name: SetPlaylistShareToken :exec
+UPDATE playlist
+SET share_token = $2
+WHERE id = $1;
+-- name: UpdatePlaylist :exec
+UPDATE playlist
+SET name = $2,
+    is_public = $3
+WHERE id = $1;
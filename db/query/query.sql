@@ -1,36 +1,85 @@
 -- name: AddArtist :one
-INSERT INTO artist ("group")
-VALUES ($1)
+INSERT INTO artist ("group", group_key)
+VALUES ($1, $2)
 RETURNING *;
 -- name: AddSongWithID :one
-INSERT INTO library (group_id, "song")
-VALUES ($1, $2)
+INSERT INTO library (group_id, "song", song_key)
+VALUES ($1, $2, $3)
 RETURNING *;
 -- name: CheckSongWithID :one
 SELECT EXISTS (
         SELECT 1
         FROM library
         WHERE group_id = $1
-            AND song = $2
+            AND song_key = $2
+            AND deleted_at IS NULL
     );
--- name: Delete :exec
-DELETE FROM library
-WHERE id = $1;
+-- name: Delete :execrows
+UPDATE library
+SET deleted_at = now()
+WHERE id = $1
+    AND deleted_at IS NULL;
+-- name: ExportWithFilters :many
+SELECT library.id,
+    artist."group",
+    library.song,
+    library."releaseDate",
+    library.text,
+    library.link
+FROM library
+    JOIN artist ON library.group_id = artist.id
+WHERE library.id > $1
+    AND library.deleted_at IS NULL
+    AND (
+        artist."group" ILIKE '%' || $2 || '%'
+        OR $2 IS NULL
+    )
+    AND (
+        library.song ILIKE '%' || $3 || '%'
+        OR $3 IS NULL
+    )
+    AND (
+        library."releaseDate" >= $4
+        OR $4 IS NULL
+    )
+    AND (
+        library."text" ILIKE '%' || $5 || '%'
+        OR $5 IS NULL
+    )
+ORDER BY library.id
+LIMIT $6;
 -- name: Fetch :exec
 UPDATE library
 SET "releaseDate" = $2,
     text = $3,
     link = $4
-WHERE id = $1;
+WHERE id = $1
+    AND deleted_at IS NULL;
 -- name: GetArtistID :one
 SELECT id
 FROM artist
-WHERE "group" = $1
+WHERE group_key = $1
+ORDER BY id
+LIMIT 1;
+-- name: GetDeleted :one
+SELECT *
+FROM library
+WHERE id = $1
+    AND deleted_at IS NOT NULL
 LIMIT 1;
 -- name: GetOne :one
 SELECT *
 FROM library
 WHERE id = $1
+    AND deleted_at IS NULL
+LIMIT 1;
+-- name: GetSongID :one
+SELECT id
+FROM library
+WHERE group_id = $1
+    AND song_key = $2
+    AND deleted_at IS NULL
+ORDER BY id
 LIMIT 1;
 -- name: GetText :one
 SELECT library.id,
@@ -40,8 +89,9 @@ SELECT library.id,
 FROM library
     JOIN artist ON library.group_id = artist.id
 WHERE library.id = $1
+    AND library.deleted_at IS NULL
 LIMIT 1;
--- name: ListWithFilters :many 
+-- name: ListByIDs :many
 SELECT library.id,
     artist."group",
     library.song,
@@ -50,7 +100,54 @@ SELECT library.id,
     library.link
 FROM library
     JOIN artist ON library.group_id = artist.id
-WHERE (
+WHERE library.id = ANY($1::int [])
+    AND library.deleted_at IS NULL
+ORDER BY library.id;
+-- name: ListDeleted :many
+SELECT library.id,
+    artist."group",
+    library.song,
+    library.deleted_at::timestamptz AS deleted_at
+FROM library
+    JOIN artist ON library.group_id = artist.id
+WHERE library.deleted_at IS NOT NULL
+ORDER BY library.deleted_at DESC,
+    library.id DESC
+LIMIT $1 OFFSET $2;
+-- name: ListExpiredDeleted :many
+SELECT id
+FROM library
+WHERE deleted_at < $1
+ORDER BY deleted_at
+LIMIT $2;
+-- name: ListSongStorageKeys :many
+SELECT storage_key
+FROM audio
+WHERE song_id = $1
+UNION ALL
+SELECT storage_key
+FROM cover
+WHERE song_id = $1;
+-- name: ListWithFilters :many 
+SELECT library.id,
+    artist."group",
+    library.song,
+    library."releaseDate",
+    library.text,
+    library.link,
+    COALESCE(rated.rating, 0)::float8 AS rating,
+    COALESCE(rated.rating_count, 0)::int AS rating_count
+FROM library
+    JOIN artist ON library.group_id = artist.id
+    LEFT JOIN (
+        SELECT song_id,
+            AVG(stars) AS rating,
+            COUNT(*) AS rating_count
+        FROM rating
+        GROUP BY song_id
+    ) rated ON rated.song_id = library.id
+WHERE library.deleted_at IS NULL
+    AND (
         artist."group" ILIKE '%' || $1 || '%'
         OR $1 IS NULL
     )
@@ -66,8 +163,29 @@ WHERE (
         library."text" ILIKE '%' || $4 || '%'
         OR $4 IS NULL
     )
-ORDER BY library.id
+    AND (
+        $7::int = 0
+        OR EXISTS (
+            SELECT 1
+            FROM favorite
+            WHERE favorite.song_id = library.id
+                AND favorite.account_id = $7::int
+        )
+    )
+ORDER BY CASE
+        WHEN $8::bool THEN COALESCE(rated.rating, 0)
+    END DESC,
+    library.id
 LIMIT $5 OFFSET $6;
+-- name: Purge :execrows
+DELETE FROM library
+WHERE id = $1
+    AND deleted_at IS NOT NULL;
+-- name: Restore :execrows
+UPDATE library
+SET deleted_at = NULL
+WHERE id = $1
+    AND deleted_at IS NOT NULL;
 -- name: Update :exec
 UPDATE library
 SET "releaseDate" = COALESCE(
@@ -76,4 +194,5 @@ SET "releaseDate" = COALESCE(
     ),
     "text" = COALESCE(NULLIF($3, ''), "text"),
     link = COALESCE(NULLIF($4, ''), link)
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1
+    AND deleted_at IS NULL;
\ No newline at end of file
@@ -0,0 +1,56 @@
+-- name: AddPlayEvent :exec
+INSERT INTO play_event (song_id, account_id, kind)
+VALUES ($1, $2, $3);
+-- name: IncrementDailyStats :exec
+INSERT INTO song_daily_stats (song_id, day, plays, views)
+VALUES ($1, CURRENT_DATE, $2, $3) ON CONFLICT (song_id, day) DO
+UPDATE
+SET plays = song_daily_stats.plays + EXCLUDED.plays,
+    views = song_daily_stats.views + EXCLUDED.views;
+-- name: ListPlayHistory :many
+SELECT play_event.id,
+    play_event.song_id,
+    artist."group",
+    library.song,
+    play_event.kind,
+    play_event.played_at
+FROM play_event
+    JOIN library ON play_event.song_id = library.id
+    JOIN artist ON library.group_id = artist.id
+WHERE play_event.account_id = $1
+    AND library.deleted_at IS NULL
+ORDER BY play_event.played_at DESC,
+    play_event.id DESC
+LIMIT $2 OFFSET $3;
+-- name: TopArtists :many
+SELECT artist.id,
+    artist."group",
+    SUM(song_daily_stats.plays)::bigint AS plays,
+    SUM(song_daily_stats.views)::bigint AS views
+FROM song_daily_stats
+    JOIN library ON song_daily_stats.song_id = library.id
+    JOIN artist ON library.group_id = artist.id
+WHERE song_daily_stats.day >= $1
+    AND library.deleted_at IS NULL
+GROUP BY artist.id,
+    artist."group"
+ORDER BY SUM(song_daily_stats.plays + song_daily_stats.views) DESC,
+    artist.id
+LIMIT $2;
+-- name: TopSongs :many
+SELECT library.id,
+    artist."group",
+    library.song,
+    SUM(song_daily_stats.plays)::bigint AS plays,
+    SUM(song_daily_stats.views)::bigint AS views
+FROM song_daily_stats
+    JOIN library ON song_daily_stats.song_id = library.id
+    JOIN artist ON library.group_id = artist.id
+WHERE song_daily_stats.day >= $1
+    AND library.deleted_at IS NULL
+GROUP BY library.id,
+    artist."group",
+    library.song
+ORDER BY SUM(song_daily_stats.plays + song_daily_stats.views) DESC,
+    library.id
+LIMIT $2;
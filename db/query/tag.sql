@@ -0,0 +1,27 @@
+-- name: AddSongTag :exec
+INSERT INTO song_tag (song_id, tag)
+VALUES ($1, $2) ON CONFLICT DO NOTHING;
+-- name: DeleteSongTags :exec
+DELETE FROM song_tag
+WHERE song_id = $1;
+-- name: ListSongTags :many
+SELECT tag
+FROM song_tag
+WHERE song_id = $1
+ORDER BY tag;
+-- name: ListAllSongTags :many
+SELECT song_id, tag
+FROM song_tag
+ORDER BY song_id,
+    tag;
+-- name: ListSongFeatures :many
+SELECT library.id,
+    library.group_id,
+    artist."group",
+    library.song,
+    library."releaseDate",
+    library.text
+FROM library
+    JOIN artist ON library.group_id = artist.id
+WHERE library.deleted_at IS NULL
+ORDER BY library.id;
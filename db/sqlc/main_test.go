@@ -23,13 +23,7 @@ func TestMain(m *testing.M) {
 	}
 
 	// Конфигурируем путь для подключения к PostgreSQL.
-	dbURL := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.DatabaseUser,
-		cfg.DatabasePassword,
-		cfg.DatabaseHost,
-		cfg.DatabasePort,
-		cfg.DatabaseName,
-	)
+	dbURL := cfg.DatabaseURL()
 
 	connect, errConn := sql.Open(cfg.DatabaseDriver, dbURL)
 	if errConn != nil {
@@ -5,12 +5,81 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 )
 
+type Account struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+	// SSO subject (sub claim), NULL for accounts created by an admin
+	Subject   sql.NullString `json:"subject"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type ApiKey struct {
+	ID     int32  `json:"id"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Prefix string `json:"prefix"`
+	// SHA-256 of the key, the key itself is never stored
+	KeyHash   string        `json:"key_hash"`
+	CreatedAt time.Time     `json:"created_at"`
+	RevokedAt sql.NullTime  `json:"revoked_at"`
+	AccountID sql.NullInt32 `json:"account_id"`
+}
+
 type Artist struct {
 	ID    int32  `json:"id"`
 	Group string `json:"group"`
+	// Group name for uniqueness checks: NFKC, case folded, whitespace trimmed and collapsed. Empty keys are filled by the application, SQL lower() does not match Unicode case folding
+	GroupKey string `json:"group_key"`
+}
+
+type AuditLog struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// user:<SSO subject>, key:<API key name> or system
+	Actor     string        `json:"actor"`
+	Role      string        `json:"role"`
+	ApiKeyID  sql.NullInt32 `json:"api_key_id"`
+	AccountID sql.NullInt32 `json:"account_id"`
+	RequestID string        `json:"request_id"`
+	Entity    string        `json:"entity"`
+	EntityID  int32         `json:"entity_id"`
+	Action    string        `json:"action"`
+	// Entity state before the change, null for created entities
+	Before json.RawMessage `json:"before"`
+	// Entity state after the change, null for deleted entities
+	After json.RawMessage `json:"after"`
+}
+
+type Audio struct {
+	SongID      int32     `json:"song_id"`
+	StorageKey  string    `json:"storage_key"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	Sha256      string    `json:"sha256"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+type Cover struct {
+	SongID int32 `json:"song_id"`
+	// Thumbnail bounding box in pixels, 0 for the original image
+	Size        int32     `json:"size"`
+	StorageKey  string    `json:"storage_key"`
+	ContentType string    `json:"content_type"`
+	Width       int32     `json:"width"`
+	Height      int32     `json:"height"`
+	Sha256      string    `json:"sha256"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+type Favorite struct {
+	AccountID int32     `json:"account_id"`
+	SongID    int32     `json:"song_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Library struct {
@@ -20,4 +89,57 @@ type Library struct {
 	ReleaseDate time.Time `json:"releaseDate"`
 	Text        string    `json:"text"`
 	Link        string    `json:"link"`
+	// Time the song was moved to the trash, NULL for active songs
+	DeletedAt sql.NullTime `json:"deleted_at"`
+	// Song title for uniqueness checks: NFKC, case folded, whitespace trimmed and collapsed. Empty keys are filled by the application, SQL lower() does not match Unicode case folding
+	SongKey string `json:"song_key"`
+}
+
+type PlayEvent struct {
+	ID        int64         `json:"id"`
+	SongID    int32         `json:"song_id"`
+	AccountID sql.NullInt32 `json:"account_id"`
+	Kind      string        `json:"kind"`
+	PlayedAt  time.Time     `json:"played_at"`
+}
+
+type Playlist struct {
+	ID         int32          `json:"id"`
+	AccountID  int32          `json:"account_id"`
+	Name       string         `json:"name"`
+	IsPublic   bool           `json:"is_public"`
+	ShareToken sql.NullString `json:"share_token"`
+	// Incremented on every change, used for optimistic concurrency
+	Version   int32     `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type PlaylistEntry struct {
+	ID         int32 `json:"id"`
+	PlaylistID int32 `json:"playlist_id"`
+	SongID     int32 `json:"song_id"`
+	// Sort key within the playlist, gaps are allowed after song deletion
+	Position int32     `json:"position"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+type Rating struct {
+	AccountID int32     `json:"account_id"`
+	SongID    int32     `json:"song_id"`
+	Stars     int16     `json:"stars"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SongDailyStat struct {
+	SongID int32     `json:"song_id"`
+	Day    time.Time `json:"day"`
+	Plays  int32     `json:"plays"`
+	// Song page and couplet page views
+	Views int32 `json:"views"`
+}
+
+type SongTag struct {
+	SongID int32  `json:"song_id"`
+	Tag    string `json:"tag"`
 }
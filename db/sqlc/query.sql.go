@@ -9,34 +9,42 @@ import (
 	"context"
 	"database/sql"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 const addArtist = `-- name: AddArtist :one
-INSERT INTO artist ("group")
-VALUES ($1)
-RETURNING id, "group"
+INSERT INTO artist ("group", group_key)
+VALUES ($1, $2)
+RETURNING id, "group", group_key
 `
 
-func (q *Queries) AddArtist(ctx context.Context, group string) (Artist, error) {
-	row := q.db.QueryRowContext(ctx, addArtist, group)
+type AddArtistParams struct {
+	Group    string `json:"group"`
+	GroupKey string `json:"group_key"`
+}
+
+func (q *Queries) AddArtist(ctx context.Context, arg AddArtistParams) (Artist, error) {
+	row := q.db.QueryRowContext(ctx, addArtist, arg.Group, arg.GroupKey)
 	var i Artist
-	err := row.Scan(&i.ID, &i.Group)
+	err := row.Scan(&i.ID, &i.Group, &i.GroupKey)
 	return i, err
 }
 
 const addSongWithID = `-- name: AddSongWithID :one
-INSERT INTO library (group_id, "song")
-VALUES ($1, $2)
-RETURNING id, group_id, song, "releaseDate", text, link
+INSERT INTO library (group_id, "song", song_key)
+VALUES ($1, $2, $3)
+RETURNING id, group_id, song, "releaseDate", text, link, deleted_at, song_key
 `
 
 type AddSongWithIDParams struct {
 	GroupID int32  `json:"group_id"`
 	Song    string `json:"song"`
+	SongKey string `json:"song_key"`
 }
 
 func (q *Queries) AddSongWithID(ctx context.Context, arg AddSongWithIDParams) (Library, error) {
-	row := q.db.QueryRowContext(ctx, addSongWithID, arg.GroupID, arg.Song)
+	row := q.db.QueryRowContext(ctx, addSongWithID, arg.GroupID, arg.Song, arg.SongKey)
 	var i Library
 	err := row.Scan(
 		&i.ID,
@@ -45,6 +53,8 @@ func (q *Queries) AddSongWithID(ctx context.Context, arg AddSongWithIDParams) (L
 		&i.ReleaseDate,
 		&i.Text,
 		&i.Link,
+		&i.DeletedAt,
+		&i.SongKey,
 	)
 	return i, err
 }
@@ -54,30 +64,122 @@ SELECT EXISTS (
         SELECT 1
         FROM library
         WHERE group_id = $1
-            AND song = $2
+            AND song_key = $2
+            AND deleted_at IS NULL
     )
 `
 
 type CheckSongWithIDParams struct {
 	GroupID int32  `json:"group_id"`
-	Song    string `json:"song"`
+	SongKey string `json:"song_key"`
 }
 
 func (q *Queries) CheckSongWithID(ctx context.Context, arg CheckSongWithIDParams) (bool, error) {
-	row := q.db.QueryRowContext(ctx, checkSongWithID, arg.GroupID, arg.Song)
+	row := q.db.QueryRowContext(ctx, checkSongWithID, arg.GroupID, arg.SongKey)
 	var exists bool
 	err := row.Scan(&exists)
 	return exists, err
 }
 
-const delete = `-- name: Delete :exec
-DELETE FROM library
+const delete = `-- name: Delete :execrows
+UPDATE library
+SET deleted_at = now()
 WHERE id = $1
+    AND deleted_at IS NULL
 `
 
-func (q *Queries) Delete(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, delete, id)
-	return err
+func (q *Queries) Delete(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, delete, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const exportWithFilters = `-- name: ExportWithFilters :many
+SELECT library.id,
+    artist."group",
+    library.song,
+    library."releaseDate",
+    library.text,
+    library.link
+FROM library
+    JOIN artist ON library.group_id = artist.id
+WHERE library.id > $1
+    AND library.deleted_at IS NULL
+    AND (
+        artist."group" ILIKE '%' || $2 || '%'
+        OR $2 IS NULL
+    )
+    AND (
+        library.song ILIKE '%' || $3 || '%'
+        OR $3 IS NULL
+    )
+    AND (
+        library."releaseDate" >= $4
+        OR $4 IS NULL
+    )
+    AND (
+        library."text" ILIKE '%' || $5 || '%'
+        OR $5 IS NULL
+    )
+ORDER BY library.id
+LIMIT $6
+`
+
+type ExportWithFiltersParams struct {
+	ID          int32          `json:"id"`
+	Column2     sql.NullString `json:"column_2"`
+	Column3     sql.NullString `json:"column_3"`
+	ReleaseDate time.Time      `json:"releaseDate"`
+	Column5     sql.NullString `json:"column_5"`
+	Limit       int32          `json:"limit"`
+}
+
+type ExportWithFiltersRow struct {
+	ID          int32     `json:"id"`
+	Group       string    `json:"group"`
+	Song        string    `json:"song"`
+	ReleaseDate time.Time `json:"releaseDate"`
+	Text        string    `json:"text"`
+	Link        string    `json:"link"`
+}
+
+func (q *Queries) ExportWithFilters(ctx context.Context, arg ExportWithFiltersParams) ([]ExportWithFiltersRow, error) {
+	rows, err := q.db.QueryContext(ctx, exportWithFilters,
+		arg.ID,
+		arg.Column2,
+		arg.Column3,
+		arg.ReleaseDate,
+		arg.Column5,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExportWithFiltersRow
+	for rows.Next() {
+		var i ExportWithFiltersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Group,
+			&i.Song,
+			&i.ReleaseDate,
+			&i.Text,
+			&i.Link,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const fetch = `-- name: Fetch :exec
@@ -86,6 +188,7 @@ SET "releaseDate" = $2,
     text = $3,
     link = $4
 WHERE id = $1
+    AND deleted_at IS NULL
 `
 
 type FetchParams struct {
@@ -108,21 +211,47 @@ func (q *Queries) Fetch(ctx context.Context, arg FetchParams) error {
 const getArtistID = `-- name: GetArtistID :one
 SELECT id
 FROM artist
-WHERE "group" = $1
+WHERE group_key = $1
+ORDER BY id
 LIMIT 1
 `
 
-func (q *Queries) GetArtistID(ctx context.Context, group string) (int32, error) {
-	row := q.db.QueryRowContext(ctx, getArtistID, group)
+func (q *Queries) GetArtistID(ctx context.Context, groupKey string) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getArtistID, groupKey)
 	var id int32
 	err := row.Scan(&id)
 	return id, err
 }
 
+const getDeleted = `-- name: GetDeleted :one
+SELECT id, group_id, song, "releaseDate", text, link, deleted_at, song_key
+FROM library
+WHERE id = $1
+    AND deleted_at IS NOT NULL
+LIMIT 1
+`
+
+func (q *Queries) GetDeleted(ctx context.Context, id int32) (Library, error) {
+	row := q.db.QueryRowContext(ctx, getDeleted, id)
+	var i Library
+	err := row.Scan(
+		&i.ID,
+		&i.GroupID,
+		&i.Song,
+		&i.ReleaseDate,
+		&i.Text,
+		&i.Link,
+		&i.DeletedAt,
+		&i.SongKey,
+	)
+	return i, err
+}
+
 const getOne = `-- name: GetOne :one
-SELECT id, group_id, song, "releaseDate", text, link
+SELECT id, group_id, song, "releaseDate", text, link, deleted_at, song_key
 FROM library
 WHERE id = $1
+    AND deleted_at IS NULL
 LIMIT 1
 `
 
@@ -136,10 +265,34 @@ func (q *Queries) GetOne(ctx context.Context, id int32) (Library, error) {
 		&i.ReleaseDate,
 		&i.Text,
 		&i.Link,
+		&i.DeletedAt,
+		&i.SongKey,
 	)
 	return i, err
 }
 
+const getSongID = `-- name: GetSongID :one
+SELECT id
+FROM library
+WHERE group_id = $1
+    AND song_key = $2
+    AND deleted_at IS NULL
+ORDER BY id
+LIMIT 1
+`
+
+type GetSongIDParams struct {
+	GroupID int32  `json:"group_id"`
+	SongKey string `json:"song_key"`
+}
+
+func (q *Queries) GetSongID(ctx context.Context, arg GetSongIDParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getSongID, arg.GroupID, arg.SongKey)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
 const getText = `-- name: GetText :one
 SELECT library.id,
     artist."group",
@@ -148,6 +301,7 @@ SELECT library.id,
 FROM library
     JOIN artist ON library.group_id = artist.id
 WHERE library.id = $1
+    AND library.deleted_at IS NULL
 LIMIT 1
 `
 
@@ -170,7 +324,7 @@ func (q *Queries) GetText(ctx context.Context, id int32) (GetTextRow, error) {
 	return i, err
 }
 
-const listWithFilters = `-- name: ListWithFilters :many
+const listByIDs = `-- name: ListByIDs :many
 SELECT library.id,
     artist."group",
     library.song,
@@ -179,7 +333,192 @@ SELECT library.id,
     library.link
 FROM library
     JOIN artist ON library.group_id = artist.id
-WHERE (
+WHERE library.id = ANY($1::int [])
+    AND library.deleted_at IS NULL
+ORDER BY library.id
+`
+
+type ListByIDsRow struct {
+	ID          int32     `json:"id"`
+	Group       string    `json:"group"`
+	Song        string    `json:"song"`
+	ReleaseDate time.Time `json:"releaseDate"`
+	Text        string    `json:"text"`
+	Link        string    `json:"link"`
+}
+
+func (q *Queries) ListByIDs(ctx context.Context, dollar_1 []int32) ([]ListByIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listByIDs, pq.Array(dollar_1))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListByIDsRow
+	for rows.Next() {
+		var i ListByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Group,
+			&i.Song,
+			&i.ReleaseDate,
+			&i.Text,
+			&i.Link,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeleted = `-- name: ListDeleted :many
+SELECT library.id,
+    artist."group",
+    library.song,
+    library.deleted_at::timestamptz AS deleted_at
+FROM library
+    JOIN artist ON library.group_id = artist.id
+WHERE library.deleted_at IS NOT NULL
+ORDER BY library.deleted_at DESC,
+    library.id DESC
+LIMIT $1 OFFSET $2
+`
+
+type ListDeletedParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListDeletedRow struct {
+	ID        int32     `json:"id"`
+	Group     string    `json:"group"`
+	Song      string    `json:"song"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func (q *Queries) ListDeleted(ctx context.Context, arg ListDeletedParams) ([]ListDeletedRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDeleted, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDeletedRow
+	for rows.Next() {
+		var i ListDeletedRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Group,
+			&i.Song,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExpiredDeleted = `-- name: ListExpiredDeleted :many
+SELECT id
+FROM library
+WHERE deleted_at < $1
+ORDER BY deleted_at
+LIMIT $2
+`
+
+type ListExpiredDeletedParams struct {
+	DeletedAt sql.NullTime `json:"deleted_at"`
+	Limit     int32        `json:"limit"`
+}
+
+func (q *Queries) ListExpiredDeleted(ctx context.Context, arg ListExpiredDeletedParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, listExpiredDeleted, arg.DeletedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSongStorageKeys = `-- name: ListSongStorageKeys :many
+SELECT storage_key
+FROM audio
+WHERE song_id = $1
+UNION ALL
+SELECT storage_key
+FROM cover
+WHERE song_id = $1
+`
+
+func (q *Queries) ListSongStorageKeys(ctx context.Context, songID int32) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listSongStorageKeys, songID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var storage_key string
+		if err := rows.Scan(&storage_key); err != nil {
+			return nil, err
+		}
+		items = append(items, storage_key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWithFilters = `-- name: ListWithFilters :many
+SELECT library.id,
+    artist."group",
+    library.song,
+    library."releaseDate",
+    library.text,
+    library.link,
+    COALESCE(rated.rating, 0)::float8 AS rating,
+    COALESCE(rated.rating_count, 0)::int AS rating_count
+FROM library
+    JOIN artist ON library.group_id = artist.id
+    LEFT JOIN (
+        SELECT song_id,
+            AVG(stars) AS rating,
+            COUNT(*) AS rating_count
+        FROM rating
+        GROUP BY song_id
+    ) rated ON rated.song_id = library.id
+WHERE library.deleted_at IS NULL
+    AND (
         artist."group" ILIKE '%' || $1 || '%'
         OR $1 IS NULL
     )
@@ -195,7 +534,19 @@ WHERE (
         library."text" ILIKE '%' || $4 || '%'
         OR $4 IS NULL
     )
-ORDER BY library.id
+    AND (
+        $7::int = 0
+        OR EXISTS (
+            SELECT 1
+            FROM favorite
+            WHERE favorite.song_id = library.id
+                AND favorite.account_id = $7::int
+        )
+    )
+ORDER BY CASE
+        WHEN $8::bool THEN COALESCE(rated.rating, 0)
+    END DESC,
+    library.id
 LIMIT $5 OFFSET $6
 `
 
@@ -206,6 +557,8 @@ type ListWithFiltersParams struct {
 	Column4     sql.NullString `json:"column_4"`
 	Limit       int32          `json:"limit"`
 	Offset      int32          `json:"offset"`
+	Column7     int32          `json:"column_7"`
+	Column8     bool           `json:"column_8"`
 }
 
 type ListWithFiltersRow struct {
@@ -215,6 +568,8 @@ type ListWithFiltersRow struct {
 	ReleaseDate time.Time `json:"releaseDate"`
 	Text        string    `json:"text"`
 	Link        string    `json:"link"`
+	Rating      float64   `json:"rating"`
+	RatingCount int32     `json:"rating_count"`
 }
 
 func (q *Queries) ListWithFilters(ctx context.Context, arg ListWithFiltersParams) ([]ListWithFiltersRow, error) {
@@ -225,6 +580,8 @@ func (q *Queries) ListWithFilters(ctx context.Context, arg ListWithFiltersParams
 		arg.Column4,
 		arg.Limit,
 		arg.Offset,
+		arg.Column7,
+		arg.Column8,
 	)
 	if err != nil {
 		return nil, err
@@ -240,6 +597,8 @@ func (q *Queries) ListWithFilters(ctx context.Context, arg ListWithFiltersParams
 			&i.ReleaseDate,
 			&i.Text,
 			&i.Link,
+			&i.Rating,
+			&i.RatingCount,
 		); err != nil {
 			return nil, err
 		}
@@ -254,6 +613,35 @@ func (q *Queries) ListWithFilters(ctx context.Context, arg ListWithFiltersParams
 	return items, nil
 }
 
+const purge = `-- name: Purge :execrows
+DELETE FROM library
+WHERE id = $1
+    AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) Purge(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purge, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const restore = `-- name: Restore :execrows
+UPDATE library
+SET deleted_at = NULL
+WHERE id = $1
+    AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) Restore(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restore, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const update = `-- name: Update :exec
 UPDATE library
 SET "releaseDate" = COALESCE(
@@ -263,6 +651,7 @@ SET "releaseDate" = COALESCE(
     "text" = COALESCE(NULLIF($3, ''), "text"),
     link = COALESCE(NULLIF($4, ''), link)
 WHERE id = $1
+    AND deleted_at IS NULL
 `
 
 type UpdateParams struct {
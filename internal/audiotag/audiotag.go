@@ -0,0 +1,59 @@
+// Package audiotag читает теги аудиофайлов: ID3v2 (MP3) и Vorbis comment (FLAC).
+// Читается только начало файла, содержащее теги, сами аудиоданные не загружаются.
+package audiotag
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+// maxTagSize ограничивает размер читаемых тегов, чтобы повреждённый файл не занял всю память.
+const maxTagSize = 64 << 20
+
+// ErrUnsupportedFormat возвращается, если файл не содержит тегов ID3v2 или не является FLAC.
+var ErrUnsupportedFormat = errors.New("unsupported audio format or no tags found")
+
+// Tags теги аудиофайла. Каждое поле содержит все найденные значения,
+// поэтому расходящиеся значения одного тега можно обнаружить и сообщить о них.
+type Tags struct {
+	Format string   // формат тегов: id3v2.2, id3v2.3, id3v2.4 или flac
+	Artist []string // исполнитель (TPE1, ARTIST)
+	Title  []string // название (TIT2, TITLE)
+	Date   []string // год или дата выпуска (TYER, TDRC, DATE, YEAR)
+	Lyrics []string // несинхронизированный текст (USLT, LYRICS, UNSYNCEDLYRICS)
+}
+
+// Read определяет формат по сигнатуре и читает теги из начала r.
+func Read(r io.Reader) (Tags, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := br.Peek(4)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return Tags{}, ErrUnsupportedFormat
+		}
+		return Tags{}, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte("ID3")):
+		return readID3v2(br)
+	case bytes.Equal(magic, []byte("fLaC")):
+		return readFLAC(br)
+	default:
+		return Tags{}, ErrUnsupportedFormat
+	}
+}
+
+// appendValues добавляет непустые значения к полю тегов.
+func appendValues(dst []string, values ...string) []string {
+	for _, v := range values {
+		if v = strings.TrimRight(v, "\x00"); strings.TrimSpace(v) != "" {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
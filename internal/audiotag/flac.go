@@ -0,0 +1,99 @@
+package audiotag
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Типы блоков метаданных FLAC.
+const (
+	flacBlockVorbisComment = 4
+	flacLastBlock          = 0x80
+)
+
+// readFLAC читает блок VORBIS_COMMENT из метаданных FLAC, пропуская остальные блоки.
+func readFLAC(r io.Reader) (Tags, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return Tags{}, fmt.Errorf("failed to read FLAC marker: %w", err)
+	}
+
+	tags := Tags{Format: "flac"}
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return Tags{}, fmt.Errorf("failed to read FLAC metadata block: %w", err)
+		}
+		blockType := header[0] &^ flacLastBlock
+		size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if blockType == flacBlockVorbisComment {
+			block := make([]byte, size)
+			if _, err := io.ReadFull(r, block); err != nil {
+				return Tags{}, fmt.Errorf("failed to read FLAC vorbis comment: %w", err)
+			}
+			if err := parseVorbisComment(block, &tags); err != nil {
+				return Tags{}, err
+			}
+		} else if _, err := io.CopyN(io.Discard, r, size); err != nil {
+			return Tags{}, fmt.Errorf("failed to skip FLAC metadata block: %w", err)
+		}
+
+		if header[0]&flacLastBlock != 0 {
+			return tags, nil
+		}
+	}
+}
+
+// parseVorbisComment разбирает блок Vorbis comment: строка производителя и список "КЛЮЧ=значение".
+func parseVorbisComment(block []byte, tags *Tags) error {
+	next := func() (string, error) {
+		if len(block) < 4 {
+			return "", fmt.Errorf("vorbis comment is truncated")
+		}
+		n := binary.LittleEndian.Uint32(block[:4])
+		block = block[4:]
+		if uint64(n) > uint64(len(block)) {
+			return "", fmt.Errorf("vorbis comment is truncated")
+		}
+		s := string(block[:n])
+		block = block[n:]
+		return s, nil
+	}
+
+	// Строка производителя кодировщика не нужна.
+	if _, err := next(); err != nil {
+		return err
+	}
+	if len(block) < 4 {
+		return fmt.Errorf("vorbis comment is truncated")
+	}
+	count := binary.LittleEndian.Uint32(block[:4])
+	block = block[4:]
+
+	for i := uint32(0); i < count; i++ {
+		comment, err := next()
+		if err != nil {
+			return err
+		}
+		key, value, found := strings.Cut(comment, "=")
+		if !found {
+			continue
+		}
+
+		switch strings.ToUpper(key) {
+		case "ARTIST":
+			tags.Artist = appendValues(tags.Artist, value)
+		case "TITLE":
+			tags.Title = appendValues(tags.Title, value)
+		case "DATE", "YEAR":
+			tags.Date = appendValues(tags.Date, value)
+		case "LYRICS", "UNSYNCEDLYRICS":
+			tags.Lyrics = appendValues(tags.Lyrics, strings.ReplaceAll(value, "\r\n", "\n"))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,259 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// Флаги заголовка и фреймов ID3v2.
+const (
+	id3FlagUnsync         = 0x80
+	id3FlagExtendedHeader = 0x40
+	id3FlagFooter         = 0x10
+
+	id3v24FrameCompressed = 0x08
+	id3v24FrameEncrypted  = 0x04
+	id3v24FrameUnsync     = 0x02
+	id3v24FrameDataLength = 0x01
+
+	id3v23FrameCompressed = 0x80
+	id3v23FrameEncrypted  = 0x40
+)
+
+// Кодировки текста во фреймах ID3v2.
+const (
+	encISO88591 = 0
+	encUTF16    = 1
+	encUTF16BE  = 2
+	encUTF8     = 3
+)
+
+// readID3v2 читает тег ID3v2 версий 2.2, 2.3 и 2.4.
+func readID3v2(r io.Reader) (Tags, error) {
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Tags{}, fmt.Errorf("failed to read ID3v2 header: %w", err)
+	}
+
+	version := header[3]
+	if version < 2 || version > 4 {
+		return Tags{}, fmt.Errorf("unsupported ID3v2 version 2.%d", version)
+	}
+	flags := header[5]
+	size := syncsafe(header[6:10])
+	if size > maxTagSize {
+		return Tags{}, fmt.Errorf("ID3v2 tag is too large: %d bytes", size)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return Tags{}, fmt.Errorf("failed to read ID3v2 tag: %w", err)
+	}
+
+	// В версиях 2.2 и 2.3 рассинхронизация применяется ко всему тегу целиком.
+	if flags&id3FlagUnsync != 0 && version < 4 {
+		body = removeUnsync(body)
+	}
+
+	if flags&id3FlagExtendedHeader != 0 && version > 2 {
+		var err error
+		if body, err = skipExtendedHeader(body, version); err != nil {
+			return Tags{}, err
+		}
+	}
+
+	tags := Tags{Format: fmt.Sprintf("id3v2.%d", version)}
+	for len(body) > 0 {
+		id, data, rest, ok := nextFrame(body, version)
+		if !ok {
+			break
+		}
+		body = rest
+
+		switch id {
+		case "TPE1", "TP1":
+			tags.Artist = appendValues(tags.Artist, decodeTextFrame(data)...)
+		case "TIT2", "TT2":
+			tags.Title = appendValues(tags.Title, decodeTextFrame(data)...)
+		case "TYER", "TYE", "TDRC":
+			tags.Date = appendValues(tags.Date, decodeTextFrame(data)...)
+		case "USLT", "ULT":
+			if lyrics, okLyrics := decodeLyricsFrame(data); okLyrics {
+				tags.Lyrics = appendValues(tags.Lyrics, lyrics)
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+// nextFrame выделяет очередной фрейм из тела тега. Возвращает ok == false,
+// если достигнут padding или данные повреждены.
+func nextFrame(body []byte, version byte) (id string, data, rest []byte, ok bool) {
+	headerSize, idSize := 10, 4
+	if version == 2 {
+		headerSize, idSize = 6, 3
+	}
+	if len(body) < headerSize || body[0] == 0 {
+		return "", nil, nil, false
+	}
+
+	id = string(body[:idSize])
+	var size int
+	var formatFlags byte
+	var skip bool
+	switch version {
+	case 2:
+		size = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+	case 3:
+		size = int(binary.BigEndian.Uint32(body[4:8]))
+		skip = body[9]&(id3v23FrameCompressed|id3v23FrameEncrypted) != 0
+	default:
+		size = syncsafe(body[4:8])
+		formatFlags = body[9]
+		skip = formatFlags&(id3v24FrameCompressed|id3v24FrameEncrypted) != 0
+	}
+
+	if size < 0 || size > len(body)-headerSize {
+		return "", nil, nil, false
+	}
+	data = body[headerSize : headerSize+size]
+	rest = body[headerSize+size:]
+
+	// Сжатые и зашифрованные фреймы пропускаем, оставляя пустые данные.
+	if skip {
+		return id, nil, rest, true
+	}
+	if formatFlags&id3v24FrameDataLength != 0 {
+		if len(data) < 4 {
+			return id, nil, rest, true
+		}
+		data = data[4:]
+	}
+	if formatFlags&id3v24FrameUnsync != 0 {
+		data = removeUnsync(data)
+	}
+
+	return id, data, rest, true
+}
+
+// skipExtendedHeader пропускает расширенный заголовок тега.
+func skipExtendedHeader(body []byte, version byte) ([]byte, error) {
+	if len(body) < 4 {
+		return nil, fmt.Errorf("ID3v2 extended header is truncated")
+	}
+
+	// В версии 2.3 размер не включает сами 4 байта размера, в 2.4 включает.
+	size := int(binary.BigEndian.Uint32(body[:4])) + 4
+	if version == 4 {
+		size = syncsafe(body[:4])
+	}
+	if size > len(body) {
+		return nil, fmt.Errorf("ID3v2 extended header is truncated")
+	}
+	return body[size:], nil
+}
+
+// decodeTextFrame декодирует текстовый фрейм. В версии 2.4 фрейм может содержать
+// несколько значений, разделённых нулевым символом.
+func decodeTextFrame(data []byte) []string {
+	if len(data) < 1 {
+		return nil
+	}
+	text := decodeText(data[0], data[1:])
+	return strings.Split(strings.TrimRight(text, "\x00"), "\x00")
+}
+
+// decodeLyricsFrame декодирует фрейм USLT: кодировка, язык, описание и сам текст.
+func decodeLyricsFrame(data []byte) (string, bool) {
+	if len(data) < 4 {
+		return "", false
+	}
+	enc := data[0]
+	rest := data[4:]
+
+	// Пропускаем описание, оканчивающееся нулевым символом нужной ширины.
+	_, text := splitTerminated(enc, rest)
+	return strings.ReplaceAll(decodeText(enc, text), "\r\n", "\n"), true
+}
+
+// splitTerminated отделяет строку до нулевого символа с учётом кодировки.
+func splitTerminated(enc byte, data []byte) (before, after []byte) {
+	if enc == encUTF16 || enc == encUTF16BE {
+		for i := 0; i+1 < len(data); i += 2 {
+			if data[i] == 0 && data[i+1] == 0 {
+				return data[:i], data[i+2:]
+			}
+		}
+		return data, nil
+	}
+
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return data[:i], data[i+1:]
+	}
+	return data, nil
+}
+
+// decodeText декодирует строку в указанной кодировке ID3v2.
+func decodeText(enc byte, data []byte) string {
+	switch enc {
+	case encUTF16:
+		return decodeUTF16(data, true)
+	case encUTF16BE:
+		return decodeUTF16(data, false)
+	case encUTF8:
+		return string(data)
+	default:
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+}
+
+// decodeUTF16 декодирует UTF-16. Если withBOM == true, порядок байт определяется по BOM,
+// причём BOM может повторяться перед каждым значением фрейма.
+func decodeUTF16(data []byte, withBOM bool) string {
+	littleEndian := false
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if withBOM && (i == 0 || (len(units) > 0 && units[len(units)-1] == 0)) {
+			switch {
+			case data[i] == 0xFF && data[i+1] == 0xFE:
+				littleEndian = true
+				continue
+			case data[i] == 0xFE && data[i+1] == 0xFF:
+				littleEndian = false
+				continue
+			}
+		}
+		if littleEndian {
+			units = append(units, uint16(data[i])|uint16(data[i+1])<<8)
+		} else {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// removeUnsync отменяет рассинхронизацию: последовательность 0xFF 0x00 заменяется на 0xFF.
+func removeUnsync(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		out = append(out, data[i])
+		if data[i] == 0xFF && i+1 < len(data) && data[i+1] == 0x00 {
+			i++
+		}
+	}
+	return out
+}
+
+// syncsafe декодирует 28-битное целое, записанное по 7 бит в каждом байте.
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
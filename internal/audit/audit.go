@@ -0,0 +1,196 @@
+// Package audit записывает изменения библиотеки и прав доступа в журнал аудита: кто,
+// когда и в каком запросе изменил запись, и как она выглядела до и после изменения.
+// Записи журнала только добавляются, база данных запрещает их изменять и удалять.
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/requestid"
+)
+
+// Изменяемые сущности.
+const (
+	EntitySong     = "song"       // песня библиотеки
+	EntityArtist   = "artist"     // группа
+	EntityTags     = "song_tags"  // теги песни, ID - ID песни
+	EntityAudio    = "song_audio" // аудиофайл песни, ID - ID песни
+	EntityCover    = "song_cover" // обложка песни, ID - ID песни
+	EntityAPIKey   = "api_key"    // API ключ
+	EntityAccount  = "account"    // учётная запись пользователя
+	EntityFavorite = "favorite"   // песня в избранном пользователя, ID - ID песни
+	EntityRating   = "rating"     // оценка песни пользователем, ID - ID песни
+	EntityPlaylist = "playlist"   // плейлист вместе со списком песен
+)
+
+// Действия с сущностями.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"  // для песни - перемещение в корзину, для ключа - отзыв
+	ActionRestore = "restore" // восстановление песни из корзины
+	ActionPurge   = "purge"   // окончательное удаление песни из корзины
+	ActionMerge   = "merge"   // объединение дубликата с другой записью, после - запись, в которую он влит
+)
+
+// System исполнитель изменений, которые сервер делает сам, например очистки корзины.
+const System = "system"
+
+// Event изменение одной сущности.
+type Event struct {
+	Entity   string
+	EntityID int32
+	Action   string
+	Before   any // состояние до изменения, nil при создании
+	After    any // состояние после изменения, nil при удалении
+}
+
+type actorKey struct{}
+
+// WithActor задаёт исполнителя изменений, сделанных без API ключа или JWT,
+// например командой import: "cli:import".
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor возвращает исполнителя для журнала: "user:<sub>" для пользователя SSO
+// и "key:<название>" для API ключа.
+func Actor(p auth.Principal) string {
+	if p.Subject != "" {
+		return "user:" + p.Subject
+	}
+	return "key:" + p.Name
+}
+
+// Record записывает событие в журнал через q. Исполнитель и ID запроса берутся из ctx.
+// Чтобы изменение и запись о нём сохранились вместе, q должен выполнять запросы
+// в транзакции изменения.
+func Record(ctx context.Context, q *db.Queries, ev Event) error {
+	before, err := state(ev.Before)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %d state before %s: %w", ev.Entity, ev.EntityID, ev.Action, err)
+	}
+	after, err := state(ev.After)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %d state after %s: %w", ev.Entity, ev.EntityID, ev.Action, err)
+	}
+
+	params := db.AddAuditEventParams{
+		Actor:     System,
+		RequestID: requestid.FromContext(ctx),
+		Entity:    ev.Entity,
+		EntityID:  ev.EntityID,
+		Action:    ev.Action,
+		Before:    before,
+		After:     after,
+	}
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		params.Actor = Actor(p)
+		params.Role = string(p.Role)
+		params.ApiKeyID = sql.NullInt32{Int32: p.KeyID, Valid: p.KeyID != 0}
+		params.AccountID = sql.NullInt32{Int32: p.AccountID, Valid: p.AccountID != 0}
+	} else if actor, ok := ctx.Value(actorKey{}).(string); ok {
+		params.Actor = actor
+	}
+
+	if err = q.AddAuditEvent(ctx, params); err != nil {
+		return fmt.Errorf("failed to record %s of %s %d: %w", ev.Action, ev.Entity, ev.EntityID, err)
+	}
+	return nil
+}
+
+// state кодирует состояние сущности в JSON, nil кодируется как null.
+func state(v any) (json.RawMessage, error) {
+	if v == nil {
+		return json.RawMessage("null"), nil
+	}
+	return json.Marshal(v)
+}
+
+// Song состояние песни в журнале.
+type Song struct {
+	ID          int32      `json:"id"`
+	GroupID     int32      `json:"groupId"`
+	Song        string     `json:"song"`
+	ReleaseDate string     `json:"releaseDate,omitempty"` // DD.MM.YYYY
+	Text        string     `json:"text,omitempty"`
+	Link        string     `json:"link,omitempty"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"` // время перемещения в корзину
+}
+
+// SongState возвращает состояние песни для журнала.
+func SongState(s db.Library) Song {
+	st := Song{
+		ID:      s.ID,
+		GroupID: s.GroupID,
+		Song:    s.Song,
+		Text:    s.Text,
+		Link:    s.Link,
+	}
+	if !s.ReleaseDate.IsZero() {
+		st.ReleaseDate = s.ReleaseDate.Format("02.01.2006")
+	}
+	if s.DeletedAt.Valid {
+		st.DeletedAt = &s.DeletedAt.Time
+	}
+	return st
+}
+
+// Artist состояние группы в журнале.
+type Artist struct {
+	ID    int32  `json:"id"`
+	Group string `json:"group"`
+}
+
+// ArtistState возвращает состояние группы для журнала.
+func ArtistState(a db.Artist) Artist {
+	return Artist{ID: a.ID, Group: a.Group}
+}
+
+// Favorite песня в избранном пользователя в журнале.
+type Favorite struct {
+	AccountID int32 `json:"accountId"`
+	SongID    int32 `json:"songId"`
+}
+
+// Rating оценка песни пользователем в журнале.
+type Rating struct {
+	AccountID int32 `json:"accountId"`
+	SongID    int32 `json:"songId"`
+	Stars     int16 `json:"stars"`
+}
+
+// Playlist состояние плейлиста в журнале. Ссылка на плейлист открывает к нему доступ,
+// поэтому в журнал записывается только её наличие.
+type Playlist struct {
+	ID        int32   `json:"id"`
+	AccountID int32   `json:"accountId"`
+	Name      string  `json:"name"`
+	Public    bool    `json:"public"`
+	Shared    bool    `json:"shared"`
+	Version   int32   `json:"version"`
+	Songs     []int32 `json:"songs"` // ID песен в порядке плейлиста
+}
+
+// PlaylistState возвращает состояние плейлиста со списком песен для журнала.
+func PlaylistState(p db.Playlist, entries []db.ListPlaylistEntriesRow) Playlist {
+	st := Playlist{
+		ID:        p.ID,
+		AccountID: p.AccountID,
+		Name:      p.Name,
+		Public:    p.IsPublic,
+		Shared:    p.ShareToken.Valid,
+		Version:   p.Version,
+		Songs:     make([]int32, 0, len(entries)),
+	}
+	for _, e := range entries {
+		st.Songs = append(st.Songs, e.SongID)
+	}
+	return st
+}
@@ -0,0 +1,141 @@
+// Package auth описывает роли API ключей, их генерацию и хеширование.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Role роль API ключа. Роли упорядочены: каждая следующая включает права предыдущей.
+type Role string
+
+const (
+	RoleReader Role = "reader" // чтение библиотеки
+	RoleEditor Role = "editor" // изменение библиотеки
+	RoleAdmin  Role = "admin"  // управление API ключами
+)
+
+// ErrUnknownRole возвращается при разборе роли, которой нет в списке.
+var ErrUnknownRole = errors.New("unknown role, expected reader, editor or admin")
+
+// keyPrefix начало каждого ключа, позволяет отличить его в логах и конфигурации.
+const keyPrefix = "eml_"
+
+// visiblePrefixLen длина начала ключа, которое хранится открыто для его опознания.
+const visiblePrefixLen = len(keyPrefix) + 8
+
+// ParseRole разбирает название роли.
+func ParseRole(s string) (Role, error) {
+	switch r := Role(strings.ToLower(strings.TrimSpace(s))); r {
+	case RoleReader, RoleEditor, RoleAdmin:
+		return r, nil
+	}
+	return "", ErrUnknownRole
+}
+
+// rank возвращает уровень прав роли, неизвестная роль не имеет прав.
+func (r Role) rank() int {
+	switch r {
+	case RoleReader:
+		return 1
+	case RoleEditor:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// Allows сообщает, достаточно ли прав роли для действия, требующего роль required.
+func (r Role) Allows(required Role) bool {
+	return r.rank() > 0 && r.rank() >= required.rank()
+}
+
+// GenerateKey создаёт новый случайный ключ. Возвращает сам ключ, который показывается
+// один раз, и его открытое начало для опознания в списке ключей.
+func GenerateKey() (key, prefix string, err error) {
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = keyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:visiblePrefixLen], nil
+}
+
+// HashKey возвращает SHA-256 ключа в шестнадцатеричном виде. Ключи случайны
+// и длинны, поэтому медленное хеширование с солью не требуется.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// KeyFromRequest извлекает ключ из заголовка "X-API-Key" или "Authorization: Bearer".
+func KeyFromRequest(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// Principal владелец API ключа или JWT, от имени которого выполняется запрос.
+type Principal struct {
+	KeyID     int32  // ID ключа в базе данных, 0 для JWT и ключа администратора из конфигурации
+	AccountID int32  // ID учётной записи пользователя, 0 если ключ к ней не привязан
+	Subject   string // идентификатор пользователя из JWT (sub), пусто для API ключей
+	Name      string // название ключа или имя пользователя
+	Role      Role   // роль ключа или пользователя
+}
+
+// String возвращает описание владельца для журнала запросов.
+func (p Principal) String() string {
+	if p.Subject != "" {
+		return fmt.Sprintf("user:%s (%s)", p.Name, p.Role)
+	}
+	return fmt.Sprintf("key:%s (%s)", p.Name, p.Role)
+}
+
+type principalKey struct{}
+
+type trackerKey struct{}
+
+// principalSlot место для владельца, заполняемое WithPrincipal.
+type principalSlot struct {
+	principal Principal
+	set       bool
+}
+
+// WithPrincipal добавляет владельца в контекст запроса и передаёт его
+// отслеживающему middleware, если оно есть.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	if slot, ok := ctx.Value(trackerKey{}).(*principalSlot); ok {
+		slot.principal, slot.set = p, true
+	}
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext возвращает владельца из контекста запроса.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// TrackPrincipal позволяет внешнему middleware, например журналу запросов, узнать
+// владельца, определённого внутренним middleware. Возвращаемая функция вызывается
+// после обработки запроса.
+func TrackPrincipal(ctx context.Context) (context.Context, func() (Principal, bool)) {
+	slot := new(principalSlot)
+	return context.WithValue(ctx, trackerKey{}, slot), func() (Principal, bool) {
+		return slot.principal, slot.set
+	}
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTOptions настройки проверки JWT, выпущенных внешним поставщиком учётных записей (SSO).
+type JWTOptions struct {
+	JWKS        string          // путь до файла или адрес набора открытых ключей (JWKS)
+	Issuer      string          // ожидаемый издатель (iss), пусто - не проверяется
+	Audience    string          // ожидаемый получатель (aud), пусто - не проверяется
+	RolesClaim  string          // поле с ролями, вложенные поля через точку: "realm_access.roles"
+	RoleMapping map[string]Role // соответствие ролей поставщика ролям приложения
+	DefaultRole Role            // роль, если в токене нет подходящих ролей, пусто - без прав
+	Leeway      time.Duration   // допустимое расхождение часов
+	// RefreshInterval минимальный интервал между повторными загрузками JWKS по адресу
+	// при встрече неизвестного kid.
+	RefreshInterval time.Duration
+	HTTPClient      *http.Client // клиент для загрузки JWKS, nil - клиент с таймаутом 10 секунд
+}
+
+// JWTValidator проверяет подпись и поля JWT и определяет по ним владельца запроса.
+type JWTValidator struct {
+	opts   JWTOptions
+	parser *jwt.Parser
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWTValidator загружает набор ключей и создаёт проверку JWT.
+func NewJWTValidator(ctx context.Context, opts JWTOptions) (*JWTValidator, error) {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.RolesClaim == "" {
+		opts.RolesClaim = "roles"
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(opts.Leeway),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+
+	keys, err := loadJWKS(ctx, opts.HTTPClient, opts.JWKS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTValidator{
+		opts:      opts,
+		parser:    jwt.NewParser(parserOpts...),
+		keys:      keys,
+		fetchedAt: time.Now(),
+	}, nil
+}
+
+// LooksLikeJWT сообщает, похож ли токен на JWT в компактной форме, в отличие от API ключа.
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2 && !strings.HasPrefix(token, keyPrefix)
+}
+
+// Validate проверяет токен и возвращает владельца запроса с ролью из токена.
+func (v *JWTValidator) Validate(ctx context.Context, token string) (Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	}); err != nil {
+		return Principal{}, fmt.Errorf("invalid JWT: %w", err)
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return Principal{}, errors.New("invalid JWT: missing sub claim")
+	}
+
+	name := subject
+	for _, field := range []string{"preferred_username", "email"} {
+		if s, ok := claims[field].(string); ok && s != "" {
+			name = s
+			break
+		}
+	}
+
+	return Principal{
+		Subject: subject,
+		Name:    name,
+		Role:    v.role(claims),
+	}, nil
+}
+
+// key возвращает открытый ключ по kid. Если ключа нет, а набор загружается по адресу,
+// набор загружается повторно не чаще RefreshInterval, чтобы подхватить смену ключей.
+func (v *JWTValidator) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := lookupKey(v.keys, kid)
+	stale := time.Since(v.fetchedAt) >= v.opts.RefreshInterval
+	v.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+	if !isRemoteJWKS(v.opts.JWKS) || !stale {
+		return nil, fmt.Errorf("unknown key ID '%s'", kid)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	// Набор мог быть уже обновлён другим запросом.
+	if key, ok = lookupKey(v.keys, kid); ok {
+		return key, nil
+	}
+
+	keys, err := loadJWKS(ctx, v.opts.HTTPClient, v.opts.JWKS)
+	v.fetchedAt = time.Now()
+	if err != nil {
+		return nil, err
+	}
+	v.keys = keys
+
+	if key, ok = lookupKey(v.keys, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key ID '%s'", kid)
+}
+
+// lookupKey ищет ключ по kid. Токен без kid принимается, только если ключ в наборе один.
+func lookupKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(keys) == 1 {
+		for _, k := range keys {
+			return k, true
+		}
+	}
+	k, ok := keys[kid]
+	return k, ok
+}
+
+// role выбирает наибольшую из ролей токена. Без RoleMapping роли токена
+// сравниваются с названиями ролей приложения напрямую.
+func (v *JWTValidator) role(claims jwt.MapClaims) Role {
+	best := v.opts.DefaultRole
+	for _, name := range claimStrings(claims, v.opts.RolesClaim) {
+		role, ok := v.opts.RoleMapping[name]
+		if !ok && len(v.opts.RoleMapping) == 0 {
+			role, ok = Role(name), Role(name).rank() > 0
+		}
+		if ok && role.rank() > best.rank() {
+			best = role
+		}
+	}
+	return best
+}
+
+// claimStrings возвращает значения поля по пути через точку. Поле может быть строкой
+// с ролями через пробел, как "scope", или массивом строк.
+func claimStrings(claims jwt.MapClaims, path string) []string {
+	var value interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = obj[part]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		result := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// ParseRoleMapping разбирает соответствие ролей вида "music-admins:admin".
+func ParseRoleMapping(entries []string) (map[string]Role, error) {
+	mapping := make(map[string]Role, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		external, name, ok := strings.Cut(entry, ":")
+		if !ok || strings.TrimSpace(external) == "" {
+			return nil, fmt.Errorf("invalid role mapping '%s', expected 'external:role'", entry)
+		}
+		role, err := ParseRole(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid role mapping '%s': %w", entry, err)
+		}
+		mapping[strings.TrimSpace(external)] = role
+	}
+	return mapping, nil
+}
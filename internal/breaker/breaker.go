@@ -0,0 +1,146 @@
+// Package breaker ограничивает обращения к недоступному внешнему сервису: после нескольких
+// ошибок подряд запросы отклоняются сразу, а через паузу пропускается один пробный запрос.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen возвращается Allow, пока обращения к сервису приостановлены.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State состояние Breaker.
+type State string
+
+const (
+	StateClosed   State = "closed"    // запросы выполняются
+	StateOpen     State = "open"      // запросы отклоняются до окончания паузы
+	StateHalfOpen State = "half_open" // выполняется пробный запрос
+)
+
+// Breaker считает ошибки подряд и приостанавливает запросы после threshold ошибок.
+// Методы nil Breaker пропускают все запросы.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// New создаёт Breaker, который приостанавливает запросы на cooldown после threshold
+// ошибок подряд. При threshold <= 0 запросы не приостанавливаются.
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		state:     StateClosed,
+	}
+}
+
+// WithClock заменяет источник времени, используется в тестах.
+func (b *Breaker) WithClock(now func() time.Time) *Breaker {
+	b.now = now
+	return b
+}
+
+// Allow разрешает запрос или возвращает ErrOpen. После разрешённого запроса нужно
+// вызвать Success, Failure или Ignore.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.current() {
+	case StateOpen:
+		return ErrOpen
+	case StateHalfOpen:
+		// Пока пробный запрос не завершился, остальные отклоняются.
+		if b.probing {
+			return ErrOpen
+		}
+		b.state = StateHalfOpen
+		b.probing = true
+	}
+	return nil
+}
+
+// Success отмечает успешный запрос и возобновляет обращения к сервису.
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = StateClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure отмечает ошибку запроса. Ошибка пробного запроса снова приостанавливает обращения.
+func (b *Breaker) Failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.threshold <= 0 {
+		return
+	}
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = b.now()
+		b.probing = false
+	}
+}
+
+// Ignore завершает запрос, исход которого не говорит о доступности сервиса, например
+// отменённый клиентом. Счётчик ошибок не меняется.
+func (b *Breaker) Ignore() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State возвращает текущее состояние, nil Breaker всегда StateClosed.
+func (b *Breaker) State() State {
+	if b == nil {
+		return StateClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.current()
+}
+
+// Failures возвращает число ошибок подряд.
+func (b *Breaker) Failures() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures
+}
+
+// current возвращает состояние с учётом окончания паузы, вызывается под mu.
+func (b *Breaker) current() State {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cooldown {
+		return StateHalfOpen
+	}
+	return b.state
+}
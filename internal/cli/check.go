@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/migration"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/server"
+)
+
+// runServe запускает сервер.
+func runServe(_ context.Context, a *app, args []string) error {
+	fs := a.flags()
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return usagef("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	server.Run(a.cfg, a.configOpts)
+	return nil
+}
+
+// runConfigCheck проверяет загруженные настройки: подключение к базе данных,
+// папку с миграциями, версию схемы и адрес внешнего API. Выводит результат каждой проверки.
+func runConfigCheck(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	failed := 0
+	report := func(name string, err error, details string) {
+		if err != nil {
+			failed++
+			fmt.Fprintf(a.stdout, "FAIL  %-12s %v\n", name, err)
+			return
+		}
+		fmt.Fprintf(a.stdout, "ok    %-12s %s\n", name, details)
+	}
+
+	report("config", a.cfgErr, "valid")
+	if a.cfgErr != nil {
+		// С неверными настройками остальные проверки не имеют смысла.
+		return fmt.Errorf("failed checks: %d", failed)
+	}
+
+	latest, errMigr := migration.Latest(a.cfg.MigrationPath)
+	report("migrations", errMigr, fmt.Sprintf("%s, latest version %d", migrationSource(a.cfg.MigrationPath), latest))
+
+	// Проверка не ждёт запуска базы данных, в отличие от serve.
+	connect, errDB := sql.Open(a.cfg.DatabaseDriver, a.cfg.DatabaseURL())
+	if errDB == nil {
+		defer connect.Close()
+		errDB = connect.PingContext(ctx)
+	}
+	report("database", errDB, fmt.Sprintf("%s:%s/%s", a.cfg.DatabaseHost, a.cfg.DatabasePort, a.cfg.DatabaseName))
+
+	if errDB == nil && errMigr == nil {
+		st, errSt := migration.Inspect(ctx, connect, a.cfg.MigrationPath)
+		if errSt == nil {
+			errSt = st.Err()
+		}
+		report("schema", errSt, fmt.Sprintf("version %d", st.Version))
+	}
+
+	parsed, errURL := url.Parse(a.cfg.ExternalAPIURL)
+	if errURL == nil && (parsed.Scheme != "http" && parsed.Scheme != "https" || parsed.Host == "") {
+		errURL = fmt.Errorf("invalid external API URL %q", a.cfg.ExternalAPIURL)
+	}
+	report("external_api", errURL, a.cfg.ExternalAPIURL)
+
+	if failed > 0 {
+		return fmt.Errorf("failed checks: %d", failed)
+	}
+	return nil
+}
+
+// migrationSource возвращает путь до миграций для вывода.
+func migrationSource(migrationPath string) string {
+	if migrationPath == "" {
+		return migration.Embedded
+	}
+	return migrationPath
+}
+
+// runConfigShow выводит действующие настройки из всех источников, секреты скрыты.
+func runConfigShow(_ context.Context, a *app, args []string) error {
+	fs := a.flags()
+	asJSON := fs.Bool("json", false, "print settings as JSON")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	entries := a.cfg.Entries()
+	if *asJSON {
+		values := make(map[string]string, len(entries))
+		for _, e := range entries {
+			values[e.Key] = e.Value
+		}
+		if err := writeIndentedJSON(a.stdout, values); err != nil {
+			return err
+		}
+	} else {
+		for _, e := range entries {
+			fmt.Fprintf(a.stdout, "%s=%s\n", e.Key, e.Value)
+		}
+	}
+
+	// Ошибки выводятся после настроек, чтобы было видно, какие значения к ним привели.
+	return a.cfgErr
+}
@@ -0,0 +1,205 @@
+// Package cli разбирает команды приложения: запуск сервера, миграции, импорт, выгрузку
+// и заполнение библиотеки, проверку настроек. Все команды берут настройки из одного .env.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	_ "github.com/jackc/pgx/v4/stdlib"
+)
+
+// Коды завершения.
+const (
+	ExitOK    = 0 // команда выполнена
+	ExitError = 1 // команда завершилась ошибкой
+	ExitUsage = 2 // неверные аргументы
+)
+
+// command команда приложения. Имя может состоять из нескольких слов, например "migrate up".
+type command struct {
+	name    string
+	args    string // аргументы для справки
+	summary string
+	run     func(ctx context.Context, a *app, args []string) error
+}
+
+// commands команды в порядке вывода в справке. Без команды выполняется serve.
+var commands = []command{
+	{"serve", "", "run the HTTP server", runServe},
+	{"migrate up", "[-create-db] [N]", "apply all or N next migrations", runMigrateUp},
+	{"migrate down", "N", "roll back N last migrations", runMigrateDown},
+	{"migrate goto", "VERSION", "migrate up or down to VERSION", runMigrateGoto},
+	{"migrate status", "[-json]", "show schema version and migrations", runMigrateStatus},
+	{"migrate force", "VERSION", "mark VERSION as applied after fixing a failed migration", runMigrateForce},
+	{"import", "[-dry-run] DIR...", "import songs from MP3 and FLAC tags", runImport},
+	{"export", "[-format F] [-gzip] [-o FILE] [filters]", "export the library", runExport},
+	{"seed", "[-file FILE]", "add sample songs to the library", runSeed},
+	{"config check", "", "check configuration and connections", runConfigCheck},
+	{"config show", "[-json]", "print the effective configuration with secrets redacted", runConfigShow},
+}
+
+// lenientCommands команды, которые выполняются и с ошибками в настройках, чтобы их показать.
+var lenientCommands = map[string]bool{"config check": true, "config show": true}
+
+// app общие для команд настройки и потоки вывода.
+type app struct {
+	configOpts config.Options
+	stdout     io.Writer
+	stderr     io.Writer
+
+	cmd    command
+	cfg    config.Config
+	cfgErr error // ошибки проверки настроек для lenientCommands
+}
+
+// usageError ошибка в аргументах команды, после неё выводится справка.
+// Пустой msg означает, что ошибку и справку уже вывел пакет flag.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, v ...interface{}) error {
+	return usageError{msg: fmt.Sprintf(format, v...)}
+}
+
+// Main выполняет команду из args (без имени программы) и возвращает код завершения.
+func Main(args []string, stdout, stderr io.Writer) int {
+	a := &app{stdout: stdout, stderr: stderr}
+
+	global := flag.NewFlagSet("music-library", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	global.StringVar(&a.configOpts.Dir, "config", ".", "directory with the optional .env file")
+	global.StringVar(&a.configOpts.File, "config-file", "", "YAML or TOML config file")
+	global.Func("set", "override a setting, KEY=VALUE, can be repeated", func(kv string) error {
+		key, val, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("expected KEY=VALUE, got %q", kv)
+		}
+		if a.configOpts.Overrides == nil {
+			a.configOpts.Overrides = make(map[string]string)
+		}
+		a.configOpts.Overrides[key] = val
+		return nil
+	})
+	global.Usage = func() { a.usage(global) }
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+
+	rest := global.Args()
+	if len(rest) == 0 {
+		rest = []string{"serve"}
+	}
+	if rest[0] == "help" {
+		a.usage(global)
+		return ExitOK
+	}
+
+	cmd, cmdArgs, ok := lookup(rest)
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", strings.Join(rest, " "))
+		a.usage(global)
+		return ExitUsage
+	}
+
+	// Загружаем настройки и инициализируем логгер.
+	cfg, errLoad := config.Load(a.configOpts)
+	var invalid *config.ValidationError
+	switch {
+	case errors.As(errLoad, &invalid) && lenientCommands[cmd.name]:
+		a.cfgErr = errLoad
+	case errLoad != nil:
+		fmt.Fprintf(stderr, "unable to load config: %v\n", errLoad)
+		return ExitError
+	default:
+		if errLog := logger.Initialize(cfg.LogLevel, cfg.LogFormat); errLog != nil {
+			fmt.Fprintf(stderr, "failed to initialize the logger: %v\n", errLog)
+			return ExitError
+		}
+	}
+	a.cmd, a.cfg = cmd, cfg
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// Изменения из командной строки записываются в журнал аудита от имени команды.
+	ctx = audit.WithActor(ctx, "cli:"+cmd.name)
+
+	err := cmd.run(ctx, a, cmdArgs)
+	var usageErr usageError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.As(err, &usageErr):
+		if usageErr.msg != "" {
+			fmt.Fprintf(stderr, "%s\nusage: %s %s\n", usageErr.msg, cmd.name, cmd.args)
+		}
+		return ExitUsage
+	default:
+		fmt.Fprintf(stderr, "%s: %v\n", cmd.name, err)
+		return ExitError
+	}
+}
+
+// lookup находит команду по первым словам args и возвращает её аргументы.
+func lookup(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) {
+			continue
+		}
+		if strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+	return command{}, nil, false
+}
+
+// usage выводит список команд.
+func (a *app) usage(global *flag.FlagSet) {
+	fmt.Fprintln(a.stderr, "usage: music-library [-config DIR] [-config-file FILE] [-set KEY=VALUE]... <command> [arguments]")
+	fmt.Fprintln(a.stderr, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(a.stderr, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(a.stderr, "\nglobal flags:")
+	global.PrintDefaults()
+}
+
+// flags создаёт набор флагов выполняемой команды, который выводит справку и ошибки в stderr.
+func (a *app) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet(a.cmd.name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(a.stderr, "usage: %s %s\n", a.cmd.name, a.cmd.args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse разбирает флаги команды. Ошибку флага пакет flag уже вывел вместе со справкой.
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/database"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/export"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/importer"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+)
+
+// runImport импортирует песни по тегам MP3 и FLAC файлов из указанных папок и выводит отчёт в формате JSON.
+func runImport(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	dryRun := fs.Bool("dry-run", false, "only check tags without changing the library")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return usagef("expected at least one directory")
+	}
+
+	var store importer.Store
+	if !*dryRun {
+		connect, errConn := a.openDB(ctx)
+		if errConn != nil {
+			return errConn
+		}
+		defer connect.Close()
+
+		store = importer.NewDBStore(connect)
+	}
+
+	batch := importer.NewBatch(store)
+	var errScan error
+	for _, dir := range fs.Args() {
+		logger.Zap.Debug(fmt.Sprintf("Scanning '%s' for audio files.", dir))
+		if errScan = batch.ScanDir(ctx, dir); errScan != nil {
+			errScan = fmt.Errorf("failed to scan '%s': %w", dir, errScan)
+			break
+		}
+	}
+
+	// Отчёт выводится и после ошибки, чтобы было видно, какие файлы уже обработаны.
+	report := batch.Report()
+	if errEnc := writeIndentedJSON(a.stdout, report); errEnc != nil {
+		return fmt.Errorf("failed to write report: %w", errEnc)
+	}
+	if errScan != nil {
+		return errScan
+	}
+
+	logger.Zap.Info(fmt.Sprintf("Import finished: created %d, updated %d, checked %d, skipped %d.",
+		report.Created, report.Updated, report.Checked, report.Skipped))
+	return nil
+}
+
+// runExport выгружает библиотеку песен в файл или в стандартный вывод.
+func runExport(ctx context.Context, a *app, args []string) (err error) {
+	fs := a.flags()
+	format := fs.String("format", "ndjson", "export format: ndjson, csv or json")
+	compress := fs.Bool("gzip", false, "compress output with gzip")
+	output := fs.String("o", "", "output file, stdout if empty")
+	group := fs.String("group", "", "filter by group name")
+	song := fs.String("song", "", "filter by song name")
+	releaseDate := fs.String("releaseDate", "", "filter by release date, format DD.MM.YYYY")
+	text := fs.String("text", "", "filter by words in song text")
+	if err = parse(fs, args); err != nil {
+		return err
+	}
+
+	exportFormat, errFormat := export.ParseFormat(*format)
+	if errFormat != nil {
+		return usagef("%v", errFormat)
+	}
+
+	filter := models.SongFilter{
+		Group: *group,
+		Song:  *song,
+		Text:  *text,
+	}
+	if *releaseDate != "" {
+		date, errDate := time.Parse("02.01.2006", *releaseDate)
+		if errDate != nil {
+			return usagef("incorrect date format, expected DD.MM.YYYY: %v", errDate)
+		}
+		filter.ReleaseDate = date
+	}
+
+	connect, err := a.openDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer connect.Close()
+
+	out := a.stdout
+	if *output != "" {
+		file, errFile := os.Create(*output)
+		if errFile != nil {
+			return fmt.Errorf("unable to create output file: %w", errFile)
+		}
+		defer func() {
+			if errClose := file.Close(); errClose != nil && err == nil {
+				err = fmt.Errorf("unable to close output file: %w", errClose)
+			}
+		}()
+		out = file
+	}
+
+	total, err := export.New(db.New(connect), export.DefaultBatchSize).Songs(ctx, out, exportFormat, filter, *compress)
+	if err != nil {
+		return fmt.Errorf("export failed after %d songs: %w", total, err)
+	}
+
+	logger.Zap.Info(fmt.Sprintf("Exported %d songs in '%s' format.", total, exportFormat))
+	return nil
+}
+
+// defaultSeed песни для заполнения пустой библиотеки при разработке.
+//
+//go:embed seed.json
+var defaultSeed []byte
+
+// SeedSong песня из файла для seed.
+type SeedSong struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"releaseDate"` // DD.MM.YYYY, пусто - не указана
+	Text        string `json:"text"`
+}
+
+// runSeed добавляет в библиотеку песни из файла или встроенный набор.
+func runSeed(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	file := fs.String("file", "", "JSON file with songs, built-in sample songs if empty")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	var r io.Reader = bytes.NewReader(defaultSeed)
+	if *file != "" {
+		f, errOpen := os.Open(*file)
+		if errOpen != nil {
+			return errOpen
+		}
+		defer f.Close()
+		r = f
+	}
+
+	connect, err := a.openDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer connect.Close()
+
+	created, updated, err := Seed(ctx, importer.NewDBStore(connect), r)
+	if err != nil {
+		return err
+	}
+
+	logger.Zap.Info(fmt.Sprintf("Seed finished: created %d, updated %d.", created, updated))
+	return nil
+}
+
+// Seed читает из r JSON массив SeedSong и сохраняет песни в store. Существующие песни
+// обновляются, поэтому повторный запуск не создаёт дубликатов.
+func Seed(ctx context.Context, store importer.Store, r io.Reader) (created, updated int, err error) {
+	var songs []SeedSong
+	if err = json.NewDecoder(r).Decode(&songs); err != nil {
+		return 0, 0, fmt.Errorf("failed to read seed songs: %w", err)
+	}
+
+	for i, s := range songs {
+		if s.Group == "" || s.Song == "" {
+			return created, updated, fmt.Errorf("song %d: group and song are required", i+1)
+		}
+
+		song := importer.Song{Group: s.Group, Title: s.Song, Text: s.Text}
+		if s.ReleaseDate != "" {
+			if song.ReleaseDate, err = time.Parse("02.01.2006", s.ReleaseDate); err != nil {
+				return created, updated, fmt.Errorf("song %d: incorrect date format, expected DD.MM.YYYY: %w", i+1, err)
+			}
+		}
+
+		_, isNew, errUpsert := store.UpsertSong(ctx, song)
+		if errUpsert != nil {
+			return created, updated, fmt.Errorf("song %d: %w", i+1, errUpsert)
+		}
+		if isNew {
+			created++
+		} else {
+			updated++
+		}
+	}
+	return created, updated, nil
+}
+
+// openDB открывает подключение к базе данных из настроек.
+func (a *app) openDB(ctx context.Context) (*sql.DB, error) {
+	return database.Connect(ctx, a.cfg)
+}
+
+func writeIndentedJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,235 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/database"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/dedupe"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/migration"
+)
+
+// runMigrateUp применяет миграции, при -create-db сначала создаёт базу данных.
+// Если схема стала актуальной, заполняет ключи названий, которые миграция оставила пустыми.
+func runMigrateUp(ctx context.Context, a *app, args []string) error {
+	fs := a.flags()
+	createDB := fs.Bool("create-db", false, "create the database if it does not exist")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	steps, err := optionalCount(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	if *createDB {
+		if errCreate := createDatabase(ctx, a); errCreate != nil {
+			return errCreate
+		}
+	}
+
+	current := false
+	err = a.withMigrator(func(mg *migration.Migrator) error {
+		if errUp := mg.Up(steps); errUp != nil {
+			return errUp
+		}
+		st, errStatus := mg.Status()
+		if errStatus != nil {
+			return errStatus
+		}
+		current = st.Err() == nil
+		return nil
+	})
+	if err != nil || !current {
+		return err
+	}
+	return fillNameKeys(ctx, a)
+}
+
+// fillNameKeys заполняет пустые ключи названий групп и песен.
+func fillNameKeys(ctx context.Context, a *app) error {
+	connect, err := a.openDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer connect.Close()
+
+	filled, err := dedupe.FillKeys(ctx, db.New(connect))
+	if err != nil {
+		return fmt.Errorf("failed to fill name keys: %w", err)
+	}
+	logger.Zap.Info(fmt.Sprintf("Filled %d group and song name keys.", filled))
+	return nil
+}
+
+// runMigrateDown откатывает последние миграции. Число миграций указывается явно,
+// чтобы случайно не удалить всю схему.
+func runMigrateDown(_ context.Context, a *app, args []string) error {
+	fs := a.flags()
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected number of migrations to roll back")
+	}
+	steps, err := strconv.Atoi(fs.Arg(0))
+	if err != nil || steps <= 0 {
+		return usagef("invalid number of migrations %q", fs.Arg(0))
+	}
+
+	return a.withMigrator(func(mg *migration.Migrator) error {
+		return mg.Down(steps)
+	})
+}
+
+// runMigrateGoto переводит схему на указанную версию.
+func runMigrateGoto(_ context.Context, a *app, args []string) error {
+	fs := a.flags()
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected target version")
+	}
+	version, err := strconv.ParseUint(fs.Arg(0), 10, 32)
+	if err != nil {
+		return usagef("invalid version %q", fs.Arg(0))
+	}
+
+	return a.withMigrator(func(mg *migration.Migrator) error {
+		return mg.Goto(uint(version))
+	})
+}
+
+// runMigrateForce записывает версию схемы без выполнения миграций, чтобы снять отметку
+// о прерванной миграции после ручного исправления схемы.
+func runMigrateForce(_ context.Context, a *app, args []string) error {
+	fs := a.flags()
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usagef("expected schema version")
+	}
+	version, err := strconv.ParseUint(fs.Arg(0), 10, 32)
+	if err != nil {
+		return usagef("invalid version %q", fs.Arg(0))
+	}
+
+	return a.withMigrator(func(mg *migration.Migrator) error {
+		return mg.Force(uint(version))
+	})
+}
+
+// runMigrateStatus выводит версию схемы и список миграций.
+func runMigrateStatus(_ context.Context, a *app, args []string) error {
+	fs := a.flags()
+	asJSON := fs.Bool("json", false, "print status as JSON")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	return a.withMigrator(func(mg *migration.Migrator) error {
+		st, err := mg.Status()
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			enc := json.NewEncoder(a.stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(st)
+		}
+		return printStatus(a, st)
+	})
+}
+
+// printStatus выводит состояние схемы в виде таблицы.
+func printStatus(a *app, st migration.Status) error {
+	state := "up to date"
+	if err := st.Err(); err != nil {
+		state = err.Error()
+	}
+	fmt.Fprintf(a.stdout, "source: %s\nversion: %d of %d (%s)\n\n", migrationSource(a.cfg.MigrationPath), st.Version, st.Latest, state)
+
+	tw := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+	for _, e := range st.Migrations {
+		applied := "no"
+		if e.Applied {
+			applied = "yes"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", e.Version, e.Name, applied)
+	}
+	return tw.Flush()
+}
+
+// withMigrator подключается к базе данных, выполняет fn и закрывает подключение.
+func (a *app) withMigrator(fn func(mg *migration.Migrator) error) (err error) {
+	mg, err := migration.New(a.cfg.MigrationPath, a.cfg.DatabaseURL())
+	if err != nil {
+		return fmt.Errorf("failed to open migrations: %w", err)
+	}
+	defer func() {
+		if errClose := mg.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}()
+	mg.SetLogger(func(format string, v ...interface{}) {
+		logger.Zap.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+	})
+
+	return fn(mg)
+}
+
+// createDatabase создаёт базу данных DB_NAME, подключаясь к служебной базе postgres.
+func createDatabase(ctx context.Context, a *app) error {
+	maintenance := a.cfg
+	maintenance.DatabaseName = "postgres"
+
+	connect, err := database.Connect(ctx, maintenance)
+	if err != nil {
+		return err
+	}
+	defer connect.Close()
+
+	var exists bool
+	err = connect.QueryRowContext(ctx, `SELECT EXISTS (SELECT FROM pg_database WHERE datname = $1)`,
+		a.cfg.DatabaseName).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check if database exists: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	// Имя базы данных нельзя передать параметром запроса, поэтому оно экранируется.
+	name := `"` + strings.ReplaceAll(a.cfg.DatabaseName, `"`, `""`) + `"`
+	if _, err = connect.ExecContext(ctx, "CREATE DATABASE "+name); err != nil {
+		return fmt.Errorf("failed to create database: %w", err)
+	}
+	logger.Zap.Info(fmt.Sprintf("Created database '%s'.", a.cfg.DatabaseName))
+	return nil
+}
+
+// optionalCount разбирает необязательное число миграций, 0 - все.
+func optionalCount(args []string) (int, error) {
+	switch len(args) {
+	case 0:
+		return 0, nil
+	case 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			return 0, usagef("invalid number of migrations %q", args[0])
+		}
+		return n, nil
+	default:
+		return 0, usagef("unexpected arguments: %s", strings.Join(args[1:], " "))
+	}
+}
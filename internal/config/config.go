@@ -1,34 +1,309 @@
+// Package config загружает настройки приложения. Источники по возрастанию приоритета:
+// значения по умолчанию, файл YAML или TOML, файл .env, переменные окружения и флаги
+// командной строки. Секреты можно передать в файлах через переменные с суффиксом _FILE.
 package config
 
-import "github.com/spf13/viper"
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type Config struct {
-	ServerHost       string `mapstructure:"ADDRESS"`          // адрес сервера
-	LogLevel         string `mapstructure:"LOG_LEVEL"`        // уровень логирования
-	MigrationPath    string `mapstructure:"MIGRATION_PATH"`   // путь до папки с миграциями
-	ExternalAPIURL   string `mapstructure:"EXTERNAL_API_URL"` // адрес до внешнего API
-	PaginationLimit  int32  `mapstructure:"PAGINATION_LIMIT"` // размер пагинации по-умолчанию
-	DatabaseUser     string `mapstructure:"DB_USER"`          // имя пользователя датабазы
-	DatabasePassword string `mapstructure:"DB_PASSWORD"`      // пароль пользователя датабазы
-	DatabaseHost     string `mapstructure:"DB_HOST"`          // адрес для подключения к датабазе
-	DatabasePort     string `mapstructure:"DB_PORT"`          // порт для подключения к датабазе
-	DatabaseName     string `mapstructure:"DB_NAME"`          // имя датабазы
-	DatabaseDriver   string `mapstructure:"DB_DRIVER"`        // драйвер датабазы
-}
-
-// LoadConfig загружает из файла '.env' переменные окружения.
-func LoadConfig(path string) (config Config, err error) {
-	viper.AddConfigPath(path)
-	viper.SetConfigName(".env")
-	viper.SetConfigType("env")
-
-	viper.AutomaticEnv()
-
-	err = viper.ReadInConfig()
+	ServerHost       string `mapstructure:"ADDRESS"`                        // адрес сервера
+	LogLevel         string `mapstructure:"LOG_LEVEL" reload:"true"`        // уровень логирования
+	LogFormat        string `mapstructure:"LOG_FORMAT"`                     // формат журнала: console или json
+	MigrationPath    string `mapstructure:"MIGRATION_PATH"`                 // путь до папки с миграциями, пусто - встроенные
+	AutoMigrate      bool   `mapstructure:"AUTO_MIGRATE"`                   // применять миграции при запуске сервера
+	ExternalAPIURL   string `mapstructure:"EXTERNAL_API_URL" reload:"true"` // адрес до внешнего API
+	PaginationLimit  int32  `mapstructure:"PAGINATION_LIMIT" reload:"true"` // размер пагинации по-умолчанию
+	DatabaseDSN      string `mapstructure:"DATABASE_URL" secret:"true"`     // адрес датабазы целиком вместо DB_*
+	DatabaseUser     string `mapstructure:"DB_USER"`                        // имя пользователя датабазы
+	DatabasePassword string `mapstructure:"DB_PASSWORD" secret:"true"`      // пароль пользователя датабазы
+	DatabaseHost     string `mapstructure:"DB_HOST"`                        // адрес для подключения к датабазе
+	DatabasePort     string `mapstructure:"DB_PORT"`                        // порт для подключения к датабазе
+	DatabaseName     string `mapstructure:"DB_NAME"`                        // имя датабазы
+	DatabaseDriver   string `mapstructure:"DB_DRIVER"`                      // драйвер датабазы
+
+	DatabaseMaxOpenConns    int           `mapstructure:"DB_MAX_OPEN_CONNS" reload:"true"`     // соединений с датабазой всего, 0 - без ограничения
+	DatabaseMaxIdleConns    int           `mapstructure:"DB_MAX_IDLE_CONNS" reload:"true"`     // простаивающих соединений с датабазой
+	DatabaseConnMaxLifetime time.Duration `mapstructure:"DB_CONN_MAX_LIFETIME" reload:"true"`  // время жизни соединения, 0 - без ограничения
+	DatabaseConnMaxIdleTime time.Duration `mapstructure:"DB_CONN_MAX_IDLE_TIME" reload:"true"` // время простоя соединения до закрытия
+	DatabaseConnectTimeout  time.Duration `mapstructure:"DB_CONNECT_TIMEOUT"`                  // ожидание датабазы при запуске
+	DatabaseStatsInterval   time.Duration `mapstructure:"DB_STATS_INTERVAL"`                   // период записи статистики пула, 0 - не записывать
+
+	SongbookTemplatesPath string        `mapstructure:"SONGBOOK_TEMPLATES_PATH"`              // папка с шаблонами песенника оператора
+	StoragePath           string        `mapstructure:"STORAGE_PATH"`                         // папка локального хранилища файлов
+	AudioMaxSize          int64         `mapstructure:"AUDIO_MAX_SIZE"`                       // максимальный размер аудиофайла в байтах
+	ImportMaxSize         int64         `mapstructure:"IMPORT_MAX_SIZE"`                      // максимальный размер запроса импорта в байтах
+	CoverMaxSize          int64         `mapstructure:"COVER_MAX_SIZE"`                       // максимальный размер обложки в байтах
+	CoverMinDimension     int           `mapstructure:"COVER_MIN_DIMENSION"`                  // минимальная ширина и высота обложки
+	CoverMaxDimension     int           `mapstructure:"COVER_MAX_DIMENSION"`                  // максимальная ширина и высота обложки
+	CoverThumbnailSizes   []int         `mapstructure:"COVER_THUMBNAIL_SIZES"`                // размеры уменьшенных копий обложки
+	AdminAPIKey           string        `mapstructure:"ADMIN_API_KEY" secret:"true"`          // ключ администратора для создания первых API ключей
+	JWTJWKS               string        `mapstructure:"JWT_JWKS"`                             // путь до файла или адрес JWKS для проверки JWT
+	JWTIssuer             string        `mapstructure:"JWT_ISSUER"`                           // ожидаемый издатель JWT
+	JWTAudience           string        `mapstructure:"JWT_AUDIENCE"`                         // ожидаемый получатель JWT
+	JWTRolesClaim         string        `mapstructure:"JWT_ROLES_CLAIM"`                      // поле JWT с ролями пользователя
+	JWTRoleMapping        []string      `mapstructure:"JWT_ROLE_MAPPING"`                     // соответствие ролей SSO ролям приложения
+	JWTDefaultRole        string        `mapstructure:"JWT_DEFAULT_ROLE"`                     // роль пользователя SSO без подходящих ролей
+	RateLimitReadRPS      float64       `mapstructure:"RATE_LIMIT_READ_RPS" reload:"true"`    // запросов чтения в секунду на клиента
+	RateLimitReadBurst    int           `mapstructure:"RATE_LIMIT_READ_BURST" reload:"true"`  // запросов чтения подряд на клиента
+	RateLimitWriteRPS     float64       `mapstructure:"RATE_LIMIT_WRITE_RPS" reload:"true"`   // запросов изменения в секунду на клиента
+	RateLimitWriteBurst   int           `mapstructure:"RATE_LIMIT_WRITE_BURST" reload:"true"` // запросов изменения подряд на клиента
+	RateLimitIPRPS        float64       `mapstructure:"RATE_LIMIT_IP_RPS" reload:"true"`      // запросов в секунду с одного IP до проверки ключа
+	RateLimitIPBurst      int           `mapstructure:"RATE_LIMIT_IP_BURST" reload:"true"`    // запросов подряд с одного IP до проверки ключа
+	RateLimitTrustProxy   bool          `mapstructure:"RATE_LIMIT_TRUST_PROXY"`               // брать IP клиента из заголовков прокси
+	RecommendCacheTTL     time.Duration `mapstructure:"RECOMMEND_CACHE_TTL"`                  // время жизни индекса похожих песен
+	TrashRetention        time.Duration `mapstructure:"TRASH_RETENTION" reload:"true"`        // сколько удалённые песни хранятся в корзине
+	TrashPurgeInterval    time.Duration `mapstructure:"TRASH_PURGE_INTERVAL"`                 // период очистки корзины, 0 - не очищать
+	TracingExporter       string        `mapstructure:"TRACING_EXPORTER"`                     // куда отправлять spans: none, stdout или otlp
+	TracingOTLPEndpoint   string        `mapstructure:"TRACING_OTLP_ENDPOINT"`                // адрес коллектора OTLP/HTTP
+	TracingOTLPInsecure   bool          `mapstructure:"TRACING_OTLP_INSECURE"`                // отправлять spans без TLS
+	TracingSampleRatio    float64       `mapstructure:"TRACING_SAMPLE_RATIO"`                 // доля записываемых трассировок
+	ExternalAPIFailures   int           `mapstructure:"EXTERNAL_API_FAILURES"`                // ошибок внешнего API подряд до приостановки запросов
+	ExternalAPICooldown   time.Duration `mapstructure:"EXTERNAL_API_COOLDOWN"`                // пауза в запросах к недоступному внешнему API
+	ExternalAPITimeout    time.Duration `mapstructure:"EXTERNAL_API_TIMEOUT" reload:"true"`   // время на запрос во внешний API
+	HealthCheckTimeout    time.Duration `mapstructure:"HEALTH_CHECK_TIMEOUT" reload:"true"`   // время на проверки /readyz
+	ShutdownDrainDelay    time.Duration `mapstructure:"SHUTDOWN_DRAIN_DELAY" reload:"true"`   // ожидание после снятия готовности до остановки
+}
+
+// defaults значения настроек, не указанных ни в одном источнике.
+var defaults = map[string]interface{}{
+	"ADDRESS":                 "0.0.0.0:7654",
+	"LOG_LEVEL":               "info",
+	"LOG_FORMAT":              "console",
+	"MIGRATION_PATH":          "",
+	"AUTO_MIGRATE":            false,
+	"EXTERNAL_API_URL":        "http://localhost:7777/info",
+	"PAGINATION_LIMIT":        10,
+	"DATABASE_URL":            "",
+	"DB_USER":                 "postgres",
+	"DB_PASSWORD":             "",
+	"DB_HOST":                 "localhost",
+	"DB_PORT":                 "5432",
+	"DB_NAME":                 "postgres",
+	"DB_DRIVER":               "pgx",
+	"DB_MAX_OPEN_CONNS":       25,
+	"DB_MAX_IDLE_CONNS":       5,
+	"DB_CONN_MAX_LIFETIME":    "30m",
+	"DB_CONN_MAX_IDLE_TIME":   "5m",
+	"DB_CONNECT_TIMEOUT":      "30s",
+	"DB_STATS_INTERVAL":       "5m",
+	"SONGBOOK_TEMPLATES_PATH": "",
+	"STORAGE_PATH":            "./data/blobs",
+	"AUDIO_MAX_SIZE":          100 << 20,
+	"IMPORT_MAX_SIZE":         1 << 30,
+	"COVER_MAX_SIZE":          10 << 20,
+	"COVER_MIN_DIMENSION":     200,
+	"COVER_MAX_DIMENSION":     6000,
+	"COVER_THUMBNAIL_SIZES":   "64,256,512",
+	"ADMIN_API_KEY":           "",
+	"JWT_JWKS":                "",
+	"JWT_ISSUER":              "",
+	"JWT_AUDIENCE":            "",
+	"JWT_ROLES_CLAIM":         "roles",
+	"JWT_ROLE_MAPPING":        "",
+	"JWT_DEFAULT_ROLE":        "",
+	"RATE_LIMIT_READ_RPS":     20,
+	"RATE_LIMIT_READ_BURST":   40,
+	"RATE_LIMIT_WRITE_RPS":    1,
+	"RATE_LIMIT_WRITE_BURST":  5,
+	"RATE_LIMIT_IP_RPS":       50,
+	"RATE_LIMIT_IP_BURST":     100,
+	"RATE_LIMIT_TRUST_PROXY":  false,
+	"RECOMMEND_CACHE_TTL":     "10m",
+	"TRASH_RETENTION":         "720h",
+	"TRASH_PURGE_INTERVAL":    "1h",
+	"TRACING_EXPORTER":        "none",
+	"TRACING_OTLP_ENDPOINT":   "",
+	"TRACING_OTLP_INSECURE":   true,
+	"TRACING_SAMPLE_RATIO":    1,
+	"EXTERNAL_API_FAILURES":   5,
+	"EXTERNAL_API_COOLDOWN":   "30s",
+	"EXTERNAL_API_TIMEOUT":    "10s",
+	"HEALTH_CHECK_TIMEOUT":    "2s",
+	"SHUTDOWN_DRAIN_DELAY":    "5s",
+}
+
+// secretFileSuffix суффикс настройки с путём до файла, из которого читается секрет,
+// например DB_PASSWORD_FILE=/run/secrets/db_password.
+const secretFileSuffix = "_FILE"
+
+// Options источники настроек.
+type Options struct {
+	Dir       string            // папка с файлом .env, пусто - текущая; файл необязателен
+	File      string            // файл YAML или TOML, пусто - не используется
+	Overrides map[string]string // значения из флагов командной строки
+}
+
+// Load загружает настройки из всех источников и проверяет их. При ошибке проверки
+// возвращает загруженные настройки вместе с *ValidationError, чтобы их можно было вывести.
+func Load(opts Options) (Config, error) {
+	v := viper.New()
+	for key, val := range defaults {
+		v.SetDefault(key, val)
+	}
+
+	// Файл YAML или TOML, затем .env: значения из .env важнее.
+	if opts.File != "" {
+		ext := strings.TrimPrefix(filepath.Ext(opts.File), ".")
+		switch ext {
+		case "yaml", "yml", "toml":
+		default:
+			return Config{}, fmt.Errorf("unsupported config file %q, expected .yaml, .yml or .toml", opts.File)
+		}
+		v.SetConfigFile(opts.File)
+		v.SetConfigType(ext)
+		if err := v.ReadInConfig(); err != nil {
+			return Config{}, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	envFile := filepath.Join(opts.Dir, ".env")
+	if _, err := os.Stat(envFile); err == nil {
+		v.SetConfigFile(envFile)
+		v.SetConfigType("env")
+		if errMerge := v.MergeInConfig(); errMerge != nil {
+			return Config{}, fmt.Errorf("failed to read %s: %w", envFile, errMerge)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, err
+	}
+
+	// Переменные окружения с теми же именами важнее файлов.
+	v.AutomaticEnv()
+
+	for key, val := range opts.Overrides {
+		key = strings.ToUpper(key)
+		if _, ok := defaults[key]; !ok && !isSecretFileKey(key) {
+			return Config{}, fmt.Errorf("unknown setting %q", key)
+		}
+		v.Set(key, val)
+	}
+
+	if err := readSecretFiles(v); err != nil {
+		return Config{}, err
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config: %w", err)
+	}
+	cfg.applyDSN()
+
+	return cfg, cfg.Validate()
+}
+
+// LoadConfig загружает настройки из файла '.env' в папке path и переменных окружения.
+func LoadConfig(path string) (Config, error) {
+	return Load(Options{Dir: path})
+}
+
+// readSecretFiles подставляет значения секретов из файлов, указанных в KEY_FILE.
+// Файл важнее значения KEY, поэтому KEY из .env не мешает передать секрет файлом.
+func readSecretFiles(v *viper.Viper) error {
+	var errs []error
+	for _, f := range fields() {
+		if !f.secret {
+			continue
+		}
+
+		path := v.GetString(f.key + secretFileSuffix)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s%s: %w", f.key, secretFileSuffix, err))
+			continue
+		}
+		// Файлы секретов обычно заканчиваются переводом строки.
+		v.Set(f.key, strings.TrimRight(string(data), "\r\n"))
+	}
+	return errors.Join(errs...)
+}
+
+func isSecretFileKey(key string) bool {
+	name, ok := strings.CutSuffix(key, secretFileSuffix)
+	if !ok {
+		return false
+	}
+	for _, f := range fields() {
+		if f.key == name && f.secret {
+			return true
+		}
+	}
+	return false
+}
+
+// applyDSN заполняет DB_* из DATABASE_URL. Ошибки в адресе сообщает Validate.
+func (c *Config) applyDSN() {
+	if c.DatabaseDSN == "" {
+		return
+	}
+	u, err := url.Parse(c.DatabaseDSN)
 	if err != nil {
 		return
 	}
 
-	err = viper.Unmarshal(&config)
-	return
+	c.DatabaseUser = u.User.Username()
+	c.DatabasePassword, _ = u.User.Password()
+	c.DatabaseHost = u.Hostname()
+	c.DatabasePort = u.Port()
+	if c.DatabasePort == "" {
+		c.DatabasePort = "5432"
+	}
+	c.DatabaseName = strings.TrimPrefix(u.Path, "/")
+}
+
+// DatabaseURL конфигурирует путь для подключения к PostgreSQL. Параметры подключения
+// из DATABASE_URL сохраняются, без него используется sslmode=disable.
+func (c Config) DatabaseURL() string {
+	u := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(c.DatabaseUser, c.DatabasePassword),
+		Host:     net.JoinHostPort(c.DatabaseHost, c.DatabasePort),
+		Path:     "/" + c.DatabaseName,
+		RawQuery: "sslmode=disable",
+	}
+	if c.DatabaseDSN != "" {
+		if dsn, err := url.Parse(c.DatabaseDSN); err == nil {
+			u.Scheme, u.RawQuery = dsn.Scheme, dsn.RawQuery
+		}
+	}
+	return u.String()
+}
+
+// field настройка из тега mapstructure поля Config.
+type field struct {
+	key    string
+	index  int
+	secret bool
+	reload bool // меняется без перезапуска, см. Live
+}
+
+// fields возвращает настройки в порядке полей Config.
+func fields() []field {
+	t := reflect.TypeOf(Config{})
+	res := make([]field, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		res = append(res, field{
+			key:    sf.Tag.Get("mapstructure"),
+			index:  i,
+			secret: sf.Tag.Get("secret") == "true",
+			reload: sf.Tag.Get("reload") == "true",
+		})
+	}
+	return res
 }
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// Change изменение настройки при перезагрузке.
+type Change struct {
+	Key string
+	Old string
+	New string
+}
+
+// Live действующие настройки работающего сервера. Настройки с тегом reload:"true"
+// заменяются при перезагрузке, для остальных нужен перезапуск.
+type Live struct {
+	cur atomic.Pointer[Config]
+}
+
+// NewLive создаёт действующие настройки из cfg.
+func NewLive(cfg Config) *Live {
+	l := &Live{}
+	l.cur.Store(&cfg)
+	return l
+}
+
+// Load возвращает действующие настройки. Настройки не меняются, пока их читают:
+// перезагрузка заменяет их целиком.
+func (l *Live) Load() Config {
+	return *l.cur.Load()
+}
+
+// Update применяет к действующим настройкам перезагружаемые значения из next. Возвращает
+// применённые изменения и имена изменившихся настроек, для которых нужен перезапуск.
+// Значения секретов в изменениях скрыты.
+func (l *Live) Update(next Config) (changes []Change, restart []string) {
+	cur := l.Load()
+	updated := cur
+
+	curV, nextV := reflect.ValueOf(cur), reflect.ValueOf(next)
+	dst := reflect.ValueOf(&updated).Elem()
+	for _, f := range fields() {
+		oldVal, newVal := curV.Field(f.index), nextV.Field(f.index)
+		if reflect.DeepEqual(oldVal.Interface(), newVal.Interface()) {
+			continue
+		}
+		if !f.reload {
+			restart = append(restart, f.key)
+			continue
+		}
+
+		dst.Field(f.index).Set(newVal)
+		c := Change{Key: f.key, Old: formatValue(oldVal), New: formatValue(newVal)}
+		if f.secret {
+			c.Old, c.New = redact(f.key, c.Old), redact(f.key, c.New)
+		}
+		changes = append(changes, c)
+	}
+
+	if len(changes) > 0 {
+		l.cur.Store(&updated)
+	}
+	return changes, restart
+}
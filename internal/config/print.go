@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// redacted заменяет значения секретов при выводе настроек.
+const redacted = "[redacted]"
+
+// Entry настройка для вывода.
+type Entry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// Entries возвращает действующие настройки в порядке полей Config. Значения секретов
+// заменяются на [redacted], в DATABASE_URL скрывается только пароль.
+func (c Config) Entries() []Entry {
+	rv := reflect.ValueOf(c)
+	var res []Entry
+	for _, f := range fields() {
+		e := Entry{Key: f.key, Value: formatValue(rv.Field(f.index)), Secret: f.secret}
+		if f.secret && e.Value != "" {
+			e.Value = redact(f.key, e.Value)
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+func redact(key, value string) string {
+	if key == "DATABASE_URL" {
+		if u, err := url.Parse(value); err == nil {
+			if _, hasPassword := u.User.Password(); hasPassword {
+				u.User = url.UserPassword(u.User.Username(), redacted)
+			}
+			return strings.Replace(u.String(), url.QueryEscape(redacted), redacted, 1)
+		}
+	}
+	return redacted
+}
+
+// formatValue записывает значение в том же виде, в каком его принимает .env.
+func formatValue(v reflect.Value) string {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	if v.Kind() == reflect.Slice {
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ValidationError список ошибок в настройках, чтобы исправить их все за один раз.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// validator собирает ошибки проверки.
+type validator struct {
+	problems []string
+}
+
+func (v *validator) check(ok bool, key, format string, args ...interface{}) {
+	if !ok {
+		v.problems = append(v.problems, key+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+func oneOf(val string, allowed ...string) bool {
+	for _, a := range allowed {
+		if val == a {
+			return true
+		}
+	}
+	return false
+}
+
+// httpURL проверяет, что raw - адрес http или https с хостом.
+func httpURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// Validate проверяет настройки и возвращает *ValidationError со всеми найденными ошибками.
+func (c Config) Validate() error {
+	v := &validator{}
+
+	_, port, err := net.SplitHostPort(c.ServerHost)
+	v.check(err == nil && port != "", "ADDRESS", "must be host:port, got %q", c.ServerHost)
+	v.check(oneOf(c.LogLevel, "debug", "info", "warn", "error", "dpanic", "panic", "fatal"),
+		"LOG_LEVEL", "must be debug, info, warn, error, dpanic, panic or fatal, got %q", c.LogLevel)
+	v.check(oneOf(c.LogFormat, "", "console", "json"), "LOG_FORMAT", "must be console or json, got %q", c.LogFormat)
+	v.check(c.MigrationPath == "" || strings.Contains(c.MigrationPath, "://"),
+		"MIGRATION_PATH", "must be a URL like file://db/migration, got %q", c.MigrationPath)
+	v.check(httpURL(c.ExternalAPIURL), "EXTERNAL_API_URL", "must be an http or https URL, got %q", c.ExternalAPIURL)
+	v.check(c.PaginationLimit > 0, "PAGINATION_LIMIT", "must be positive, got %d", c.PaginationLimit)
+
+	if c.DatabaseDSN != "" {
+		u, errDSN := url.Parse(c.DatabaseDSN)
+		v.check(errDSN == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") && u.Host != "",
+			"DATABASE_URL", "must be a postgres:// URL")
+	}
+	v.check(c.DatabaseDriver != "", "DB_DRIVER", "must not be empty")
+	v.check(c.DatabaseUser != "", "DB_USER", "must not be empty")
+	v.check(c.DatabaseHost != "", "DB_HOST", "must not be empty")
+	v.check(c.DatabaseName != "", "DB_NAME", "must not be empty")
+	dbPort, errPort := strconv.Atoi(c.DatabasePort)
+	v.check(errPort == nil && dbPort > 0 && dbPort < 65536, "DB_PORT", "must be a port number, got %q", c.DatabasePort)
+	v.check(c.DatabaseMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS", "must not be negative, got %d", c.DatabaseMaxOpenConns)
+	v.check(c.DatabaseMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS", "must not be negative, got %d", c.DatabaseMaxIdleConns)
+	v.check(c.DatabaseMaxOpenConns == 0 || c.DatabaseMaxIdleConns <= c.DatabaseMaxOpenConns, "DB_MAX_IDLE_CONNS",
+		"must not be greater than DB_MAX_OPEN_CONNS %d, got %d", c.DatabaseMaxOpenConns, c.DatabaseMaxIdleConns)
+	v.check(c.DatabaseConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME", "must not be negative")
+	v.check(c.DatabaseConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME", "must not be negative")
+	v.check(c.DatabaseConnectTimeout > 0, "DB_CONNECT_TIMEOUT", "must be positive")
+	v.check(c.DatabaseStatsInterval >= 0, "DB_STATS_INTERVAL", "must not be negative")
+
+	v.check(c.StoragePath != "", "STORAGE_PATH", "must not be empty")
+	v.check(c.AudioMaxSize > 0, "AUDIO_MAX_SIZE", "must be positive, got %d", c.AudioMaxSize)
+	v.check(c.ImportMaxSize > 0, "IMPORT_MAX_SIZE", "must be positive, got %d", c.ImportMaxSize)
+	v.check(c.CoverMaxSize > 0, "COVER_MAX_SIZE", "must be positive, got %d", c.CoverMaxSize)
+	v.check(c.CoverMinDimension > 0, "COVER_MIN_DIMENSION", "must be positive, got %d", c.CoverMinDimension)
+	v.check(c.CoverMaxDimension >= c.CoverMinDimension, "COVER_MAX_DIMENSION",
+		"must not be less than COVER_MIN_DIMENSION %d, got %d", c.CoverMinDimension, c.CoverMaxDimension)
+	for _, size := range c.CoverThumbnailSizes {
+		v.check(size > 0, "COVER_THUMBNAIL_SIZES", "sizes must be positive, got %d", size)
+	}
+
+	v.check(c.JWTJWKS == "" || c.JWTRolesClaim != "", "JWT_ROLES_CLAIM", "must not be empty when JWT_JWKS is set")
+	v.check(oneOf(c.JWTDefaultRole, "", "reader", "editor", "admin"),
+		"JWT_DEFAULT_ROLE", "must be reader, editor or admin, got %q", c.JWTDefaultRole)
+
+	v.check(c.RateLimitReadRPS >= 0, "RATE_LIMIT_READ_RPS", "must not be negative")
+	v.check(c.RateLimitReadBurst >= 0, "RATE_LIMIT_READ_BURST", "must not be negative")
+	v.check(c.RateLimitWriteRPS >= 0, "RATE_LIMIT_WRITE_RPS", "must not be negative")
+	v.check(c.RateLimitWriteBurst >= 0, "RATE_LIMIT_WRITE_BURST", "must not be negative")
+	v.check(c.RateLimitIPRPS >= 0, "RATE_LIMIT_IP_RPS", "must not be negative")
+	v.check(c.RateLimitIPBurst >= 0, "RATE_LIMIT_IP_BURST", "must not be negative")
+	v.check(c.RecommendCacheTTL >= 0, "RECOMMEND_CACHE_TTL", "must not be negative")
+	v.check(c.TrashRetention > 0, "TRASH_RETENTION", "must be positive")
+	v.check(c.TrashPurgeInterval >= 0, "TRASH_PURGE_INTERVAL", "must not be negative")
+
+	v.check(oneOf(c.TracingExporter, "", "none", "stdout", "otlp"),
+		"TRACING_EXPORTER", "must be none, stdout or otlp, got %q", c.TracingExporter)
+	v.check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1,
+		"TRACING_SAMPLE_RATIO", "must be between 0 and 1, got %g", c.TracingSampleRatio)
+
+	v.check(c.ExternalAPIFailures >= 0, "EXTERNAL_API_FAILURES", "must not be negative")
+	v.check(c.ExternalAPICooldown >= 0, "EXTERNAL_API_COOLDOWN", "must not be negative")
+	v.check(c.ExternalAPITimeout > 0, "EXTERNAL_API_TIMEOUT", "must be positive")
+	v.check(c.HealthCheckTimeout > 0, "HEALTH_CHECK_TIMEOUT", "must be positive")
+	v.check(c.ShutdownDrainDelay >= 0, "SHUTDOWN_DRAIN_DELAY", "must not be negative")
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Package cover проверяет обложки и генерирует их уменьшенные копии.
+package cover
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // регистрирует декодер PNG
+	"sort"
+
+	"fmt"
+
+	"golang.org/x/image/draw"
+)
+
+// thumbnailQuality качество JPEG для уменьшенных копий.
+const thumbnailQuality = 85
+
+var (
+	// ErrUnsupportedFormat возвращается, если изображение не в формате JPEG или PNG.
+	ErrUnsupportedFormat = errors.New("unsupported image format, expected JPEG or PNG")
+	// ErrInvalidDimensions возвращается, если размеры изображения вне допустимых пределов.
+	ErrInvalidDimensions = errors.New("invalid image dimensions")
+)
+
+// Options ограничения для обложек и размеры уменьшенных копий.
+type Options struct {
+	MinDimension   int   // минимальная ширина и высота оригинала в пикселях
+	MaxDimension   int   // максимальная ширина и высота оригинала в пикселях
+	ThumbnailSizes []int // размеры квадрата, в который вписывается уменьшенная копия
+}
+
+// Image закодированное изображение.
+type Image struct {
+	Size        int // размер уменьшенной копии, 0 для оригинала
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Process проверяет формат и размеры обложки и возвращает оригинал вместе с уменьшенными
+// копиями в формате JPEG, отсортированными по возрастанию размера.
+func Process(data []byte, opts Options) ([]Image, error) {
+	// Сначала читаем только заголовок, чтобы не декодировать слишком большие изображения.
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return nil, ErrUnsupportedFormat
+	}
+
+	if cfg.Width < opts.MinDimension || cfg.Height < opts.MinDimension ||
+		(opts.MaxDimension > 0 && (cfg.Width > opts.MaxDimension || cfg.Height > opts.MaxDimension)) {
+		return nil, fmt.Errorf("%w: %dx%d, allowed from %d to %d pixels",
+			ErrInvalidDimensions, cfg.Width, cfg.Height, opts.MinDimension, opts.MaxDimension)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedFormat, err)
+	}
+
+	images := []Image{{
+		ContentType: "image/" + format,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Data:        data,
+	}}
+
+	for _, size := range normalizeSizes(opts.ThumbnailSizes) {
+		thumb, errThumb := thumbnail(src, size)
+		if errThumb != nil {
+			return nil, fmt.Errorf("failed to create %dpx thumbnail: %w", size, errThumb)
+		}
+		images = append(images, thumb)
+	}
+
+	return images, nil
+}
+
+// thumbnail вписывает изображение в квадрат size x size с сохранением пропорций.
+// Изображения меньше квадрата не увеличиваются.
+func thumbnail(src image.Image, size int) (Image, error) {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	// JPEG не поддерживает прозрачность, поэтому подкладываем белый фон.
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		Size:        size,
+		ContentType: "image/jpeg",
+		Width:       w,
+		Height:      h,
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+// normalizeSizes убирает повторы и неположительные размеры и сортирует по возрастанию.
+func normalizeSizes(sizes []int) []int {
+	seen := make(map[int]bool)
+	var res []int
+	for _, s := range sizes {
+		if s > 0 && !seen[s] {
+			seen[s] = true
+			res = append(res, s)
+		}
+	}
+	sort.Ints(res)
+	return res
+}
@@ -0,0 +1,159 @@
+// Package database открывает пул соединений с базой данных: настраивает его размер
+// и время жизни соединений, при запуске ждёт готовности базы данных и периодически
+// записывает статистику пула в журнал.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+)
+
+// PoolOptions настройки пула соединений. Нулевые значения означают ограничения
+// database/sql по умолчанию.
+type PoolOptions struct {
+	MaxOpenConns    int           // соединений всего, 0 - без ограничения
+	MaxIdleConns    int           // простаивающих соединений
+	ConnMaxLifetime time.Duration // время жизни соединения, 0 - без ограничения
+	ConnMaxIdleTime time.Duration // время простоя соединения до закрытия, 0 - без ограничения
+}
+
+// RetryOptions повторы подключения при запуске.
+type RetryOptions struct {
+	Timeout      time.Duration // время на все попытки
+	InitialDelay time.Duration // пауза после первой неудачной попытки
+	MaxDelay     time.Duration // максимальная пауза, паузы удваиваются до неё
+}
+
+// DefaultRetry повторы подключения, если в RetryOptions не указаны паузы.
+var DefaultRetry = RetryOptions{
+	Timeout:      30 * time.Second,
+	InitialDelay: 500 * time.Millisecond,
+	MaxDelay:     10 * time.Second,
+}
+
+// Pool возвращает настройки пула из настроек приложения DB_*.
+func Pool(cfg config.Config) PoolOptions {
+	return PoolOptions{
+		MaxOpenConns:    cfg.DatabaseMaxOpenConns,
+		MaxIdleConns:    cfg.DatabaseMaxIdleConns,
+		ConnMaxLifetime: cfg.DatabaseConnMaxLifetime,
+		ConnMaxIdleTime: cfg.DatabaseConnMaxIdleTime,
+	}
+}
+
+// Connect открывает пул соединений с базой данных из настроек приложения и ждёт её
+// готовности не дольше DB_CONNECT_TIMEOUT.
+func Connect(ctx context.Context, cfg config.Config) (*sql.DB, error) {
+	retry := DefaultRetry
+	retry.Timeout = cfg.DatabaseConnectTimeout
+	return Open(ctx, cfg.DatabaseDriver, cfg.DatabaseURL(), Pool(cfg), retry)
+}
+
+// Configure применяет настройки пула. Их можно менять у открытого пула.
+func Configure(db *sql.DB, opts PoolOptions) {
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+}
+
+// Open открывает пул соединений, настраивает его и ждёт готовности базы данных.
+// При ошибке пул закрывается.
+func Open(ctx context.Context, driver, dsn string, pool PoolOptions, retry RetryOptions) (*sql.DB, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create connection to database: %w", err)
+	}
+	Configure(db, pool)
+
+	if err = Ping(ctx, db, retry); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Ping проверяет подключение к базе данных и повторяет попытки с растущими паузами,
+// пока база данных не ответит или не истечёт retry.Timeout. Так сервер дожидается
+// базы данных, которая запускается одновременно с ним.
+func Ping(ctx context.Context, db *sql.DB, retry RetryOptions) error {
+	if retry.InitialDelay <= 0 {
+		retry.InitialDelay = DefaultRetry.InitialDelay
+	}
+	if retry.MaxDelay < retry.InitialDelay {
+		retry.MaxDelay = max(DefaultRetry.MaxDelay, retry.InitialDelay)
+	}
+	if retry.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, retry.Timeout)
+		defer cancel()
+	}
+
+	delay := retry.InitialDelay
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			if attempt > 1 {
+				logger.Ctx(ctx).Info("connected to the database", logger.Int("attempts", attempt))
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("database is unavailable after %d attempts: %w", attempt, err)
+		}
+
+		logger.Ctx(ctx).Warn("database is unavailable, retrying",
+			logger.Int("attempt", attempt),
+			logger.Duration("retry_in", delay),
+			logger.Err(err),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("database is unavailable after %d attempts: %w", attempt, err)
+		case <-timer.C:
+		}
+		delay = min(delay*2, retry.MaxDelay)
+	}
+}
+
+// LogStats записывает статистику пула в журнал каждые interval, пока не отменён ctx.
+// При interval <= 0 статистика не записывается.
+func LogStats(ctx context.Context, db *sql.DB, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var prev sql.DBStats
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			st := db.Stats()
+			// Счётчики ожиданий и закрытий записываются за прошедший интервал.
+			logger.Ctx(ctx).Info("database pool stats",
+				logger.Int("max_open", st.MaxOpenConnections),
+				logger.Int("open", st.OpenConnections),
+				logger.Int("in_use", st.InUse),
+				logger.Int("idle", st.Idle),
+				logger.Int64("wait_count", st.WaitCount-prev.WaitCount),
+				logger.Duration("wait_duration", st.WaitDuration-prev.WaitDuration),
+				logger.Int64("max_idle_closed", st.MaxIdleClosed-prev.MaxIdleClosed),
+				logger.Int64("max_idle_time_closed", st.MaxIdleTimeClosed-prev.MaxIdleTimeClosed),
+				logger.Int64("max_lifetime_closed", st.MaxLifetimeClosed-prev.MaxLifetimeClosed),
+			)
+			prev = st
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+)
+
+// uniqueViolation код ошибки PostgreSQL при нарушении ограничения уникальности.
+const uniqueViolation = "23505"
+
+// IsUniqueViolation сообщает, нарушено ли ограничение уникальности. Распознаются
+// ошибки драйверов pgx и lib/pq.
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == uniqueViolation
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == uniqueViolation
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+// Package dedupe нормализует названия групп и песен для проверки уникальности
+// и находит похожие названия, которые могут оказаться дубликатами.
+package dedupe
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Key возвращает ключ уникальности названия: Unicode NFKC, свёртка регистра,
+// пробелы по краям удалены, пробелы внутри сжаты до одного.
+// "Muse", "muse" и "Muse " дают один ключ "muse".
+func Key(name string) string {
+	// NFKC повторяется после свёртки регистра, свёртка может нарушить нормальную форму.
+	s := norm.NFKC.String(cases.Fold().String(norm.NFKC.String(name)))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// words возвращает слова ключа без знаков препинания.
+func words(key string) []string {
+	return strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Similarity оценивает сходство двух ключей от 0 до 1. Оценка - наибольшее из сходства
+// по расстоянию Левенштейна и доли общих слов, знаки препинания не учитываются.
+// Так "the killers" похоже и на "the killerz", и на "killers, the".
+func Similarity(a, b string) float64 {
+	wa, wb := words(a), words(b)
+	ca, cb := []rune(strings.Join(wa, " ")), []rune(strings.Join(wb, " "))
+	if len(ca) == 0 || len(cb) == 0 {
+		if len(ca) == len(cb) {
+			return 1
+		}
+		return 0
+	}
+
+	score := 1 - float64(levenshtein(ca, cb))/float64(max(len(ca), len(cb)))
+	return max(score, jaccard(wa, wb))
+}
+
+// levenshtein возвращает число вставок, удалений и замен символов, превращающих a в b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// jaccard возвращает долю общих слов среди всех слов a и b.
+func jaccard(a, b []string) float64 {
+	set := make(map[string]bool, len(a))
+	for _, w := range a {
+		set[w] = true
+	}
+	common, union := 0, len(set)
+	seen := make(map[string]bool, len(b))
+	for _, w := range b {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		if set[w] {
+			common++
+		} else {
+			union++
+		}
+	}
+	return float64(common) / float64(union)
+}
+
+// Item название, среди которых ищутся дубликаты.
+type Item struct {
+	ID    int32
+	Scope int32 // сравниваются только названия одной области, например песни одной группы
+	Name  string
+}
+
+// Pair пара похожих названий.
+type Pair struct {
+	A, B  int32 // ID элементов, A < B
+	Score float64
+}
+
+// Pairs возвращает пары названий одной области со сходством не меньше minScore,
+// начиная с самых похожих. Сложность квадратичная от размера области.
+func Pairs(items []Item, minScore float64) []Pair {
+	type keyed struct {
+		id  int32
+		key string
+	}
+	scopes := make(map[int32][]keyed)
+	for _, it := range items {
+		scopes[it.Scope] = append(scopes[it.Scope], keyed{id: it.ID, key: Key(it.Name)})
+	}
+
+	var pairs []Pair
+	for _, scope := range scopes {
+		for i := range scope {
+			for j := i + 1; j < len(scope); j++ {
+				score := 1.0
+				if scope[i].key != scope[j].key {
+					score = Similarity(scope[i].key, scope[j].key)
+				}
+				if score < minScore {
+					continue
+				}
+				a, b := scope[i].id, scope[j].id
+				if a > b {
+					a, b = b, a
+				}
+				pairs = append(pairs, Pair{A: a, B: b, Score: score})
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	return pairs
+}
@@ -0,0 +1,60 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/database"
+)
+
+// FillKeys заполняет пустые ключи групп и песен через Key и возвращает число заполненных
+// ключей. Миграция добавляет ключи пустыми: lower и normalize в SQL не повторяют свёртку
+// регистра Key, например "ß" и "ss", и записи с ключами из SQL не находились бы при добавлении.
+// Повторный вызов проходит только по записям, ключи которых ещё не заполнены. Если ключ
+// уже занят другой записью, запись остаётся с пустым ключом: это дубликат, который
+// выводится в отчёте о дубликатах и объединяется с найденной записью.
+func FillKeys(ctx context.Context, q *db.Queries) (int, error) {
+	filled := 0
+
+	artists, err := q.ListArtistsWithoutKey(ctx)
+	if err != nil {
+		return filled, fmt.Errorf("failed to list groups without key: %w", err)
+	}
+	for _, a := range artists {
+		// Ключ пустого названия остаётся пустым.
+		key := Key(a.Group)
+		if key == "" {
+			continue
+		}
+		err = q.SetArtistKey(ctx, db.SetArtistKeyParams{ID: a.ID, GroupKey: key})
+		if database.IsUniqueViolation(err) {
+			continue
+		}
+		if err != nil {
+			return filled, fmt.Errorf("failed to set key of group %d: %w", a.ID, err)
+		}
+		filled++
+	}
+
+	songs, err := q.ListSongsWithoutKey(ctx)
+	if err != nil {
+		return filled, fmt.Errorf("failed to list songs without key: %w", err)
+	}
+	for _, s := range songs {
+		key := Key(s.Song)
+		if key == "" {
+			continue
+		}
+		err = q.SetSongKey(ctx, db.SetSongKeyParams{ID: s.ID, SongKey: key})
+		if database.IsUniqueViolation(err) {
+			continue
+		}
+		if err != nil {
+			return filled, fmt.Errorf("failed to set key of song %d: %w", s.ID, err)
+		}
+		filled++
+	}
+
+	return filled, nil
+}
@@ -0,0 +1,221 @@
+package export
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+)
+
+// DefaultBatchSize количество песен, получаемых из базы данных за один запрос.
+const DefaultBatchSize int32 = 500
+
+// Format формат выгрузки библиотеки.
+type Format string
+
+const (
+	FormatNDJSON Format = "ndjson"
+	FormatCSV    Format = "csv"
+	FormatJSON   Format = "json"
+)
+
+// ParseFormat проверяет и возвращает формат выгрузки. Пустая строка означает NDJSON.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return FormatNDJSON, nil
+	case FormatNDJSON, FormatCSV, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", s)
+	}
+}
+
+// ContentType возвращает MIME тип для формата выгрузки.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=UTF-8"
+	case FormatJSON:
+		return "application/json; charset=UTF-8"
+	default:
+		return "application/x-ndjson; charset=UTF-8"
+	}
+}
+
+// Source источник песен для выгрузки, реализуется *db.Queries.
+type Source interface {
+	ExportWithFilters(ctx context.Context, arg db.ExportWithFiltersParams) ([]db.ExportWithFiltersRow, error)
+}
+
+// Exporter выгружает песни из базы данных порциями, не загружая всю библиотеку в память.
+type Exporter struct {
+	src       Source
+	batchSize int32
+}
+
+// New создаёт Exporter. Если batchSize < 1, используется DefaultBatchSize.
+func New(src Source, batchSize int32) *Exporter {
+	if batchSize < 1 {
+		batchSize = DefaultBatchSize
+	}
+	return &Exporter{src: src, batchSize: batchSize}
+}
+
+// Songs записывает в w все песни, подходящие под фильтр, в указанном формате.
+// Если compress == true, то результат сжимается gzip. Возвращает количество выгруженных песен.
+func (e *Exporter) Songs(ctx context.Context, w io.Writer, format Format, filter models.SongFilter, compress bool) (int, error) {
+	if compress {
+		gz := gzip.NewWriter(w)
+		n, err := e.write(ctx, newRecordWriter(gz, format), filter)
+		if errClose := gz.Close(); errClose != nil && err == nil {
+			err = fmt.Errorf("failed to close gzip writer: %w", errClose)
+		}
+		return n, err
+	}
+	return e.write(ctx, newRecordWriter(w, format), filter)
+}
+
+// write получает из базы данных порциями песни, подходящие под фильтр, и передаёт их rw.
+func (e *Exporter) write(ctx context.Context, rw recordWriter, filter models.SongFilter) (int, error) {
+	if err := rw.begin(); err != nil {
+		return 0, err
+	}
+
+	params := db.ExportWithFiltersParams{
+		Column2:     sql.NullString{String: filter.Group, Valid: filter.Group != ""},
+		Column3:     sql.NullString{String: filter.Song, Valid: filter.Song != ""},
+		ReleaseDate: filter.ReleaseDate,
+		Column5:     sql.NullString{String: filter.Text, Valid: filter.Text != ""},
+		Limit:       e.batchSize,
+	}
+
+	var total int
+	for {
+		// Получаем следующую порцию песен, начиная с последнего выгруженного ID.
+		rows, err := e.src.ExportWithFilters(ctx, params)
+		if err != nil {
+			return total, fmt.Errorf("failed to fetch songs for export: %w", err)
+		}
+
+		for _, row := range rows {
+			written, errWrite := rw.write(toExportSong(row))
+			if errWrite != nil {
+				return total, fmt.Errorf("failed to write song %d: %w", row.ID, errWrite)
+			}
+			if written {
+				total++
+			}
+		}
+
+		if int32(len(rows)) < e.batchSize {
+			break
+		}
+		params.ID = rows[len(rows)-1].ID
+	}
+
+	return total, rw.end()
+}
+
+func toExportSong(row db.ExportWithFiltersRow) models.ExportSong {
+	return models.ExportSong{
+		ID:          row.ID,
+		Group:       row.Group,
+		Song:        row.Song,
+		ReleaseDate: row.ReleaseDate.Format("2006-01-02"),
+		Text:        row.Text,
+		Link:        row.Link,
+	}
+}
+
+// recordWriter записывает песни в конкретном формате.
+// Метод write возвращает false, если песня была пропущена.
+type recordWriter interface {
+	begin() error
+	write(s models.ExportSong) (bool, error)
+	end() error
+}
+
+func newRecordWriter(w io.Writer, format Format) recordWriter {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}
+	case FormatJSON:
+		return &jsonArrayWriter{w: w}
+	default:
+		return &ndjsonWriter{enc: json.NewEncoder(w)}
+	}
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) begin() error { return nil }
+
+func (n *ndjsonWriter) write(s models.ExportSong) (bool, error) { return true, n.enc.Encode(s) }
+
+func (n *ndjsonWriter) end() error { return nil }
+
+type jsonArrayWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonArrayWriter) begin() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonArrayWriter) write(s models.ExportSong) (bool, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return false, err
+	}
+	if j.count > 0 {
+		if _, err = io.WriteString(j.w, ","); err != nil {
+			return false, err
+		}
+	}
+	j.count++
+	_, err = j.w.Write(data)
+	return true, err
+}
+
+func (j *jsonArrayWriter) end() error {
+	_, err := io.WriteString(j.w, "]\n")
+	return err
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) begin() error {
+	return c.w.Write([]string{"id", "group", "song", "releaseDate", "text", "link"})
+}
+
+func (c *csvWriter) write(s models.ExportSong) (bool, error) {
+	return true, c.w.Write([]string{
+		strconv.Itoa(int(s.ID)),
+		s.Group,
+		s.Song,
+		s.ReleaseDate,
+		s.Text,
+		s.Link,
+	})
+}
+
+func (c *csvWriter) end() error {
+	c.w.Flush()
+	return c.w.Error()
+}
@@ -0,0 +1,159 @@
+package export
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+)
+
+// PlaylistFormat формат плейлиста для плееров.
+type PlaylistFormat string
+
+const (
+	PlaylistM3U8 PlaylistFormat = "m3u8"
+	PlaylistXSPF PlaylistFormat = "xspf"
+)
+
+// ParsePlaylistFormat проверяет и возвращает формат плейлиста. Пустая строка означает M3U8.
+func ParsePlaylistFormat(s string) (PlaylistFormat, error) {
+	switch f := PlaylistFormat(strings.ToLower(s)); f {
+	case "":
+		return PlaylistM3U8, nil
+	case PlaylistM3U8, PlaylistXSPF:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported playlist format: %s", s)
+	}
+}
+
+// ContentType возвращает MIME тип для формата плейлиста.
+func (f PlaylistFormat) ContentType() string {
+	if f == PlaylistXSPF {
+		return "application/xspf+xml; charset=UTF-8"
+	}
+	return "application/vnd.apple.mpegurl; charset=UTF-8"
+}
+
+// Playlist записывает в w плейлист из песен, подходящих под фильтр. В качестве адреса
+// трека используется поле link, поэтому песни без ссылки в плейлист не попадают.
+// Возвращает количество добавленных треков.
+func (e *Exporter) Playlist(ctx context.Context, w io.Writer, format PlaylistFormat, title string, filter models.SongFilter) (int, error) {
+	return e.write(ctx, newPlaylistWriter(w, format, title), filter)
+}
+
+// WritePlaylist записывает в w плейлист из песен songs в заданном порядке, например
+// из сохранённого плейлиста пользователя. Песни без ссылки пропускаются.
+// Возвращает количество добавленных треков.
+func WritePlaylist(w io.Writer, format PlaylistFormat, title string, songs []models.ExportSong) (int, error) {
+	rw := newPlaylistWriter(w, format, title)
+	if err := rw.begin(); err != nil {
+		return 0, err
+	}
+
+	var total int
+	for _, s := range songs {
+		written, err := rw.write(s)
+		if err != nil {
+			return total, fmt.Errorf("failed to write song %d: %w", s.ID, err)
+		}
+		if written {
+			total++
+		}
+	}
+	return total, rw.end()
+}
+
+func newPlaylistWriter(w io.Writer, format PlaylistFormat, title string) recordWriter {
+	if format == PlaylistXSPF {
+		return &xspfWriter{w: w, enc: xml.NewEncoder(w), title: title}
+	}
+	return &m3u8Writer{w: w, title: title}
+}
+
+// m3u8Writer записывает плейлист в расширенном формате M3U в кодировке UTF-8.
+type m3u8Writer struct {
+	w     io.Writer
+	title string
+}
+
+func (m *m3u8Writer) begin() error {
+	header := "#EXTM3U\n"
+	if m.title != "" {
+		header += "#PLAYLIST:" + oneLine(m.title) + "\n"
+	}
+	_, err := io.WriteString(m.w, header)
+	return err
+}
+
+func (m *m3u8Writer) write(s models.ExportSong) (bool, error) {
+	if s.Link == "" {
+		return false, nil
+	}
+	// Длительность песни неизвестна, поэтому указываем -1.
+	_, err := fmt.Fprintf(m.w, "#EXTINF:-1,%s - %s\n%s\n", oneLine(s.Group), oneLine(s.Song), oneLine(s.Link))
+	return err == nil, err
+}
+
+func (m *m3u8Writer) end() error { return nil }
+
+// xspfWriter записывает плейлист в формате XSPF (XML Shareable Playlist Format).
+type xspfWriter struct {
+	w     io.Writer
+	enc   *xml.Encoder
+	title string
+}
+
+type xspfTrack struct {
+	XMLName  xml.Name `xml:"track"`
+	Location string   `xml:"location"`
+	Creator  string   `xml:"creator,omitempty"`
+	Title    string   `xml:"title,omitempty"`
+}
+
+func (x *xspfWriter) begin() error {
+	header := xml.Header + `<playlist version="1" xmlns="http://xspf.org/ns/0/">`
+	if _, err := io.WriteString(x.w, header); err != nil {
+		return err
+	}
+	if x.title != "" {
+		if err := x.enc.EncodeElement(x.title, xml.StartElement{Name: xml.Name{Local: "title"}}); err != nil {
+			return err
+		}
+		if err := x.enc.Flush(); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(x.w, "<trackList>")
+	return err
+}
+
+func (x *xspfWriter) write(s models.ExportSong) (bool, error) {
+	if s.Link == "" {
+		return false, nil
+	}
+	if err := x.enc.Encode(xspfTrack{
+		Location: s.Link,
+		Creator:  s.Group,
+		Title:    s.Song,
+	}); err != nil {
+		return false, err
+	}
+	return true, x.enc.Flush()
+}
+
+func (x *xspfWriter) end() error {
+	_, err := io.WriteString(x.w, "</trackList></playlist>\n")
+	return err
+}
+
+// lineBreaks заменяет переводы строк пробелами.
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// oneLine убирает переводы строк, чтобы значение не нарушало построчный формат M3U8.
+func oneLine(s string) string {
+	return strings.TrimSpace(lineBreaks.Replace(s))
+}
@@ -0,0 +1,466 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+// errNoAccount возвращается, если запрос выполняется без учётной записи пользователя:
+// ключом администратора из конфигурации или ключом, не привязанным к пользователю.
+var errNoAccount = errors.New("credentials are not linked to a user account")
+
+var (
+	errNotFavorite = errors.New("song is not in favorites")
+	errNotRated    = errors.New("song is not rated")
+)
+
+// callerAccount возвращает ID учётной записи владельца запроса. Пользователь SSO
+// получает учётную запись при первом обращении.
+func (hq *HandleQueries) callerAccount(r *http.Request) (int32, error) {
+	p, ok := auth.PrincipalFromContext(r.Context())
+	switch {
+	case !ok:
+		return 0, errNoAccount
+	case p.AccountID != 0:
+		return p.AccountID, nil
+	case p.Subject == "":
+		return 0, errNoAccount
+	}
+
+	account, err := hq.UpsertSubjectAccount(r.Context(), db.UpsertSubjectAccountParams{
+		Name:    p.Name,
+		Subject: sql.NullString{String: p.Subject, Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create account for SSO user: %w", err)
+	}
+	return account.ID, nil
+}
+
+// songForCaller считывает ID песни из URL и учётную запись владельца запроса.
+// При ошибке отправляет ответ и возвращает ok == false.
+func (hq *HandleQueries) songForCaller(w http.ResponseWriter, r *http.Request) (songID, accountID int32, ok bool) {
+	songID, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || songID < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return 0, 0, false
+	}
+
+	// Проверям существование песни и возвращаем ошибку, если её нет в базе данных.
+	if _, err = hq.GetOne(r.Context(), songID); err != nil {
+		logger.Ctx(r.Context()).Error("ID does not exist")
+		ErrReturn(fmt.Errorf("ID does not exist"), http.StatusBadRequest, w)
+		return 0, 0, false
+	}
+
+	accountID, err = hq.callerAccount(r)
+	if errors.Is(err, errNoAccount) {
+		ErrReturn(err, http.StatusForbidden, w)
+		return 0, 0, false
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to get caller account", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return 0, 0, false
+	}
+
+	return songID, accountID, true
+}
+
+// FavoriteSong обрабатывает PUT запрос и добавляет песню по указанному ID: "?id=21"
+// в избранное пользователя. Повторное добавление не является ошибкой.
+//
+// @Summary Добавляет песню в избранное.
+// @Description Добавляет песню в избранное пользователя, от имени которого выполняется запрос. Ключ должен быть привязан к учётной записи.
+// @Tags user
+// @Produce json
+// @Param id query int32 true "ID песни."
+// @Success 200 {object} map[string]interface{} "{}" "Песня добавлена в избранное."
+// @Failure 400 {object} map[string]string "Некорректный ID песни или песня не существует."
+// @Failure 403 {object} map[string]string "Ключ не привязан к учётной записи пользователя."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/favorite [put]
+func (hq *HandleQueries) FavoriteSong(w http.ResponseWriter, r *http.Request) {
+	songID, accountID, ok := hq.songForCaller(w, r)
+	if !ok {
+		return
+	}
+
+	err := hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		affected, errAdd := qtx.AddFavorite(r.Context(), db.AddFavoriteParams{AccountID: accountID, SongID: songID})
+		if errAdd != nil {
+			return fmt.Errorf("failed to add favorite: %w", errAdd)
+		}
+		// Песня уже в избранном, изменений нет.
+		if affected == 0 {
+			return nil
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntityFavorite,
+			EntityID: songID,
+			Action:   audit.ActionCreate,
+			After:    audit.Favorite{AccountID: accountID, SongID: songID},
+		})
+	})
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to add favorite", logger.Int64("song", int64(songID)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeEmptyJSON(w, r)
+}
+
+// UnfavoriteSong обрабатывает DELETE запрос и убирает песню по указанному ID: "?id=21"
+// из избранного пользователя.
+//
+// @Summary Убирает песню из избранного.
+// @Description Убирает песню из избранного пользователя, от имени которого выполняется запрос.
+// @Tags user
+// @Produce json
+// @Param id query int32 true "ID песни."
+// @Success 200 {object} map[string]interface{} "{}" "Песня убрана из избранного."
+// @Failure 400 {object} map[string]string "Некорректный ID песни или песня не существует."
+// @Failure 403 {object} map[string]string "Ключ не привязан к учётной записи пользователя."
+// @Failure 404 {object} map[string]string "Песни нет в избранном."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/favorite [delete]
+func (hq *HandleQueries) UnfavoriteSong(w http.ResponseWriter, r *http.Request) {
+	songID, accountID, ok := hq.songForCaller(w, r)
+	if !ok {
+		return
+	}
+
+	err := hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		affected, errDel := qtx.DeleteFavorite(r.Context(), db.DeleteFavoriteParams{AccountID: accountID, SongID: songID})
+		if errDel != nil {
+			return fmt.Errorf("failed to delete favorite: %w", errDel)
+		}
+		if affected == 0 {
+			return errNotFavorite
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntityFavorite,
+			EntityID: songID,
+			Action:   audit.ActionDelete,
+			Before:   audit.Favorite{AccountID: accountID, SongID: songID},
+		})
+	})
+	if errors.Is(err, errNotFavorite) {
+		ErrReturn(err, http.StatusNotFound, w)
+		return
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to remove favorite", logger.Int64("song", int64(songID)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeEmptyJSON(w, r)
+}
+
+// RateSong обрабатывает PUT запрос в формате JSON {"stars": 4} и ставит оценку
+// от 1 до 5 песне по указанному ID: "?id=21". Повторная оценка заменяет предыдущую.
+//
+// @Summary Оценивает песню.
+// @Description Ставит или заменяет оценку песни от 1 до 5 звёзд от имени пользователя. Возвращает среднюю оценку песни.
+// @Tags user
+// @Accept  json
+// @Produce json
+// @Param id query int32 true "ID песни."
+// @Param models.RatingParams body models.RatingParams true "Оценка от 1 до 5."
+// @Success 200 {object} models.SongRating "Оценка сохранена."
+// @Failure 400 {object} map[string]string "Некорректный ID песни или оценка вне диапазона 1-5."
+// @Failure 403 {object} map[string]string "Ключ не привязан к учётной записи пользователя."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/rating [put]
+func (hq *HandleQueries) RateSong(w http.ResponseWriter, r *http.Request) {
+	var params models.RatingParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.Ctx(r.Context()).Error("failed to decode rating", logger.Err(err))
+		ErrReturn(fmt.Errorf("invalid JSON body"), http.StatusBadRequest, w)
+		return
+	}
+	if params.Stars < 1 || params.Stars > 5 {
+		ErrReturn(fmt.Errorf("stars must be between 1 and 5"), http.StatusBadRequest, w)
+		return
+	}
+
+	songID, accountID, ok := hq.songForCaller(w, r)
+	if !ok {
+		return
+	}
+
+	err := hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		ev := audit.Event{
+			Entity:   audit.EntityRating,
+			EntityID: songID,
+			Action:   audit.ActionCreate,
+			After:    audit.Rating{AccountID: accountID, SongID: songID, Stars: int16(params.Stars)},
+		}
+
+		// Повторная оценка записывается в журнал как изменение прежней.
+		prev, errGet := qtx.GetRating(r.Context(), db.GetRatingParams{AccountID: accountID, SongID: songID})
+		switch {
+		case errGet == nil:
+			ev.Action = audit.ActionUpdate
+			ev.Before = audit.Rating{AccountID: accountID, SongID: songID, Stars: prev}
+		case !errors.Is(errGet, sql.ErrNoRows):
+			return fmt.Errorf("failed to get rating: %w", errGet)
+		}
+
+		if errSet := qtx.SetRating(r.Context(), db.SetRatingParams{
+			AccountID: accountID,
+			SongID:    songID,
+			Stars:     int16(params.Stars),
+		}); errSet != nil {
+			return fmt.Errorf("failed to set rating: %w", errSet)
+		}
+		return audit.Record(r.Context(), qtx, ev)
+	})
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to rate song", logger.Int64("song", int64(songID)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	rating, err := hq.GetSongRating(r.Context(), songID)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to get song rating", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resJSON, errJSON := json.Marshal(models.SongRating{
+		SongID:      songID,
+		Rating:      rating.Rating,
+		RatingCount: rating.RatingCount,
+		Stars:       params.Stars,
+	})
+	if errJSON != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// UnrateSong обрабатывает DELETE запрос и удаляет оценку пользователя у песни
+// по указанному ID: "?id=21".
+//
+// @Summary Удаляет оценку песни.
+// @Description Удаляет оценку песни, поставленную пользователем, от имени которого выполняется запрос.
+// @Tags user
+// @Produce json
+// @Param id query int32 true "ID песни."
+// @Success 200 {object} map[string]interface{} "{}" "Оценка удалена."
+// @Failure 400 {object} map[string]string "Некорректный ID песни или песня не существует."
+// @Failure 403 {object} map[string]string "Ключ не привязан к учётной записи пользователя."
+// @Failure 404 {object} map[string]string "Пользователь не оценивал песню."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/rating [delete]
+func (hq *HandleQueries) UnrateSong(w http.ResponseWriter, r *http.Request) {
+	songID, accountID, ok := hq.songForCaller(w, r)
+	if !ok {
+		return
+	}
+
+	err := hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		stars, errGet := qtx.GetRating(r.Context(), db.GetRatingParams{AccountID: accountID, SongID: songID})
+		if errors.Is(errGet, sql.ErrNoRows) {
+			return errNotRated
+		}
+		if errGet != nil {
+			return fmt.Errorf("failed to get rating: %w", errGet)
+		}
+
+		if _, errDel := qtx.DeleteRating(r.Context(), db.DeleteRatingParams{AccountID: accountID, SongID: songID}); errDel != nil {
+			return fmt.Errorf("failed to delete rating: %w", errDel)
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntityRating,
+			EntityID: songID,
+			Action:   audit.ActionDelete,
+			Before:   audit.Rating{AccountID: accountID, SongID: songID, Stars: stars},
+		})
+	})
+	if errors.Is(err, errNotRated) {
+		ErrReturn(err, http.StatusNotFound, w)
+		return
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to remove rating", logger.Int64("song", int64(songID)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeEmptyJSON(w, r)
+}
+
+// AddUserAccount обрабатывает POST запрос в формате JSON {"name": "alice"} и создаёт
+// учётную запись пользователя, к которой затем привязываются API ключи.
+//
+// @Summary Создаёт учётную запись пользователя.
+// @Description Создаёт учётную запись пользователя для избранного и оценок. Пользователи SSO получают учётную запись автоматически. Требуется роль admin.
+// @Tags auth
+// @Accept  json
+// @Produce json
+// @Param models.AccountParams body models.AccountParams true "Имя пользователя."
+// @Success 201 {object} models.Account "Учётная запись создана."
+// @Failure 400 {object} map[string]string "Некорректный запрос."
+// @Failure 401 {object} map[string]string "API ключ не передан или недействителен."
+// @Failure 403 {object} map[string]string "Недостаточно прав."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /users [post]
+func (hq *HandleQueries) AddUserAccount(w http.ResponseWriter, r *http.Request) {
+	var params models.AccountParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.Ctx(r.Context()).Error("failed to decode account params", logger.Err(err))
+		ErrReturn(fmt.Errorf("invalid JSON body"), http.StatusBadRequest, w)
+		return
+	}
+
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" {
+		ErrReturn(fmt.Errorf("name is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	var account db.Account
+	err := hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		var errCreate error
+		account, errCreate = qtx.CreateAccount(r.Context(), params.Name)
+		if errCreate != nil {
+			return fmt.Errorf("failed to create account: %w", errCreate)
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntityAccount,
+			EntityID: account.ID,
+			Action:   audit.ActionCreate,
+			After:    accountInfo(account),
+		})
+	})
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to add account", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resJSON, errJSON := json.Marshal(accountInfo(account))
+	if errJSON != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusCreated)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// ShowUserAccounts обрабатывает GET запрос и возвращает все учётные записи пользователей.
+//
+// @Summary Возвращает список учётных записей.
+// @Description Возвращает все учётные записи пользователей, включая созданные через SSO. Требуется роль admin.
+// @Tags auth
+// @Produce json
+// @Success 200 {array} models.Account "Список учётных записей."
+// @Failure 401 {object} map[string]string "API ключ не передан или недействителен."
+// @Failure 403 {object} map[string]string "Недостаточно прав."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /users [get]
+func (hq *HandleQueries) ShowUserAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := hq.ListAccounts(r.Context())
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to list accounts", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]models.Account, 0, len(accounts))
+	for _, a := range accounts {
+		result = append(result, accountInfo(a))
+	}
+
+	resJSON, errJSON := json.Marshal(result)
+	if errJSON != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// accountInfo преобразует запись об учётной записи в ответ.
+func accountInfo(a db.Account) models.Account {
+	return models.Account{
+		ID:        a.ID,
+		Name:      a.Name,
+		SSO:       a.Subject.Valid,
+		CreatedAt: a.CreatedAt,
+	}
+}
+
+// writeEmptyJSON отправляет успешный ответ с пустым объектом JSON.
+func writeEmptyJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := w.Write([]byte(`{}`)); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
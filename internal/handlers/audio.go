@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+var errNoAudio = errors.New("song has no audio file")
+
+// audioExtensions расширения файлов для поддерживаемых аудиоформатов.
+var audioExtensions = map[string]string{
+	"audio/mpeg": ".mp3",
+	"audio/flac": ".flac",
+	"audio/ogg":  ".ogg",
+	"audio/wav":  ".wav",
+	"audio/mp4":  ".m4a",
+	"audio/aac":  ".aac",
+}
+
+// UploadSongAudio обрабатывает PUT запрос и сохраняет аудиофайл песни по указанному ID: "?id=21".
+// Файл передаётся в теле запроса, его формат определяется по содержимому.
+// Предыдущий файл песни заменяется.
+//
+// @Summary Загружает аудиофайл песни.
+// @Description Сохраняет аудиофайл (MP3, FLAC, OGG, WAV, M4A, AAC) из тела запроса в хранилище и привязывает его к песне. Возвращает размер и контрольную сумму SHA-256.
+// @Tags audio
+// @Accept  octet-stream
+// @Produce json
+// @Param id query int true "ID песни."
+// @Param file body string true "Содержимое аудиофайла."
+// @Success 201 {object} models.AudioInfo "Файл успешно сохранён."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неверный ID песни."
+// @Failure 413 {object} map[string]string "Файл превышает допустимый размер."
+// @Failure 415 {object} map[string]string "Файл не является поддерживаемым аудиоформатом."
+// @Failure 500 {string} string "Ошибка сервера при сохранении файла."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/audio [put]
+func (hq *HandleQueries) UploadSongAudio(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	// Проверям существование песни и возвращаем ошибку, если её нет в базе данных.
+	if _, err = hq.GetOne(r.Context(), id); err != nil {
+		logger.Ctx(r.Context()).Error("ID does not exist")
+		ErrReturn(fmt.Errorf("ID does not exist"), http.StatusBadRequest, w)
+		return
+	}
+
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, hq.AudioMaxSize))
+
+	// Определяем формат по первым байтам файла.
+	head, _ := body.Peek(512)
+	contentType := detectAudioType(head)
+	ext, ok := audioExtensions[contentType]
+	if !ok {
+		logger.Ctx(r.Context()).Error("unsupported audio type", logger.String("contentType", contentType))
+		ErrReturn(fmt.Errorf("unsupported audio format"), http.StatusUnsupportedMediaType, w)
+		return
+	}
+
+	// Каждая загрузка получает уникальный ключ, поэтому читатели старого файла
+	// не видят частично заменённый файл.
+	key := fmt.Sprintf("audio/%d/%d%s", id, time.Now().UnixNano(), ext)
+	obj, err := hq.Blobs.Put(r.Context(), key, body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			logger.Ctx(r.Context()).Error("audio file is too large", logger.Err(err))
+			ErrReturn(fmt.Errorf("audio file exceeds %d bytes", hq.AudioMaxSize), http.StatusRequestEntityTooLarge, w)
+			return
+		}
+		logger.Ctx(r.Context()).Error("failed to store audio", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Заменяем запись о файле и записываем замену в журнал аудита.
+	var prev, audio db.Audio
+	hasPrev := false
+	err = hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		var errGet error
+		prev, errGet = qtx.GetAudio(r.Context(), id)
+		switch {
+		case errors.Is(errGet, sql.ErrNoRows):
+		case errGet != nil:
+			return fmt.Errorf("failed to get previous audio: %w", errGet)
+		default:
+			hasPrev = true
+		}
+
+		var errSave error
+		audio, errSave = qtx.UpsertAudio(r.Context(), db.UpsertAudioParams{
+			SongID:      id,
+			StorageKey:  obj.Key,
+			ContentType: contentType,
+			Size:        obj.Size,
+			Sha256:      obj.SHA256,
+		})
+		if errSave != nil {
+			return fmt.Errorf("failed to save audio: %w", errSave)
+		}
+
+		ev := audit.Event{Entity: audit.EntityAudio, EntityID: id, Action: audit.ActionCreate, After: audioInfo(audio)}
+		if hasPrev {
+			ev.Action, ev.Before = audit.ActionUpdate, audioInfo(prev)
+		}
+		return audit.Record(r.Context(), qtx, ev)
+	})
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to save audio", logger.Int64("song", int64(id)), logger.Err(err))
+		if errDel := hq.Blobs.Delete(r.Context(), obj.Key); errDel != nil {
+			logger.Ctx(r.Context()).Error(errDel.Error())
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Удаляем предыдущий файл песни, он больше не используется.
+	if hasPrev && prev.StorageKey != obj.Key {
+		if errDel := hq.Blobs.Delete(r.Context(), prev.StorageKey); errDel != nil {
+			logger.Ctx(r.Context()).Error(errDel.Error())
+		}
+	}
+
+	resJSON, errJSON := json.Marshal(audioInfo(audio))
+	if errJSON != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusCreated)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// StreamSongAudio обрабатывает GET и HEAD запросы и отдаёт аудиофайл песни по указанному ID: "?id=21".
+// Поддерживаются заголовки Range (ответ 206 Partial Content), If-Range и If-None-Match,
+// поэтому браузер может перематывать воспроизведение.
+//
+// @Summary Отдаёт аудиофайл песни.
+// @Description Отдаёт аудиофайл песни с поддержкой Range запросов (206 Partial Content). Контрольная сумма SHA-256 передаётся в заголовках ETag и Repr-Digest.
+// @Tags audio
+// @Produce octet-stream
+// @Param id query int true "ID песни."
+// @Param Range header string false "Диапазон байт, например: bytes=0-1023."
+// @Success 200 {file} file "Аудиофайл целиком."
+// @Success 206 {file} file "Запрошенный диапазон аудиофайла."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неверный ID песни."
+// @Failure 404 {object} map[string]string "У песни нет аудиофайла."
+// @Failure 416 {string} string "Запрошенный диапазон недопустим."
+// @Failure 500 {string} string "Ошибка сервера при чтении файла."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/audio [get]
+func (hq *HandleQueries) StreamSongAudio(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	audio, err := hq.GetAudio(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		logger.Ctx(r.Context()).Debug("song has no audio", logger.Int64("song", int64(id)))
+		ErrReturn(errNoAudio, http.StatusNotFound, w)
+		return
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to get audio", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	file, err := hq.Blobs.Open(r.Context(), audio.StorageKey)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to open audio", logger.String("key", audio.StorageKey), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", audio.ContentType)
+	w.Header().Set("ETag", `"`+audio.Sha256+`"`)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if digest, errHex := hex.DecodeString(audio.Sha256); errHex == nil {
+		// Контрольная сумма всего файла, а не переданного диапазона (RFC 9530).
+		w.Header().Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(digest)+":")
+	}
+
+	// ServeContent обрабатывает Range, If-Range, If-None-Match и HEAD запросы.
+	http.ServeContent(w, r, "", audio.UploadedAt, file)
+}
+
+// DeleteSongAudio обрабатывает DELETE запрос и удаляет аудиофайл песни по указанному ID: "?id=21".
+//
+// @Summary Удаляет аудиофайл песни.
+// @Description Удаляет аудиофайл песни из хранилища, сама песня остаётся в библиотеке.
+// @Tags audio
+// @Produce json
+// @Param id query int true "ID песни."
+// @Success 200 {object} map[string]interface{} "{}" "Аудиофайл успешно удалён."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неверный ID песни."
+// @Failure 404 {object} map[string]string "У песни нет аудиофайла."
+// @Failure 500 {string} string "Ошибка сервера при удалении файла."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/audio [delete]
+func (hq *HandleQueries) DeleteSongAudio(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	var audio db.Audio
+	err = hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		var errGet error
+		audio, errGet = qtx.GetAudio(r.Context(), id)
+		if errors.Is(errGet, sql.ErrNoRows) {
+			return errNoAudio
+		}
+		if errGet != nil {
+			return fmt.Errorf("failed to get audio: %w", errGet)
+		}
+
+		if errDel := qtx.DeleteAudio(r.Context(), id); errDel != nil {
+			return fmt.Errorf("failed to delete audio: %w", errDel)
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntityAudio,
+			EntityID: id,
+			Action:   audit.ActionDelete,
+			Before:   audioInfo(audio),
+		})
+	})
+	if errors.Is(err, errNoAudio) {
+		ErrReturn(err, http.StatusNotFound, w)
+		return
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to delete audio", logger.Int64("song", int64(id)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if err = hq.Blobs.Delete(r.Context(), audio.StorageKey); err != nil {
+		logger.Ctx(r.Context()).Error("failed to delete audio file", logger.String("key", audio.StorageKey), logger.Err(err))
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write([]byte(`{}`)); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// detectAudioType определяет MIME тип аудиофайла по его первым байтам.
+func detectAudioType(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return "audio/flac"
+	case bytes.HasPrefix(head, []byte("ID3")),
+		len(head) > 1 && head[0] == 0xFF && head[1]&0xE0 == 0xE0 && head[1]&0x06 != 0:
+		// Тег ID3v2 либо сразу заголовок MPEG кадра (не ADTS).
+		return "audio/mpeg"
+	case len(head) > 1 && head[0] == 0xFF && head[1]&0xF6 == 0xF0:
+		return "audio/aac"
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return "audio/ogg"
+	case len(head) >= 12 && bytes.Equal(head[:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WAVE")):
+		return "audio/wav"
+	case len(head) >= 12 && bytes.Equal(head[4:8], []byte("ftyp")) && bytes.HasPrefix(head[8:], []byte("M4A")):
+		return "audio/mp4"
+	}
+
+	contentType, _, _ := strings.Cut(http.DetectContentType(head), ";")
+	return contentType
+}
+
+func audioInfo(a db.Audio) models.AudioInfo {
+	return models.AudioInfo{
+		SongID:      a.SongID,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		SHA256:      a.Sha256,
+		UploadedAt:  a.UploadedAt,
+	}
+}
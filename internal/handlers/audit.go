@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+// auditUntil верхняя граница времени, если параметр "to" не указан.
+var auditUntil = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+// ShowAuditLog обрабатывает GET запрос и выводит журнал аудита, начиная с последних
+// изменений. Все фильтры необязательны.
+// Формат запроса: "?entity=song&entityId=21&action=update&actor=key:ci&requestId=abc&from=2024-05-01T00:00:00Z&to=2024-06-01T00:00:00Z&limit=20&offset=0".
+//
+// @Summary Выводит журнал аудита.
+// @Description Выводит изменения песен, их тегов, аудиофайлов и обложек, API ключей и учётных записей: исполнителя, время, ID запроса и состояние до и после изменения. Требуется роль admin.
+// @Tags auth
+// @Produce json
+// @Param entity query string false "Сущность: song, artist, song_tags, song_audio, song_cover, api_key, account, favorite, rating или playlist."
+// @Param entityId query int false "ID сущности, для song_* - ID песни."
+// @Param action query string false "Действие: create, update, delete, restore или purge."
+// @Param actor query string false "Исполнитель: user:<sub>, key:<название ключа>, cli:<команда> или system."
+// @Param requestId query string false "ID запроса из заголовка X-Request-ID."
+// @Param from query string false "Начало периода включительно, RFC 3339."
+// @Param to query string false "Конец периода не включительно, RFC 3339."
+// @Param limit query int false "Размер страницы, не больше 100. Значение по умолчанию: PAGINATION_LIMIT."
+// @Param offset query int false "Смещение. Значение по умолчанию: 0."
+// @Success 200 {array} models.AuditEvent "Записи журнала."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неверный формат времени."
+// @Failure 401 {object} map[string]string "API ключ не передан или недействителен."
+// @Failure 403 {object} map[string]string "Недостаточно прав."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /audit [get]
+func (hq *HandleQueries) ShowAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	params := db.ListAuditEventsParams{
+		Column1:     query.Get("entity"),
+		Column3:     query.Get("action"),
+		Column4:     query.Get("actor"),
+		Column5:     query.Get("requestId"),
+		CreatedAt_2: auditUntil,
+		Limit:       hq.statsLimit(r),
+	}
+
+	if v := query.Get("entityId"); v != "" {
+		id, err := services.StringToInt32WithOverflowCheck(v)
+		if err != nil || id < 1 {
+			ErrReturn(fmt.Errorf("entityId must be a positive number"), http.StatusBadRequest, w)
+			return
+		}
+		params.Column2 = id
+	}
+
+	for name, dst := range map[string]*time.Time{"from": &params.CreatedAt, "to": &params.CreatedAt_2} {
+		v := query.Get(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			ErrReturn(fmt.Errorf("incorrect %s, expected RFC 3339 time like 2024-05-01T00:00:00Z", name), http.StatusBadRequest, w)
+			return
+		}
+		*dst = t
+	}
+
+	offset, errOffset := services.StringToInt32WithOverflowCheck(query.Get("offset"))
+	if errOffset == nil && offset > 0 {
+		params.Offset = offset
+	}
+
+	rows, err := hq.ListAuditEvents(r.Context(), params)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to list audit events", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	events := make([]models.AuditEvent, len(rows))
+	for i, row := range rows {
+		events[i] = auditEvent(row)
+	}
+	writeJSON(w, r, events)
+}
+
+// auditEvent преобразует запись журнала аудита в ответ.
+func auditEvent(row db.ListAuditEventsRow) models.AuditEvent {
+	return models.AuditEvent{
+		ID:        row.ID,
+		Time:      row.CreatedAt,
+		Actor:     row.Actor,
+		Role:      row.Role,
+		APIKeyID:  row.ApiKeyID.Int32,
+		AccountID: row.AccountID.Int32,
+		RequestID: row.RequestID,
+		Entity:    row.Entity,
+		EntityID:  row.EntityID,
+		Action:    row.Action,
+		Before:    json.RawMessage(row.Before),
+		After:     json.RawMessage(row.After),
+	}
+}
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+var (
+	errKeyNotFound = errors.New("API key does not exist or is already revoked")
+	errKeyLookup   = errors.New("failed to find API key")
+)
+
+// RequireRole (middleware) пропускает только запросы с действующим API ключом или JWT,
+// роль которых не ниже role. Без ключа или с недействительным ключом возвращается 401,
+// при недостаточных правах - 403, при ошибке проверки ключа в базе данных - 500.
+func (hq *HandleQueries) RequireRole(role auth.Role) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := auth.KeyFromRequest(r)
+			if key == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="music-library"`)
+				ErrReturn(fmt.Errorf("API key or bearer token is required"), http.StatusUnauthorized, w)
+				return
+			}
+
+			principal, err := hq.authenticate(r, key)
+			if errors.Is(err, errKeyLookup) {
+				logger.Ctx(r.Context()).Error("failed to look up API key", logger.Err(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if err != nil {
+				logger.Ctx(r.Context()).Warn(err.Error())
+				w.Header().Set("WWW-Authenticate", `Bearer realm="music-library", error="invalid_token"`)
+				ErrReturn(fmt.Errorf("invalid, expired or revoked credentials"), http.StatusUnauthorized, w)
+				return
+			}
+
+			if !principal.Role.Allows(role) {
+				logger.Ctx(r.Context()).Info("role required", logger.String("principal", principal.String()), logger.String("role", string(role)))
+				ErrReturn(fmt.Errorf("%s role is required", role), http.StatusForbidden, w)
+				return
+			}
+
+			h.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// authenticate находит владельца ключа: пользователя SSO по JWT, ключ администратора
+// из конфигурации либо действующий ключ из базы данных.
+func (hq *HandleQueries) authenticate(r *http.Request, key string) (auth.Principal, error) {
+	if hq.JWT != nil && auth.LooksLikeJWT(key) {
+		return hq.JWT.Validate(r.Context(), key)
+	}
+
+	if hq.AdminAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(hq.AdminAPIKey)) == 1 {
+		return auth.Principal{Name: "config", Role: auth.RoleAdmin}, nil
+	}
+
+	apiKey, err := hq.GetActiveAPIKeyByHash(r.Context(), auth.HashKey(key))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return auth.Principal{}, errKeyNotFound
+	case err != nil:
+		return auth.Principal{}, fmt.Errorf("%w: %w", errKeyLookup, err)
+	}
+
+	return auth.Principal{
+		KeyID:     apiKey.ID,
+		AccountID: apiKey.AccountID.Int32,
+		Name:      apiKey.Name,
+		Role:      auth.Role(apiKey.Role),
+	}, nil
+}
+
+// AddAPIKey обрабатывает POST запрос в формате JSON {"name": "mobile app", "role": "reader"}
+// и создаёт новый API ключ. Ключ можно привязать к учётной записи пользователя полем
+// "accountId". В базе данных хранится только SHA-256 ключа, поэтому сам ключ возвращается
+// в ответе один раз.
+//
+// @Summary Создаёт API ключ.
+// @Description Создаёт API ключ с ролью reader, editor или admin. Ключ возвращается только в этом ответе. Требуется роль admin.
+// @Tags auth
+// @Accept  json
+// @Produce json
+// @Param models.APIKeyParams body models.APIKeyParams true "Название и роль ключа."
+// @Success 201 {object} models.CreatedAPIKey "Ключ успешно создан."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неизвестная роль."
+// @Failure 401 {object} map[string]string "API ключ не передан или недействителен."
+// @Failure 403 {object} map[string]string "Недостаточно прав."
+// @Failure 500 {string} string "Ошибка сервера при создании ключа."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /auth/keys [post]
+func (hq *HandleQueries) AddAPIKey(w http.ResponseWriter, r *http.Request) {
+	var params models.APIKeyParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.Ctx(r.Context()).Error("failed to decode API key params", logger.Err(err))
+		ErrReturn(fmt.Errorf("invalid JSON body"), http.StatusBadRequest, w)
+		return
+	}
+
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" {
+		ErrReturn(fmt.Errorf("name is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	role, err := auth.ParseRole(params.Role)
+	if err != nil {
+		ErrReturn(err, http.StatusBadRequest, w)
+		return
+	}
+
+	// Проверяем существование учётной записи, к которой привязывается ключ.
+	if params.AccountID != 0 {
+		if _, err = hq.GetAccount(r.Context(), params.AccountID); err != nil {
+			logger.Ctx(r.Context()).Error("failed to find account", logger.Err(err))
+			ErrReturn(fmt.Errorf("account does not exist"), http.StatusBadRequest, w)
+			return
+		}
+	}
+
+	key, prefix, err := auth.GenerateKey()
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to generate API key", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var apiKey db.ApiKey
+	err = hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		var errCreate error
+		apiKey, errCreate = qtx.CreateAPIKey(r.Context(), db.CreateAPIKeyParams{
+			Name:    params.Name,
+			Role:    string(role),
+			Prefix:  prefix,
+			KeyHash: auth.HashKey(key),
+			AccountID: sql.NullInt32{
+				Int32: params.AccountID,
+				Valid: params.AccountID != 0,
+			},
+		})
+		if errCreate != nil {
+			return fmt.Errorf("failed to create API key: %w", errCreate)
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntityAPIKey,
+			EntityID: apiKey.ID,
+			Action:   audit.ActionCreate,
+			After:    apiKeyInfo(apiKey),
+		})
+	})
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to add API key", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	creator, _ := auth.PrincipalFromContext(r.Context())
+	logger.Ctx(r.Context()).Info("API key created", logger.String("name", apiKey.Name), logger.String("prefix", apiKey.Prefix),
+		logger.String("role", apiKey.Role), logger.String("by", creator.String()))
+
+	resJSON, errJSON := json.Marshal(models.CreatedAPIKey{APIKey: apiKeyInfo(apiKey), Key: key})
+	if errJSON != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	w.WriteHeader(http.StatusCreated)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// ShowAPIKeys обрабатывает GET запрос и возвращает все API ключи, включая отозванные.
+// Сами ключи и их хеши не возвращаются.
+//
+// @Summary Возвращает список API ключей.
+// @Description Возвращает сведения о всех API ключах, включая отозванные. Требуется роль admin.
+// @Tags auth
+// @Produce json
+// @Success 200 {array} models.APIKey "Список ключей."
+// @Failure 401 {object} map[string]string "API ключ не передан или недействителен."
+// @Failure 403 {object} map[string]string "Недостаточно прав."
+// @Failure 500 {string} string "Ошибка сервера при получении списка ключей."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /auth/keys [get]
+func (hq *HandleQueries) ShowAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := hq.ListAPIKeys(r.Context())
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to list API keys", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]models.APIKey, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, apiKeyInfo(k))
+	}
+
+	resJSON, errJSON := json.Marshal(result)
+	if errJSON != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// DeleteAPIKey обрабатывает DELETE запрос и отзывает API ключ по указанному ID: "?id=3".
+// Запись о ключе сохраняется, но ключ перестаёт приниматься.
+//
+// @Summary Отзывает API ключ.
+// @Description Отзывает API ключ по ID, после чего ключ перестаёт приниматься. Требуется роль admin.
+// @Tags auth
+// @Produce json
+// @Param id query int32 true "ID ключа."
+// @Success 200 {object} map[string]interface{} "{}" "Ключ успешно отозван."
+// @Failure 400 {object} map[string]string "Некорректный ID ключа."
+// @Failure 401 {object} map[string]string "API ключ не передан или недействителен."
+// @Failure 403 {object} map[string]string "Недостаточно прав."
+// @Failure 404 {object} map[string]string "Ключ не найден или уже отозван."
+// @Failure 500 {string} string "Ошибка сервера при отзыве ключа."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /auth/keys [delete]
+func (hq *HandleQueries) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	err = hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		before, errGet := qtx.GetAPIKey(r.Context(), id)
+		if errors.Is(errGet, sql.ErrNoRows) {
+			return errKeyNotFound
+		}
+		if errGet != nil {
+			return fmt.Errorf("failed to get API key: %w", errGet)
+		}
+
+		affected, errRevoke := qtx.RevokeAPIKey(r.Context(), id)
+		if errRevoke != nil {
+			return fmt.Errorf("failed to revoke API key: %w", errRevoke)
+		}
+		if affected == 0 {
+			return errKeyNotFound
+		}
+
+		after, errGet := qtx.GetAPIKey(r.Context(), id)
+		if errGet != nil {
+			return fmt.Errorf("failed to get revoked API key: %w", errGet)
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntityAPIKey,
+			EntityID: id,
+			Action:   audit.ActionDelete,
+			Before:   apiKeyInfo(before),
+			After:    apiKeyInfo(after),
+		})
+	})
+	if errors.Is(err, errKeyNotFound) {
+		ErrReturn(err, http.StatusNotFound, w)
+		return
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to revoke API key", logger.Int64("key", int64(id)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	revoker, _ := auth.PrincipalFromContext(r.Context())
+	logger.Ctx(r.Context()).Info("API key revoked", logger.Int64("key", int64(id)), logger.String("by", revoker.String()))
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write([]byte(`{}`)); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// apiKeyInfo преобразует запись о ключе в ответ без хеша ключа.
+func apiKeyInfo(k db.ApiKey) models.APIKey {
+	info := models.APIKey{
+		ID:        k.ID,
+		Name:      k.Name,
+		Role:      k.Role,
+		Prefix:    k.Prefix,
+		AccountID: k.AccountID.Int32,
+		CreatedAt: k.CreatedAt,
+	}
+	if k.RevokedAt.Valid {
+		info.RevokedAt = &k.RevokedAt.Time
+	}
+	return info
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/cover"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+// UploadSongCover обрабатывает PUT запрос и сохраняет обложку песни по указанному ID: "?id=21".
+// Изображение JPEG или PNG передаётся в теле запроса, проверяются его размер в байтах
+// и в пикселях, после чего создаются уменьшенные копии. Предыдущая обложка заменяется.
+//
+// @Summary Загружает обложку песни.
+// @Description Сохраняет обложку (JPEG или PNG) из тела запроса и генерирует уменьшенные копии размеров из COVER_THUMBNAIL_SIZES. Возвращает адреса обложки и копий.
+// @Tags cover
+// @Accept  jpeg,png
+// @Produce json
+// @Param id query int true "ID песни."
+// @Param image body string true "Содержимое изображения."
+// @Success 201 {object} models.CoverURLs "Обложка успешно сохранена."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неверный ID песни."
+// @Failure 413 {object} map[string]string "Изображение превышает допустимый размер."
+// @Failure 415 {object} map[string]string "Изображение не в формате JPEG или PNG."
+// @Failure 422 {object} map[string]string "Недопустимые размеры изображения."
+// @Failure 500 {string} string "Ошибка сервера при сохранении обложки."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/cover [put]
+func (hq *HandleQueries) UploadSongCover(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	// Проверям существование песни и возвращаем ошибку, если её нет в базе данных.
+	if _, err = hq.GetOne(r.Context(), id); err != nil {
+		logger.Ctx(r.Context()).Error("ID does not exist")
+		ErrReturn(fmt.Errorf("ID does not exist"), http.StatusBadRequest, w)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, hq.CoverMaxSize))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			logger.Ctx(r.Context()).Error("cover is too large", logger.Err(err))
+			ErrReturn(fmt.Errorf("cover exceeds %d bytes", hq.CoverMaxSize), http.StatusRequestEntityTooLarge, w)
+			return
+		}
+		logger.Ctx(r.Context()).Error("failed to read cover", logger.Err(err))
+		ErrReturn(fmt.Errorf("failed to read request body"), http.StatusBadRequest, w)
+		return
+	}
+
+	images, err := cover.Process(data, cover.Options{
+		MinDimension:   hq.CoverMinDimension,
+		MaxDimension:   hq.CoverMaxDimension,
+		ThumbnailSizes: hq.CoverThumbnailSizes,
+	})
+	switch {
+	case errors.Is(err, cover.ErrUnsupportedFormat):
+		logger.Ctx(r.Context()).Error("unsupported cover format", logger.Err(err))
+		ErrReturn(err, http.StatusUnsupportedMediaType, w)
+		return
+	case errors.Is(err, cover.ErrInvalidDimensions):
+		logger.Ctx(r.Context()).Error("invalid cover dimensions", logger.Err(err))
+		ErrReturn(err, http.StatusUnprocessableEntity, w)
+		return
+	case err != nil:
+		logger.Ctx(r.Context()).Error("failed to process cover", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	covers, err := hq.storeCovers(r.Context(), id, images)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to store covers", logger.Int64("song", int64(id)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resJSON, errJSON := json.Marshal(coverURLs(covers))
+	if errJSON != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusCreated)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// storeCovers сохраняет изображения в хранилище и в одной транзакции заменяет ими
+// предыдущую обложку песни. Файлы предыдущей обложки удаляются после завершения транзакции.
+func (hq *HandleQueries) storeCovers(ctx context.Context, songID int32, images []cover.Image) ([]db.Cover, error) {
+	version := time.Now().UnixNano()
+
+	var covers []db.Cover
+	removeNew := func() {
+		for _, c := range covers {
+			if errDel := hq.Blobs.Delete(ctx, c.StorageKey); errDel != nil {
+				logger.Ctx(ctx).Error(errDel.Error())
+			}
+		}
+	}
+
+	for _, img := range images {
+		name := strconv.Itoa(img.Size) + ".jpg"
+		switch {
+		case img.Size == 0 && img.ContentType == "image/png":
+			name = "original.png"
+		case img.Size == 0:
+			name = "original.jpg"
+		}
+
+		obj, err := hq.Blobs.Put(ctx, fmt.Sprintf("covers/%d/%d/%s", songID, version, name), bytes.NewReader(img.Data))
+		if err != nil {
+			removeNew()
+			return nil, fmt.Errorf("failed to store cover: %w", err)
+		}
+		covers = append(covers, db.Cover{
+			SongID:      songID,
+			Size:        int32(img.Size),
+			StorageKey:  obj.Key,
+			ContentType: img.ContentType,
+			Width:       int32(img.Width),
+			Height:      int32(img.Height),
+			Sha256:      obj.SHA256,
+		})
+	}
+
+	old, err := hq.replaceCovers(ctx, songID, covers)
+	if err != nil {
+		removeNew()
+		return nil, err
+	}
+
+	for _, c := range old {
+		if errDel := hq.Blobs.Delete(ctx, c.StorageKey); errDel != nil {
+			logger.Ctx(ctx).Error(errDel.Error())
+		}
+	}
+
+	return covers, nil
+}
+
+// replaceCovers в транзакции заменяет записи об обложке песни, записывает замену в журнал
+// аудита и возвращает предыдущие записи.
+func (hq *HandleQueries) replaceCovers(ctx context.Context, songID int32, covers []db.Cover) ([]db.Cover, error) {
+	tx, err := hq.beginTx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if errRb := tx.Rollback(); errRb != nil && !errors.Is(errRb, sql.ErrTxDone) {
+			logger.Ctx(ctx).Error("error rolling back transaction", logger.Err(errRb))
+		}
+	}()
+	qtx := db.New(tx)
+
+	old, err := qtx.ListCovers(ctx, songID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get previous cover: %w", err)
+	}
+
+	if err = qtx.DeleteCovers(ctx, songID); err != nil {
+		return nil, fmt.Errorf("failed to delete previous cover: %w", err)
+	}
+
+	for _, c := range covers {
+		if err = qtx.AddCover(ctx, db.AddCoverParams{
+			SongID:      c.SongID,
+			Size:        c.Size,
+			StorageKey:  c.StorageKey,
+			ContentType: c.ContentType,
+			Width:       c.Width,
+			Height:      c.Height,
+			Sha256:      c.Sha256,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to save cover: %w", err)
+		}
+	}
+
+	if len(old) > 0 || len(covers) > 0 {
+		ev := audit.Event{Entity: audit.EntityCover, EntityID: songID, Action: audit.ActionUpdate}
+		switch {
+		case len(old) == 0:
+			ev.Action = audit.ActionCreate
+		case len(covers) == 0:
+			ev.Action = audit.ActionDelete
+		}
+		if len(old) > 0 {
+			ev.Before = coverStates(old)
+		}
+		if len(covers) > 0 {
+			ev.After = coverStates(covers)
+		}
+		if err = audit.Record(ctx, qtx, ev); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
+	return old, nil
+}
+
+// GetSongCover обрабатывает GET и HEAD запросы и отдаёт обложку песни или её уменьшенную копию.
+// Формат запроса: "?id=21&size=256", без size отдаётся оригинал.
+//
+// @Summary Отдаёт обложку песни.
+// @Description Отдаёт оригинал обложки или уменьшенную копию указанного размера.
+// @Tags cover
+// @Produce jpeg,png
+// @Param id query int true "ID песни."
+// @Param size query int false "Размер уменьшенной копии в пикселях. Без параметра отдаётся оригинал."
+// @Success 200 {file} file "Изображение обложки."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неверный ID песни."
+// @Failure 404 {object} map[string]string "У песни нет обложки указанного размера."
+// @Failure 500 {string} string "Ошибка сервера при чтении обложки."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/cover [get]
+func (hq *HandleQueries) GetSongCover(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	var size int32
+	if rawSize := r.URL.Query().Get("size"); rawSize != "" {
+		size, err = services.StringToInt32WithOverflowCheck(rawSize)
+		if err != nil || size < 0 {
+			ErrReturn(fmt.Errorf("invalid cover size"), http.StatusBadRequest, w)
+			return
+		}
+	}
+
+	c, err := hq.GetCover(r.Context(), db.GetCoverParams{SongID: id, Size: size})
+	if errors.Is(err, sql.ErrNoRows) {
+		ErrReturn(fmt.Errorf("song has no cover of this size"), http.StatusNotFound, w)
+		return
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to get cover", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	file, err := hq.Blobs.Open(r.Context(), c.StorageKey)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to open cover", logger.String("key", c.StorageKey), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", c.ContentType)
+	w.Header().Set("ETag", `"`+c.Sha256+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, "", c.UploadedAt, file)
+}
+
+// DeleteSongCover обрабатывает DELETE запрос и удаляет обложку песни по указанному ID: "?id=21".
+//
+// @Summary Удаляет обложку песни.
+// @Description Удаляет обложку песни и все её уменьшенные копии.
+// @Tags cover
+// @Produce json
+// @Param id query int true "ID песни."
+// @Success 200 {object} map[string]interface{} "{}" "Обложка успешно удалена."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неверный ID песни."
+// @Failure 404 {object} map[string]string "У песни нет обложки."
+// @Failure 500 {string} string "Ошибка сервера при удалении обложки."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/cover [delete]
+func (hq *HandleQueries) DeleteSongCover(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	old, err := hq.replaceCovers(r.Context(), id, nil)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to delete covers", logger.Int64("song", int64(id)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if len(old) == 0 {
+		ErrReturn(fmt.Errorf("song has no cover"), http.StatusNotFound, w)
+		return
+	}
+
+	for _, c := range old {
+		if errDel := hq.Blobs.Delete(r.Context(), c.StorageKey); errDel != nil {
+			logger.Ctx(r.Context()).Error(errDel.Error())
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write([]byte(`{}`)); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// coverState изображение обложки в журнале аудита.
+type coverState struct {
+	Size        int32  `json:"size"` // 0 для оригинала
+	ContentType string `json:"contentType"`
+	Width       int32  `json:"width"`
+	Height      int32  `json:"height"`
+	SHA256      string `json:"sha256"`
+}
+
+// coverStates возвращает описание изображений обложки для журнала аудита.
+func coverStates(covers []db.Cover) []coverState {
+	states := make([]coverState, len(covers))
+	for i, c := range covers {
+		states[i] = coverState{
+			Size:        c.Size,
+			ContentType: c.ContentType,
+			Width:       c.Width,
+			Height:      c.Height,
+			SHA256:      c.Sha256,
+		}
+	}
+	return states
+}
+
+// coverURLs формирует адреса обложки и её уменьшенных копий. Возвращает nil, если обложки нет.
+func coverURLs(covers []db.Cover) *models.CoverURLs {
+	var urls *models.CoverURLs
+	for _, c := range covers {
+		if urls == nil {
+			urls = &models.CoverURLs{}
+		}
+		url := fmt.Sprintf("/song/cover?id=%d", c.SongID)
+		if c.Size == 0 {
+			urls.Original = url
+			continue
+		}
+		if urls.Thumbnails == nil {
+			urls.Thumbnails = make(map[string]string)
+		}
+		urls.Thumbnails[strconv.Itoa(int(c.Size))] = fmt.Sprintf("%s&size=%d", url, c.Size)
+	}
+	return urls
+}
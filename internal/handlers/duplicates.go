@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/dedupe"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+)
+
+var errArtistNotFound = errors.New("group does not exist")
+
+// defaultMinScore наименьшее сходство названий, при котором они считаются возможными дубликатами.
+const defaultMinScore = 0.85
+
+// ShowDuplicates обрабатывает GET запрос и выводит пары похожих групп и пары похожих песен
+// одной группы, начиная с самых похожих. Формат запроса: "?minScore=0.85&limit=20".
+//
+// @Summary Выводит возможные дубликаты.
+// @Description Сравнивает названия групп и названия песен каждой группы без учёта регистра, лишних пробелов и знаков препинания и выводит пары, сходство которых не меньше minScore. Сходство 1 означает, что названия совпадают после нормализации.
+// @Tags library
+// @Produce json
+// @Param minScore query number false "Наименьшее сходство от 0 до 1. Значение по умолчанию: 0.85."
+// @Param limit query int false "Число пар групп и пар песен, не больше 100. Значение по умолчанию: PAGINATION_LIMIT."
+// @Success 200 {object} models.Duplicates "Возможные дубликаты."
+// @Failure 400 {object} map[string]string "Некорректный запрос."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /library/duplicates [get]
+func (hq *HandleQueries) ShowDuplicates(w http.ResponseWriter, r *http.Request) {
+	minScore := defaultMinScore
+	if v := r.URL.Query().Get("minScore"); v != "" {
+		score, err := strconv.ParseFloat(v, 64)
+		if err != nil || score <= 0 || score > 1 {
+			ErrReturn(fmt.Errorf("minScore must be a number in (0, 1]"), http.StatusBadRequest, w)
+			return
+		}
+		minScore = score
+	}
+	limit := int(hq.statsLimit(r))
+
+	artists, err := hq.ListArtistNames(r.Context())
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to list groups", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	songs, err := hq.ListSongNames(r.Context())
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to list songs", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	res := models.Duplicates{
+		Artists: []models.DuplicateArtists{},
+		Songs:   []models.DuplicateSongs{},
+	}
+
+	artistByID := make(map[int32]models.DuplicateArtist, len(artists))
+	artistItems := make([]dedupe.Item, 0, len(artists))
+	for _, a := range artists {
+		artistByID[a.ID] = models.DuplicateArtist{ID: a.ID, Group: a.Group, Songs: a.Songs}
+		artistItems = append(artistItems, dedupe.Item{ID: a.ID, Name: a.Group})
+	}
+	for _, p := range dedupe.Pairs(artistItems, minScore) {
+		if len(res.Artists) == limit {
+			break
+		}
+		res.Artists = append(res.Artists, models.DuplicateArtists{
+			Score:   p.Score,
+			Artists: []models.DuplicateArtist{artistByID[p.A], artistByID[p.B]},
+		})
+	}
+
+	// Песни сравниваются только внутри группы, одинаковые песни похожих групп
+	// объединяются при объединении групп.
+	songByID := make(map[int32]models.DuplicateSong, len(songs))
+	songItems := make([]dedupe.Item, 0, len(songs))
+	for _, s := range songs {
+		songByID[s.ID] = models.DuplicateSong{ID: s.ID, GroupID: s.GroupID, Group: s.Group, Song: s.Song}
+		songItems = append(songItems, dedupe.Item{ID: s.ID, Scope: s.GroupID, Name: s.Song})
+	}
+	for _, p := range dedupe.Pairs(songItems, minScore) {
+		if len(res.Songs) == limit {
+			break
+		}
+		res.Songs = append(res.Songs, models.DuplicateSongs{
+			Score: p.Score,
+			Songs: []models.DuplicateSong{songByID[p.A], songByID[p.B]},
+		})
+	}
+
+	writeJSON(w, r, res)
+}
+
+// MergeArtists обрабатывает POST запрос в формате JSON {"targetId": 1, "sourceIds": [2, 3]}
+// и объединяет группы sourceIds с группой targetId. Песни переносятся к targetId, а если
+// у targetId уже есть песня с тем же названием, песни объединяются. Группы sourceIds удаляются,
+// объединение записывается в журнал аудита.
+//
+// @Summary Объединяет группы.
+// @Description Переносит песни групп sourceIds к группе targetId и удаляет группы sourceIds. Песни с тем же названием, что и песни targetId, объединяются: избранное, оценки, теги, места в плейлистах и статистика переходят к песне targetId. Всё объединение выполняется в одной транзакции.
+// @Tags library
+// @Accept  json
+// @Produce json
+// @Param models.MergeParams body models.MergeParams true "Группа, которая остаётся, и её дубликаты."
+// @Success 200 {object} models.MergeResult "Группы объединены."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, группа не существует."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /library/merge/artists [post]
+func (hq *HandleQueries) MergeArtists(w http.ResponseWriter, r *http.Request) {
+	params, ok := decodeMergeParams(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	var res models.MergeResult
+	var keys []string
+	err := hq.inTx(ctx, func(qtx *db.Queries) error {
+		res, keys = models.MergeResult{TargetID: params.TargetID}, nil
+
+		target, errGet := qtx.GetArtist(ctx, params.TargetID)
+		if errors.Is(errGet, sql.ErrNoRows) {
+			return errArtistNotFound
+		}
+		if errGet != nil {
+			return fmt.Errorf("failed to get group %d: %w", params.TargetID, errGet)
+		}
+
+		for _, id := range params.SourceIDs {
+			source, errSrc := qtx.GetArtist(ctx, id)
+			if errors.Is(errSrc, sql.ErrNoRows) {
+				return errArtistNotFound
+			}
+			if errSrc != nil {
+				return fmt.Errorf("failed to get group %d: %w", id, errSrc)
+			}
+
+			songs, errSongs := qtx.ListArtistSongs(ctx, id)
+			if errSongs != nil {
+				return fmt.Errorf("failed to list songs of group %d: %w", id, errSongs)
+			}
+			for _, song := range songs {
+				// Песни в корзине переносятся без проверки, при восстановлении
+				// одинаковое название вернёт конфликт.
+				if !song.DeletedAt.Valid {
+					dupID, errDup := qtx.GetSongID(ctx, db.GetSongIDParams{GroupID: target.ID, SongKey: dedupe.Key(song.Song)})
+					if errDup == nil {
+						songKeys, errMerge := mergeSong(ctx, qtx, dupID, song)
+						if errMerge != nil {
+							return errMerge
+						}
+						keys = append(keys, songKeys...)
+						res.MergedSongs++
+						continue
+					}
+					if !errors.Is(errDup, sql.ErrNoRows) {
+						return fmt.Errorf("error checking song: %w", errDup)
+					}
+				}
+
+				if errMove := qtx.MoveSong(ctx, db.MoveSongParams{ID: song.ID, GroupID: target.ID}); errMove != nil {
+					return fmt.Errorf("failed to move song %d: %w", song.ID, errMove)
+				}
+				moved := song
+				moved.GroupID = target.ID
+				if errRec := audit.Record(ctx, qtx, audit.Event{
+					Entity:   audit.EntitySong,
+					EntityID: song.ID,
+					Action:   audit.ActionUpdate,
+					Before:   audit.SongState(song),
+					After:    audit.SongState(moved),
+				}); errRec != nil {
+					return errRec
+				}
+				res.MovedSongs++
+			}
+
+			if errDel := qtx.DeleteArtist(ctx, id); errDel != nil {
+				return fmt.Errorf("failed to delete group %d: %w", id, errDel)
+			}
+			if errRec := audit.Record(ctx, qtx, audit.Event{
+				Entity:   audit.EntityArtist,
+				EntityID: id,
+				Action:   audit.ActionMerge,
+				Before:   audit.ArtistState(source),
+				After:    audit.ArtistState(target),
+			}); errRec != nil {
+				return errRec
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errArtistNotFound) {
+		ErrReturn(err, http.StatusBadRequest, w)
+		return
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to merge artists", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	hq.afterMerge(ctx, keys)
+
+	writeJSON(w, r, res)
+}
+
+// MergeSongs обрабатывает POST запрос в формате JSON {"targetId": 21, "sourceIds": [22]}
+// и объединяет песни sourceIds с песней targetId. Песни sourceIds удаляются, объединение
+// записывается в журнал аудита.
+//
+// @Summary Объединяет песни.
+// @Description Переносит избранное, оценки, теги, места в плейлистах и статистику песен sourceIds к песне targetId и удаляет песни sourceIds. Аудиофайл и обложка переносятся, если у targetId их нет, пустые текст и ссылка targetId заполняются из sourceIds. Песни могут принадлежать разным группам.
+// @Tags library
+// @Accept  json
+// @Produce json
+// @Param models.MergeParams body models.MergeParams true "Песня, которая остаётся, и её дубликаты."
+// @Success 200 {object} models.MergeResult "Песни объединены."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, песня не существует."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /library/merge/songs [post]
+func (hq *HandleQueries) MergeSongs(w http.ResponseWriter, r *http.Request) {
+	params, ok := decodeMergeParams(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	var res models.MergeResult
+	var keys []string
+	err := hq.inTx(ctx, func(qtx *db.Queries) error {
+		res, keys = models.MergeResult{TargetID: params.TargetID}, nil
+
+		if _, errGet := qtx.GetOne(ctx, params.TargetID); errors.Is(errGet, sql.ErrNoRows) {
+			return errSongNotFound
+		} else if errGet != nil {
+			return fmt.Errorf("failed to get song %d: %w", params.TargetID, errGet)
+		}
+
+		for _, id := range params.SourceIDs {
+			source, errSrc := qtx.GetOne(ctx, id)
+			if errors.Is(errSrc, sql.ErrNoRows) {
+				return errSongNotFound
+			}
+			if errSrc != nil {
+				return fmt.Errorf("failed to get song %d: %w", id, errSrc)
+			}
+
+			songKeys, errMerge := mergeSong(ctx, qtx, params.TargetID, source)
+			if errMerge != nil {
+				return errMerge
+			}
+			keys = append(keys, songKeys...)
+			res.MergedSongs++
+		}
+		return nil
+	})
+	if errors.Is(err, errSongNotFound) {
+		ErrReturn(err, http.StatusBadRequest, w)
+		return
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to merge songs", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	hq.afterMerge(ctx, keys)
+
+	writeJSON(w, r, res)
+}
+
+// decodeMergeParams считывает и проверяет запрос на объединение, повторяющиеся ID
+// дубликатов пропускаются. При ошибке отправляет ответ и возвращает ok == false.
+func decodeMergeParams(w http.ResponseWriter, r *http.Request) (models.MergeParams, bool) {
+	var params models.MergeParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.Ctx(r.Context()).Error("failed to decode merge params", logger.Err(err))
+		ErrReturn(fmt.Errorf("invalid JSON body"), http.StatusBadRequest, w)
+		return params, false
+	}
+	if params.TargetID < 1 || len(params.SourceIDs) == 0 {
+		ErrReturn(fmt.Errorf("targetId and sourceIds are required"), http.StatusBadRequest, w)
+		return params, false
+	}
+
+	seen := make(map[int32]bool, len(params.SourceIDs))
+	sources := make([]int32, 0, len(params.SourceIDs))
+	for _, id := range params.SourceIDs {
+		if id < 1 || id == params.TargetID {
+			ErrReturn(fmt.Errorf("sourceIds must be positive and differ from targetId"), http.StatusBadRequest, w)
+			return params, false
+		}
+		if !seen[id] {
+			seen[id] = true
+			sources = append(sources, id)
+		}
+	}
+	params.SourceIDs = sources
+	return params, true
+}
+
+// mergeSong вливает песню source в песню targetID: переносит избранное, оценки, теги,
+// места в плейлистах, прослушивания и статистику, а аудиофайл и обложку - если у targetID
+// их нет. Пустые текст и ссылка targetID заполняются из source, затем source удаляется.
+// Возвращает ключи файлов source, которые нужно удалить из хранилища после транзакции.
+func mergeSong(ctx context.Context, qtx *db.Queries, targetID int32, source db.Library) ([]string, error) {
+	moves := []struct {
+		what string
+		move func(context.Context, int32, int32) error
+	}{
+		{"favorites", func(ctx context.Context, from, to int32) error {
+			return qtx.MergeFavorites(ctx, db.MergeFavoritesParams{SongID: from, Column2: to})
+		}},
+		{"ratings", func(ctx context.Context, from, to int32) error {
+			return qtx.MergeRatings(ctx, db.MergeRatingsParams{SongID: from, Column2: to})
+		}},
+		{"tags", func(ctx context.Context, from, to int32) error {
+			return qtx.MergeSongTags(ctx, db.MergeSongTagsParams{SongID: from, Column2: to})
+		}},
+		{"playlist entries", func(ctx context.Context, from, to int32) error {
+			return qtx.MovePlaylistEntries(ctx, db.MovePlaylistEntriesParams{SongID: from, Column2: to})
+		}},
+		{"plays", func(ctx context.Context, from, to int32) error {
+			return qtx.MovePlayEvents(ctx, db.MovePlayEventsParams{SongID: from, Column2: to})
+		}},
+		{"daily stats", func(ctx context.Context, from, to int32) error {
+			return qtx.MergeDailyStats(ctx, db.MergeDailyStatsParams{SongID: from, Column2: to})
+		}},
+		{"audio", func(ctx context.Context, from, to int32) error {
+			return qtx.MoveAudio(ctx, db.MoveAudioParams{SongID: from, Column2: to})
+		}},
+		{"covers", func(ctx context.Context, from, to int32) error {
+			return qtx.MoveCovers(ctx, db.MoveCoversParams{SongID: from, Column2: to})
+		}},
+		{"details", func(ctx context.Context, from, to int32) error {
+			return qtx.FillSongDetails(ctx, db.FillSongDetailsParams{ID: from, Column2: to})
+		}},
+	}
+	for _, m := range moves {
+		if err := m.move(ctx, source.ID, targetID); err != nil {
+			return nil, fmt.Errorf("failed to merge %s of song %d into %d: %w", m.what, source.ID, targetID, err)
+		}
+	}
+
+	// Файлы, которые не перенесены, удаляются вместе с песней.
+	keys, err := qtx.ListSongStorageKeys(ctx, source.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files of song %d: %w", source.ID, err)
+	}
+	if err = qtx.DeleteMergedSong(ctx, source.ID); err != nil {
+		return nil, fmt.Errorf("failed to delete song %d: %w", source.ID, err)
+	}
+
+	target, err := qtx.GetOne(ctx, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get song %d: %w", targetID, err)
+	}
+	if err = audit.Record(ctx, qtx, audit.Event{
+		Entity:   audit.EntitySong,
+		EntityID: source.ID,
+		Action:   audit.ActionMerge,
+		Before:   audit.SongState(source),
+		After:    audit.SongState(target),
+	}); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// afterMerge удаляет из хранилища файлы объединённых песен и сбрасывает индекс похожих песен.
+func (hq *HandleQueries) afterMerge(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := hq.Blobs.Delete(ctx, key); err != nil {
+			logger.Ctx(ctx).Error("failed to delete merged file", logger.String("key", key), logger.Err(err))
+		}
+	}
+	hq.related.Invalidate()
+}
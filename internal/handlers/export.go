@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/export"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+// ExportSongs обрабатывает GET запрос и выгружает всю библиотеку песен вместе с группами
+// в формате NDJSON, CSV или JSON массива. Песни читаются из базы данных порциями и сразу
+// передаются клиенту, поэтому библиотека не загружается в память целиком.
+// Поддерживаются те же фильтры, что и в ListSongsWithFilters.
+// Формат запроса: "?format=csv&gzip=true&group=Muse".
+//
+// @Summary Выгружает библиотеку песен.
+// @Description Потоково выгружает все песни вместе с группами в формате NDJSON, CSV или JSON массива с возможностью фильтрации и сжатия gzip.
+// @Tags library
+// @Produce json,plain
+// @Param format query string false "Формат выгрузки: ndjson, csv или json. Значение по умолчанию: ndjson."
+// @Param gzip query bool false "Сжимать результат gzip."
+// @Param group query string false "Имя группы для фильтрации."
+// @Param song query string false "Название композиции для фильтрации."
+// @Param releaseDate query string false "Дата релиза для фильтрации. Формат: DD.MM.YYYY."
+// @Param text query string false "Слова в тексте песни для фильтрации."
+// @Success 200 {array} models.ExportSong "Выгрузка песен в указанном формате."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неизвестный формат или неверный формат даты."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /library/export [get]
+func (hq *HandleQueries) ExportSongs(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		logger.Ctx(r.Context()).Error("invalid export format", logger.Err(err))
+		ErrReturn(err, http.StatusBadRequest, w)
+		return
+	}
+
+	compress := false
+	if gz := r.URL.Query().Get("gzip"); gz != "" {
+		if compress, err = strconv.ParseBool(gz); err != nil {
+			logger.Ctx(r.Context()).Error("invalid gzip parameter", logger.Err(err))
+			ErrReturn(fmt.Errorf("invalid gzip parameter, expected true or false"), http.StatusBadRequest, w)
+			return
+		}
+	}
+
+	filter, err := songFilterFromQuery(r)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("error parsing date", logger.Err(err))
+		ErrReturn(fmt.Errorf("incorrect date format, expected DD.MM.YYYY: %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	filename := "library." + string(format)
+	if compress {
+		filename += ".gz"
+		w.Header().Set("Content-Type", "application/gzip")
+	} else {
+		w.Header().Set("Content-Type", format.ContentType())
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	w.WriteHeader(http.StatusOK)
+
+	// После начала выгрузки статус ответа изменить уже нельзя, поэтому ошибки только логируются.
+	total, errExp := export.New(hq.Queries, export.DefaultBatchSize).Songs(r.Context(), w, format, filter, compress)
+	if errExp != nil {
+		logger.Ctx(r.Context()).Error("export interrupted", logger.Int("songs", total), logger.Err(errExp))
+		return
+	}
+
+	logger.Ctx(r.Context()).Debug("songs exported", logger.Int("songs", total), logger.String("format", string(format)))
+}
+
+// ExportPlaylist обрабатывает GET запрос и формирует плейлист в формате M3U8 или XSPF
+// из песен, подходящих под фильтры ListSongsWithFilters, или из сохранённого плейлиста
+// пользователя. В качестве адреса трека используется поле link, песни без ссылки
+// в плейлист не попадают.
+// Формат запроса: "?format=xspf&title=Muse&group=Muse", "?playlist=3" или "?token=...".
+//
+// @Summary Формирует плейлист из списка песен.
+// @Description Формирует плейлист M3U8 (с записями #EXTINF "группа - песня") или XSPF из песен, подходящих под фильтры. С параметром playlist или token выгружает сохранённый плейлист в его порядке, фильтры при этом не применяются. Личный плейлист по ID доступен только владельцу. В качестве адреса трека используется поле link.
+// @Tags library
+// @Produce plain,xml
+// @Param format query string false "Формат плейлиста: m3u8 или xspf. Значение по умолчанию: m3u8."
+// @Param playlist query int32 false "ID сохранённого плейлиста."
+// @Param token query string false "Токен из ссылки на сохранённый плейлист."
+// @Param title query string false "Название плейлиста. Для сохранённого плейлиста по умолчанию используется его название."
+// @Param group query string false "Имя группы для фильтрации."
+// @Param song query string false "Название композиции для фильтрации."
+// @Param releaseDate query string false "Дата релиза для фильтрации. Формат: DD.MM.YYYY."
+// @Param text query string false "Слова в тексте песни для фильтрации."
+// @Success 200 {string} string "Плейлист в указанном формате."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неизвестный формат или неверный формат даты."
+// @Failure 404 {object} map[string]string "Плейлист не найден."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /library/playlist [get]
+func (hq *HandleQueries) ExportPlaylist(w http.ResponseWriter, r *http.Request) {
+	format, err := export.ParsePlaylistFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		logger.Ctx(r.Context()).Error("invalid export format", logger.Err(err))
+		ErrReturn(err, http.StatusBadRequest, w)
+		return
+	}
+
+	if query := r.URL.Query(); query.Has("playlist") || query.Has("token") {
+		hq.exportSavedPlaylist(w, r, format)
+		return
+	}
+
+	filter, err := songFilterFromQuery(r)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("error parsing date", logger.Err(err))
+		ErrReturn(fmt.Errorf("incorrect date format, expected DD.MM.YYYY: %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "playlist."+string(format)))
+
+	w.WriteHeader(http.StatusOK)
+
+	// После начала выгрузки статус ответа изменить уже нельзя, поэтому ошибки только логируются.
+	total, errExp := export.New(hq.Queries, export.DefaultBatchSize).Playlist(r.Context(), w, format, r.URL.Query().Get("title"), filter)
+	if errExp != nil {
+		logger.Ctx(r.Context()).Error("playlist export interrupted", logger.Int("tracks", total), logger.Err(errExp))
+		return
+	}
+
+	logger.Ctx(r.Context()).Debug("playlist exported", logger.Int("tracks", total), logger.String("format", string(format)))
+}
+
+// exportSavedPlaylist формирует плейлист из сохранённого плейлиста по ID "?playlist=3"
+// или по токену из ссылки "?token=...". Доступ проверяется так же, как в ShowPlaylist
+// и ShowSharedPlaylist, песни выгружаются в порядке плейлиста.
+func (hq *HandleQueries) exportSavedPlaylist(w http.ResponseWriter, r *http.Request, format export.PlaylistFormat) {
+	var p db.Playlist
+	var err error
+	if token := r.URL.Query().Get("token"); token != "" {
+		p, err = hq.sharedPlaylist(r.Context(), token)
+	} else {
+		id, errID := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("playlist"))
+		if errID != nil || id < 1 {
+			logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(errID))
+			ErrReturn(fmt.Errorf("playlist ID < 1 or %w", errID), http.StatusBadRequest, w)
+			return
+		}
+		p, _, err = hq.readablePlaylist(r, id)
+	}
+	if err != nil {
+		hq.playlistError(w, r, err)
+		return
+	}
+
+	tracks, err := hq.ListPlaylistTracks(r.Context(), p.ID)
+	if err != nil {
+		hq.playlistError(w, r, fmt.Errorf("failed to list playlist tracks: %w", err))
+		return
+	}
+	songs := make([]models.ExportSong, 0, len(tracks))
+	for _, t := range tracks {
+		songs = append(songs, models.ExportSong{ID: t.ID, Group: t.Group, Song: t.Song, Link: t.Link})
+	}
+
+	title := r.URL.Query().Get("title")
+	if title == "" {
+		title = p.Name
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "playlist."+string(format)))
+
+	w.WriteHeader(http.StatusOK)
+
+	total, errExp := export.WritePlaylist(w, format, title, songs)
+	if errExp != nil {
+		logger.Ctx(r.Context()).Error("playlist export interrupted", logger.Int64("playlist", int64(p.ID)),
+			logger.Int("tracks", total), logger.Err(errExp))
+		return
+	}
+
+	logger.Ctx(r.Context()).Debug("playlist exported", logger.Int64("playlist", int64(p.ID)),
+		logger.Int("tracks", total), logger.String("format", string(format)))
+}
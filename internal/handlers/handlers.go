@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -11,24 +13,83 @@ import (
 	"fmt"
 
 	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
 	cfg "github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/database"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/dedupe"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/health"
 	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/metrics"
 	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/recommend"
 	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/songbook"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/storage"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/tracing"
+	"github.com/go-chi/chi/v5"
 )
 
 type HandleQueries struct {
 	*sql.DB
 	*db.Queries
 	cfg.Config
+
+	Songbook *songbook.Renderer // шаблоны песенника
+	Blobs    storage.BlobStore  // хранилище аудиофайлов и обложек
+	JWT      *auth.JWTValidator // проверка JWT от SSO, nil - только API ключи
+	Health   *health.Checker    // проверки для /readyz
+	Live     *cfg.Live          // настройки, которые меняются без перезапуска
+
+	related *recommend.Cache // индекс похожих песен
 }
 
-func NewHandlerQueries(connect *sql.DB, cfg cfg.Config) *HandleQueries {
-	return &HandleQueries{
-		connect,
-		db.New(connect),
-		cfg,
+func NewHandlerQueries(connect *sql.DB, conf cfg.Config) *HandleQueries {
+	hq := &HandleQueries{
+		DB:      connect,
+		Queries: db.New(tracing.WrapDB(connect)),
+		Config:  conf,
+		Live:    cfg.NewLive(conf),
+	}
+	hq.related = recommend.NewCache(conf.RecommendCacheTTL, hq.loadSongFeatures)
+	return hq
+}
+
+// settings возвращает действующие настройки с учётом перезагрузки. Перезагружаемые
+// настройки, например PAGINATION_LIMIT, нужно читать отсюда, а не из hq.Config.
+func (hq *HandleQueries) settings() cfg.Config {
+	if hq.Live == nil {
+		return hq.Config
+	}
+	return hq.Live.Load()
+}
+
+// beginTx начинает транзакцию со span трассировки. Запросы транзакции выполняются через db.New(tx).
+func (hq *HandleQueries) beginTx(ctx context.Context) (*tracing.Tx, error) {
+	return tracing.BeginTx(ctx, hq.DB, nil)
+}
+
+// inTx выполняет fn в транзакции и фиксирует её, если fn не вернула ошибку.
+// Так изменение и запись о нём в журнале аудита сохраняются вместе.
+func (hq *HandleQueries) inTx(ctx context.Context, fn func(qtx *db.Queries) error) error {
+	tx, err := hq.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if errRb := tx.Rollback(); errRb != nil && !errors.Is(errRb, sql.ErrTxDone) {
+			logger.Ctx(ctx).Error("error rolling back transaction", logger.Err(errRb))
+		}
+	}()
+
+	if err = fn(db.New(tx)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
 	}
+	return nil
 }
 
 // AddSongInLibrary добавляет песню в библиотеку. Обрабатывает POST запрос в формате
@@ -47,40 +108,59 @@ func NewHandlerQueries(connect *sql.DB, cfg cfg.Config) *HandleQueries {
 // @Success 200 {string} string "Успешное добавление песни без дополнительных данных. Возвращает сообщение с ID песни."
 // @Success 201 {object} map[string]int32 "Успешное добавление песни с полными данными. Возвращает ID добавленной песни."
 // @Failure 400 {object} map[string]string "Некорректный запрос, например, если песня уже существует в библиотеке."
+// @Failure 409 {object} map[string]string "Ту же группу одновременно добавляет другой запрос, запрос можно повторить."
 // @Failure 500 {string} string "Ошибка сервера при добавлении или обновлении песни."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /library/add [post]
 func (hq *HandleQueries) AddSongInLibrary(w http.ResponseWriter, r *http.Request) {
 	// Получаем group и song из запроса, и помещаем данные в структуру.
 	var baseParam models.AddParams
 	if err := json.NewDecoder(r.Body).Decode(&baseParam); err != nil {
-		logger.Zap.Error(err)
+		logger.Ctx(r.Context()).Error("invalid request body", logger.Err(err))
 		ErrReturn(fmt.Errorf("invalid request"), http.StatusBadRequest, w)
 		return
 	}
+	baseParam.Group, baseParam.Song = strings.TrimSpace(baseParam.Group), strings.TrimSpace(baseParam.Song)
 
 	// Начинаем выполнение транзакции.
-	tx, err := hq.Begin()
+	tx, err := hq.beginTx(r.Context())
 	if err != nil {
-		logger.Zap.Error(fmt.Errorf("error starting transaction: %w", err))
+		logger.Ctx(r.Context()).Error("error starting transaction", logger.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
-	qtx := hq.WithTx(tx)
+	defer func() {
+		if errRb := tx.Rollback(); errRb != nil && !errors.Is(errRb, sql.ErrTxDone) {
+			logger.Ctx(r.Context()).Error("error rolling back transaction", logger.Err(errRb))
+		}
+	}()
+	qtx := db.New(tx)
 
-	// Проверяем существует ли название группы в базе.
-	groupID, errGrp := qtx.GetArtistID(r.Context(), baseParam.Group)
+	// Проверяем существует ли название группы в базе. Названия сравниваются по ключам
+	// без учёта регистра и лишних пробелов, поэтому "muse " найдёт группу "Muse".
+	groupID, errGrp := qtx.GetArtistID(r.Context(), dedupe.Key(baseParam.Group))
 	if errGrp == sql.ErrNoRows {
 		// Добавляем имя группы, если не существует.
-		insert, errIns := qtx.AddArtist(r.Context(), baseParam.Group)
+		insert, errIns := qtx.AddArtist(r.Context(), db.AddArtistParams{
+			Group:    baseParam.Group,
+			GroupKey: dedupe.Key(baseParam.Group),
+		})
+		// Ту же группу одновременно добавил другой запрос, ключи групп уникальны.
+		if database.IsUniqueViolation(errIns) {
+			ErrReturn(fmt.Errorf("group is being added by another request, retry"), http.StatusConflict, w)
+			return
+		}
 		if errIns != nil {
-			logger.Zap.Error(fmt.Errorf("error adding group: %w", err))
+			logger.Ctx(r.Context()).Error("error adding group", logger.Err(errIns))
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 		groupID = insert.ID
 
 	} else if errGrp != nil {
-		logger.Zap.Error(fmt.Errorf("error checking group: %w", err))
+		logger.Ctx(r.Context()).Error("error checking group", logger.Err(errGrp))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -88,17 +168,17 @@ func (hq *HandleQueries) AddSongInLibrary(w http.ResponseWriter, r *http.Request
 	// Проверяем существование песни с указанной группой в базе.
 	songExists, errExs := qtx.CheckSongWithID(r.Context(), db.CheckSongWithIDParams{
 		GroupID: groupID,
-		Song:    baseParam.Song,
+		SongKey: dedupe.Key(baseParam.Song),
 	})
 	if errExs != nil {
-		logger.Zap.Error(fmt.Errorf("error checking song: %w", err))
+		logger.Ctx(r.Context()).Error("error checking song", logger.Err(errExs))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
 	// Если название песни с указанной группой уже существует, то возвращаем сообщение c ошибкой.
 	if songExists {
-		logger.Zap.Debug(fmt.Errorf("song already exists"))
+		logger.Ctx(r.Context()).Debug("song already exists")
 		ErrReturn(fmt.Errorf("song already exists in the library for this group"), http.StatusBadRequest, w)
 		return
 	}
@@ -107,25 +187,49 @@ func (hq *HandleQueries) AddSongInLibrary(w http.ResponseWriter, r *http.Request
 	insertedSong, errInsSong := qtx.AddSongWithID(r.Context(), db.AddSongWithIDParams{
 		GroupID: groupID,
 		Song:    baseParam.Song,
+		SongKey: dedupe.Key(baseParam.Song),
 	})
+	// Ту же песню одновременно добавил другой запрос.
+	if database.IsUniqueViolation(errInsSong) {
+		ErrReturn(fmt.Errorf("song already exists in the library for this group"), http.StatusBadRequest, w)
+		return
+	}
 	if errInsSong != nil {
-		logger.Zap.Error(fmt.Errorf("error adding song: %w", err))
+		logger.Ctx(r.Context()).Error("error adding song", logger.Err(errInsSong))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Записываем добавление песни в журнал аудита в той же транзакции.
+	if err = audit.Record(r.Context(), qtx, audit.Event{
+		Entity:   audit.EntitySong,
+		EntityID: insertedSong.ID,
+		Action:   audit.ActionCreate,
+		After:    audit.SongState(insertedSong),
+	}); err != nil {
+		logger.Ctx(r.Context()).Error("failed to record audit event", logger.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
 	// Завершаем выполнение транзакции.
 	if err = tx.Commit(); err != nil {
-		logger.Zap.Error(fmt.Errorf("error committing transaction: %w", err))
+		logger.Ctx(r.Context()).Error("error committing transaction", logger.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	defer hq.related.Invalidate()
 
 	// Делаем запрос во внешний API для получения дополнительной информации о песне.
 	// Если запрос завершился неудачей, то песня добавляется без дополнительных данных.
-	details, errDet := services.FetchSongDetails(baseParam.Group, baseParam.Song, hq.ExternalAPIURL)
+	settings := hq.settings()
+	fetchCtx, cancelFetch := context.WithTimeout(r.Context(), settings.ExternalAPITimeout)
+	defer cancelFetch()
+
+	details, errDet := services.FetchSongDetails(fetchCtx, baseParam.Group, baseParam.Song, settings.ExternalAPIURL)
 	if errDet != nil {
-		logger.Zap.Error(errDet)
+		logger.Ctx(r.Context()).Error(errDet.Error())
+		metrics.CountEnrichment(metrics.EnrichmentUnavailable)
 
 		line1 := "Unable to get additional information about the song."
 		line2 := "There is no data or the server is unavailable."
@@ -138,7 +242,7 @@ func (hq *HandleQueries) AddSongInLibrary(w http.ResponseWriter, r *http.Request
 		w.WriteHeader(http.StatusOK)
 
 		if _, err = w.Write([]byte(res)); err != nil {
-			logger.Zap.Error(fmt.Errorf("failed attempt WRITE response: %w", err))
+			logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
 			return
 		}
 		return
@@ -154,17 +258,34 @@ func (hq *HandleQueries) AddSongInLibrary(w http.ResponseWriter, r *http.Request
 	// Приводим дату к нужному формату и обновляем в FetchParams.
 	fetch.ReleaseDate, err = time.Parse("02.01.2006", details.ReleaseDate)
 	if err != nil {
-		logger.Zap.Error(fmt.Errorf("error parsing date: %w", err))
+		logger.Ctx(r.Context()).Error("error parsing date", logger.Err(err))
+		metrics.CountEnrichment(metrics.EnrichmentFailed)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	enriched := insertedSong
+	enriched.ReleaseDate, enriched.Text, enriched.Link = fetch.ReleaseDate, fetch.Text, fetch.Link
+
 	// Делаем update песни в базе данных, заполняя поля releaseDate, text, link
-	if err = hq.Fetch(r.Context(), fetch); err != nil {
-		logger.Zap.Error(fmt.Errorf("error updating song: %w", err))
+	if err = hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		if errFetch := qtx.Fetch(r.Context(), fetch); errFetch != nil {
+			return fmt.Errorf("error updating song: %w", errFetch)
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntitySong,
+			EntityID: insertedSong.ID,
+			Action:   audit.ActionUpdate,
+			Before:   audit.SongState(insertedSong),
+			After:    audit.SongState(enriched),
+		})
+	}); err != nil {
+		logger.Ctx(r.Context()).Error("failed to save song details", logger.Int64("song", int64(insertedSong.ID)), logger.Err(err))
+		metrics.CountEnrichment(metrics.EnrichmentFailed)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	metrics.CountEnrichment(metrics.EnrichmentSuccess)
 
 	result := map[string]int32{
 		"id": insertedSong.ID,
@@ -172,7 +293,7 @@ func (hq *HandleQueries) AddSongInLibrary(w http.ResponseWriter, r *http.Request
 
 	resJSON, errJSON := json.Marshal(result)
 	if errJSON != nil {
-		logger.Zap.Error(fmt.Errorf("failed attempt json-marshal response: %w", err))
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -182,15 +303,16 @@ func (hq *HandleQueries) AddSongInLibrary(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusCreated)
 
 	if _, err = w.Write(resJSON); err != nil {
-		logger.Zap.Error(fmt.Errorf("failed attempt WRITE response: %w", err))
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
 		return
 	}
 }
 
-// DeleteSong обрабатывает DELETE запрос и удаляет песню из библиотеки по указанному ID: "?id=21".
+// DeleteSong обрабатывает DELETE запрос и перемещает песню в корзину по указанному ID: "?id=21".
+// Песню можно восстановить, пока её не удалит очистка корзины через TRASH_RETENTION.
 //
 // @Summary Удаляет песню из онлайн библиотеки.
-// @Description Обрабатывает DELETE запрос и удаляет песню из библиотеки по указанному ID.
+// @Description Перемещает песню в корзину: она пропадает из всех списков, но её можно восстановить через /library/restore. Песни удаляются из корзины окончательно вместе с файлами через TRASH_RETENTION.
 // @Tags library
 // @Accept  json
 // @Produce json
@@ -198,35 +320,68 @@ func (hq *HandleQueries) AddSongInLibrary(w http.ResponseWriter, r *http.Request
 // @Success 200 {object} map[string]interface{} "{}" "Песня успешно удалена."
 // @Failure 400 {object} map[string]string "Некорректный запрос. Например, если ID песни некорректен или песня не существует."
 // @Failure 500 {string} string "Ошибка сервера при удалении песни."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /library/delete [delete]
 func (hq *HandleQueries) DeleteSong(w http.ResponseWriter, r *http.Request) {
 	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
 	if err != nil || id < 1 {
-		logger.Zap.Error(fmt.Errorf("ID < 1 or %w", err))
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
 		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
 		return
 	}
 
-	// Проверям существование песни и возвращаем ошибку, если её нет в базе данных.
-	if _, err = hq.GetOne(r.Context(), id); err != nil {
-		logger.Zap.Error("ID does not exist")
+	// Перемещаем песню в корзину, файлы песни остаются до очистки корзины.
+	err = hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		song, errGet := qtx.GetOne(r.Context(), id)
+		if errors.Is(errGet, sql.ErrNoRows) {
+			return errSongNotFound
+		}
+		if errGet != nil {
+			return fmt.Errorf("failed to get song: %w", errGet)
+		}
+
+		deleted, errDel := qtx.Delete(r.Context(), id)
+		if errDel != nil {
+			return fmt.Errorf("delete request failed: %w", errDel)
+		}
+		// Песню переместили в корзину одновременно с этим запросом.
+		if deleted == 0 {
+			return errSongNotFound
+		}
+
+		trashed, errGet := qtx.GetDeleted(r.Context(), id)
+		if errGet != nil {
+			return fmt.Errorf("failed to get deleted song: %w", errGet)
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntitySong,
+			EntityID: id,
+			Action:   audit.ActionDelete,
+			Before:   audit.SongState(song),
+			After:    audit.SongState(trashed),
+		})
+	})
+	// Песни нет в базе данных или она уже в корзине.
+	if errors.Is(err, errSongNotFound) {
+		logger.Ctx(r.Context()).Error("ID does not exist")
 		ErrReturn(fmt.Errorf("ID does not exist"), http.StatusBadRequest, w)
 		return
 	}
-
-	// Удаляем задачу из базы данных.
-	if err = hq.Delete(r.Context(), id); err != nil {
-		logger.Zap.Error("Delete request failed.")
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to delete song", logger.Int64("song", int64(id)), logger.Err(err))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	hq.related.Invalidate()
 
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
 	w.WriteHeader(http.StatusOK)
 
 	if _, err = w.Write([]byte(`{}`)); err != nil {
-		logger.Zap.Error(fmt.Errorf("failed attempt WRITE response: %w", err))
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
 		return
 	}
 }
@@ -234,9 +389,11 @@ func (hq *HandleQueries) DeleteSong(w http.ResponseWriter, r *http.Request) {
 // ListAllSongsWithFilters обрабатывает GET запрос, получает данные из базы данных и
 // выводит весь список песен из библиотеки в соответствии с фильтрами.
 // Формат запроса: "?group=Pink Floyd&releaseDate=11.11.2022&limit5&offset=0".
+// Параметр "sort=rating" сортирует песни по средней оценке, "favorites=true" оставляет
+// только избранные песни пользователя.
 //
 // @Summary Выводит весь список песен из библиотеки в соответствии с фильтрами.
-// @Description Получает данные из базы и выводит весь список песен из библиотеки с возможностью фильтрации по группе, названию песни, дате релиза и тексту. Также поддерживается пагинация.
+// @Description Получает данные из базы и выводит весь список песен из библиотеки с возможностью фильтрации по группе, названию песни, дате релиза, тексту и избранному пользователя, а также сортировки по средней оценке. Также поддерживается пагинация.
 // @Tags library
 // @Accept  json
 // @Produce json
@@ -246,20 +403,28 @@ func (hq *HandleQueries) DeleteSong(w http.ResponseWriter, r *http.Request) {
 // @Param text query string false "Слова в тексте песни для фильтрации."
 // @Param limit query int false "Лимит для создания пагинации. Значение по умолчанию: 10."
 // @Param offset query int false "Смещение для создания пагинации. Значение по умолчанию: 0."
-// @Success 200 {array} db.Library "Успешный запрос с учётом фильтрации."
+// @Param sort query string false "Сортировка: id (по умолчанию) или rating - по убыванию средней оценки."
+// @Param favorites query bool false "Только избранные песни пользователя."
+// @Success 200 {array} handlers.songResponse "Успешный запрос с учётом фильтрации."
 // @Failure 400 {object} map[string]string "Некорректный запрос, например, неверный формат даты."
+// @Failure 403 {object} map[string]string "Для favorites ключ должен быть привязан к учётной записи пользователя."
 // @Failure 500 {string} string "Ошибка сервера при обработке запроса."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /library/list [get]
 func (hq *HandleQueries) ListSongsWithFilters(w http.ResponseWriter, r *http.Request) {
-	// Чтение параметров запроса из URL.
-	group := r.URL.Query().Get("group")
-	song := r.URL.Query().Get("song")
-	releaseDate := r.URL.Query().Get("releaseDate")
-	text := r.URL.Query().Get("text")
+	// Чтение параметров фильтрации из URL.
+	filter, err := songFilterFromQuery(r)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("Error parsing date", logger.Err(err))
+		ErrReturn(fmt.Errorf("incorrect date format, expected DD.MM.YYYY: %w", err), http.StatusBadRequest, w)
+		return
+	}
 
 	limit, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("limit"))
 	if err != nil || limit <= 0 {
-		limit = hq.PaginationLimit
+		limit = hq.settings().PaginationLimit
 	}
 
 	offset, errOffset := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("offset"))
@@ -267,35 +432,54 @@ func (hq *HandleQueries) ListSongsWithFilters(w http.ResponseWriter, r *http.Req
 		offset = 0
 	}
 
-	// Если полученные параметры не пусты, то записываем их в структуру запроса к базе данных.
-	params := db.ListWithFiltersParams{
-		Column1: sql.NullString{String: group, Valid: group != ""},
-		Column2: sql.NullString{String: song, Valid: song != ""},
-		Column4: sql.NullString{String: text, Valid: text != ""},
-		Limit:   limit,
-		Offset:  offset,
+	var byRating bool
+	switch sortBy := r.URL.Query().Get("sort"); sortBy {
+	case "", "id":
+	case "rating":
+		byRating = true
+	default:
+		ErrReturn(fmt.Errorf("unsupported sort '%s', expected id or rating", sortBy), http.StatusBadRequest, w)
+		return
 	}
 
-	if releaseDate != "" {
-		params.ReleaseDate, err = time.Parse("02.01.2006", releaseDate)
+	// Для избранного определяем учётную запись пользователя.
+	var favoritesOf int32
+	if favorites, _ := strconv.ParseBool(r.URL.Query().Get("favorites")); favorites {
+		favoritesOf, err = hq.callerAccount(r)
+		if errors.Is(err, errNoAccount) {
+			ErrReturn(err, http.StatusForbidden, w)
+			return
+		}
 		if err != nil {
-			logger.Zap.Error("Error parsing date: %w", err)
-			ErrReturn(fmt.Errorf("incorrect date format, expected DD.MM.YYYY: %w", err), http.StatusBadRequest, w)
+			logger.Ctx(r.Context()).Error("failed to get caller account", logger.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 	}
 
+	// Если полученные параметры не пусты, то записываем их в структуру запроса к базе данных.
+	params := db.ListWithFiltersParams{
+		Column1:     sql.NullString{String: filter.Group, Valid: filter.Group != ""},
+		Column2:     sql.NullString{String: filter.Song, Valid: filter.Song != ""},
+		ReleaseDate: filter.ReleaseDate,
+		Column4:     sql.NullString{String: filter.Text, Valid: filter.Text != ""},
+		Limit:       limit,
+		Offset:      offset,
+		Column7:     favoritesOf,
+		Column8:     byRating,
+	}
+
 	// Делаем запрос в базу данных с учётом указанных параметров фильтра.
 	res, errUpdate := hq.ListWithFilters(r.Context(), params)
 	if errUpdate != nil || res == nil {
-		logger.Zap.Error("Request could not be processed based on the specified filters.")
+		logger.Ctx(r.Context()).Error("Request could not be processed based on the specified filters.")
 		ErrReturn(fmt.Errorf("there is no data for these filters or the request cannot be processed"), http.StatusBadRequest, w)
 		return
 	}
 
-	ans, errJSON := json.Marshal(res)
+	ans, errJSON := json.Marshal(hq.withCovers(r.Context(), res))
 	if errJSON != nil {
-		logger.Zap.Error(fmt.Errorf("failed attempt json-marshal response: %w", errJSON))
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -305,11 +489,61 @@ func (hq *HandleQueries) ListSongsWithFilters(w http.ResponseWriter, r *http.Req
 	w.WriteHeader(http.StatusOK)
 
 	if _, errWrite := w.Write(ans); errWrite != nil {
-		logger.Zap.Error("failed attempt WRITE response")
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response")
 		return
 	}
 }
 
+// songResponse песня из списка вместе с адресами обложки.
+type songResponse struct {
+	db.ListWithFiltersRow
+	Cover *models.CoverURLs `json:"cover,omitempty"`
+}
+
+// withCovers добавляет к песням адреса обложек. Если обложки получить не удалось,
+// песни возвращаются без них.
+func (hq *HandleQueries) withCovers(ctx context.Context, rows []db.ListWithFiltersRow) []songResponse {
+	ids := make([]int32, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+
+	covers, err := hq.ListCoversBySongIDs(ctx, ids)
+	if err != nil {
+		logger.Ctx(ctx).Error("failed to get covers", logger.Err(err))
+	}
+
+	bySong := make(map[int32][]db.Cover)
+	for _, c := range covers {
+		bySong[c.SongID] = append(bySong[c.SongID], c)
+	}
+
+	songs := make([]songResponse, len(rows))
+	for i, row := range rows {
+		songs[i] = songResponse{ListWithFiltersRow: row, Cover: coverURLs(bySong[row.ID])}
+	}
+	return songs
+}
+
+// songFilterFromQuery считывает из URL параметры фильтрации: group, song, releaseDate и text.
+func songFilterFromQuery(r *http.Request) (models.SongFilter, error) {
+	filter := models.SongFilter{
+		Group: r.URL.Query().Get("group"),
+		Song:  r.URL.Query().Get("song"),
+		Text:  r.URL.Query().Get("text"),
+	}
+
+	if releaseDate := r.URL.Query().Get("releaseDate"); releaseDate != "" {
+		date, err := time.Parse("02.01.2006", releaseDate)
+		if err != nil {
+			return filter, err
+		}
+		filter.ReleaseDate = date
+	}
+
+	return filter, nil
+}
+
 // TextSongWithPagination обрабатывает GET запрос и выводит текст песни по указанному ID,
 // разбитый на куплеты по страницам. Текст разделяется на куплеты по символу "\n\n".
 // Формат запроса: "?id=16&page=1".
@@ -323,18 +557,21 @@ func (hq *HandleQueries) ListSongsWithFilters(w http.ResponseWriter, r *http.Req
 // @Param page query int true "Номер страницы для пагинации."
 // @Success 200 {string} string "Успешный запрос, текст куплета."
 // @Failure 400 {object} map[string]string "Некорректный запрос (например, неверный ID или номер страницы)."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song/couplet [get]
 func (hq *HandleQueries) TextSongWithPagination(w http.ResponseWriter, r *http.Request) {
 	songID, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
 	if err != nil || songID < 1 {
-		logger.Zap.Error(fmt.Errorf("ID < 1 or %w", err))
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
 		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
 		return
 	}
 
 	page, errPage := strconv.Atoi(r.URL.Query().Get("page"))
 	if errPage != nil {
-		logger.Zap.Error("invalid string to number conversion or PAGE number")
+		logger.Ctx(r.Context()).Error("invalid string to number conversion or PAGE number")
 		ErrReturn(fmt.Errorf("invalid string to number conversion or PAGE number"), http.StatusBadRequest, w)
 		return
 	}
@@ -342,21 +579,26 @@ func (hq *HandleQueries) TextSongWithPagination(w http.ResponseWriter, r *http.R
 	// Получаем данные песни из базы данных.
 	song, errSG := hq.GetText(r.Context(), songID)
 	if errSG != nil {
-		logger.Zap.Error("Unable to retrieve song data.")
+		logger.Ctx(r.Context()).Error("Unable to retrieve song data.")
 		ErrReturn(fmt.Errorf("invalid ID number"), http.StatusBadRequest, w)
 		return
 	}
 
 	// Разбиваем текст на куплеты по символу '\n\n'.
-	couplet := strings.Split(song.Text, "\n\n")
+	couplet := services.SplitCouplets(song.Text)
 
 	// Проверяем, не выходит ли запрашиваемая страница за пределы.
 	if page > len(couplet) || page < 1 {
-		logger.Zap.Error("Page out of range")
+		logger.Ctx(r.Context()).Error("Page out of range")
 		ErrReturn(fmt.Errorf("page out of range"), http.StatusBadRequest, w)
 		return
 	}
 
+	// Учитываем просмотр куплета в статистике, ошибка записи не мешает ответу.
+	if errPlay := hq.recordPlay(r, songID, playKindCouplet); errPlay != nil {
+		logger.Ctx(r.Context()).Error("failed to record couplet view", logger.Err(errPlay))
+	}
+
 	// Конфигурируем выходной результат.
 	result := fmt.Sprintf("Group: %s, Song: %s\n\n%s", song.Group, song.Song, couplet[page-1])
 
@@ -365,7 +607,7 @@ func (hq *HandleQueries) TextSongWithPagination(w http.ResponseWriter, r *http.R
 	w.WriteHeader(http.StatusOK)
 
 	if _, err = w.Write([]byte(result)); err != nil {
-		logger.Zap.Error("failed attempt WRITE response")
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response")
 		return
 	}
 }
@@ -382,12 +624,15 @@ func (hq *HandleQueries) TextSongWithPagination(w http.ResponseWriter, r *http.R
 // @Success 200 {object} map[string]interface{} "{}"
 // @Failure 400 {object} map[string]string "Некорректный запрос (например, неверные данные или формат запроса)."
 // @Failure 500 {string} string "Ошибка сервера при обновлении песни."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /library/update [put]
 func (hq *HandleQueries) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	// Обрабатываем полученные данные из JSON и записываем в структуру.
 	var sd models.SongDetail
 	if err := json.NewDecoder(r.Body).Decode(&sd); err != nil {
-		logger.Zap.Error(err)
+		logger.Ctx(r.Context()).Error("invalid request body", logger.Err(err))
 		ErrReturn(fmt.Errorf("invalid request"), http.StatusBadRequest, w)
 		return
 	}
@@ -400,7 +645,7 @@ func (hq *HandleQueries) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	if sd.ReleaseDate != "" {
 		releaseDate, errParse = time.Parse("02.01.2006", sd.ReleaseDate)
 		if errParse != nil {
-			logger.Zap.Error("Error parsing date: %w", errParse)
+			logger.Ctx(r.Context()).Error("Error parsing date", logger.Err(errParse))
 			ErrReturn(fmt.Errorf("incorrect date format, expected DD.MM.YYYY: %w", errParse), http.StatusBadRequest, w)
 			return
 		}
@@ -409,13 +654,6 @@ func (hq *HandleQueries) UpdateSong(w http.ResponseWriter, r *http.Request) {
 		releaseDate = time.Time{} // Пустая дата для обработки в SQL
 	}
 
-	// Проверяем существование записи в базе данных
-	if _, err := hq.GetOne(r.Context(), sd.ID); err != nil {
-		logger.Zap.Error("ID does not exist")
-		ErrReturn(fmt.Errorf("ID does not exist"), http.StatusBadRequest, w)
-		return
-	}
-
 	// Подготавливаем параметры для обновления
 	upd := db.UpdateParams{
 		ID:      sd.ID,
@@ -424,61 +662,101 @@ func (hq *HandleQueries) UpdateSong(w http.ResponseWriter, r *http.Request) {
 		Column4: sd.Link,     // Если поле не нужно обновлять, передадим пустую строку
 	}
 
-	// Выполняем обновление
-	if errUpdate := hq.Update(r.Context(), upd); errUpdate != nil {
-		ErrReturn(fmt.Errorf("can't update song: %w", errUpdate), http.StatusBadRequest, w)
+	// Выполняем обновление и записываем песню до и после него в журнал аудита.
+	// Ошибка самого обновления, например слишком длинное значение, - ошибка запроса.
+	var errUpdate error
+	err := hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		// Проверяем существование записи в базе данных
+		before, err := qtx.GetOne(r.Context(), sd.ID)
+		if err != nil {
+			return errSongNotFound
+		}
+
+		if err = qtx.Update(r.Context(), upd); err != nil {
+			errUpdate = fmt.Errorf("can't update song: %w", err)
+			return errUpdate
+		}
+
+		after, err := qtx.GetOne(r.Context(), sd.ID)
+		if err != nil {
+			return fmt.Errorf("can't get updated song: %w", err)
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntitySong,
+			EntityID: sd.ID,
+			Action:   audit.ActionUpdate,
+			Before:   audit.SongState(before),
+			After:    audit.SongState(after),
+		})
+	})
+	switch {
+	case errors.Is(err, errSongNotFound):
+		logger.Ctx(r.Context()).Error("ID does not exist")
+		ErrReturn(fmt.Errorf("ID does not exist"), http.StatusBadRequest, w)
+		return
+	case errUpdate != nil:
+		ErrReturn(errUpdate, http.StatusBadRequest, w)
+		return
+	case err != nil:
+		logger.Ctx(r.Context()).Error("failed to update song", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	hq.related.Invalidate()
 
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 	w.WriteHeader(http.StatusOK)
 	if _, errWrite := w.Write([]byte(`{}`)); errWrite != nil {
-		logger.Zap.Error("failed attempt WRITE response")
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response")
 		return
 	}
 }
 
-// WithRequestDetails (middleware) добавляет дополнительный код для регистрации сведений о запросе.
-func (hq *HandleQueries) WithRequestDetails(h http.Handler) http.Handler {
+// AccessLog (middleware) записывает в журнал одну строку о каждом запросе: метод, маршрут,
+// код и размер ответа, длительность, ID запроса и владельца запроса.
+func (hq *HandleQueries) AccessLog(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		lw := &logginResponseWriter{ResponseWriter: w, status: http.StatusOK}
 
-		h.ServeHTTP(w, r)
+		ctx, caller := auth.TrackPrincipal(r.Context())
+		h.ServeHTTP(lw, r.WithContext(ctx))
 
-		logger.Zap.Info(
-			"Method:", r.Method,
-			"Duration:", time.Since(start),
-			"URI:", r.RequestURI,
-		)
-	})
-}
-
-// WithResponseDetails (middleware) добавляет дополнительный код для регистрации сведений об ответе.
-func (hq *HandleQueries) WithResponseDetails(h http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		lw := logginResponseWriter{
-			ResponseWriter: w,
-			status:         0,
-			size:           0,
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
 		}
 
-		h.ServeHTTP(&lw, r)
-
-		logger.Zap.Info(
-			"Status:", lw.status,
-			"Size:", lw.size,
+		logger.Ctx(r.Context()).Info("request",
+			logger.String("method", r.Method),
+			logger.String("route", route),
+			logger.String("path", r.URL.Path),
+			logger.Int("status", lw.status),
+			logger.Int("size", lw.size),
+			logger.Duration("duration", time.Since(start)),
+			logger.String("caller", callerName(caller)),
 		)
 	})
 }
 
+// callerName возвращает описание владельца запроса для журнала, "-" для анонимных запросов.
+func callerName(caller func() (auth.Principal, bool)) string {
+	if p, ok := caller(); ok {
+		return p.String()
+	}
+	return "-"
+}
+
 // Переопределение методов для выведения дополнительной информации о запросах и ответах.
 type logginResponseWriter struct {
 	http.ResponseWriter
-	status int
-	size   int
+	status      int
+	size        int
+	wroteHeader bool
 }
 
 func (r *logginResponseWriter) Write(b []byte) (int, error) {
+	r.wroteHeader = true
 	size, err := r.ResponseWriter.Write(b)
 	r.size += size
 	return size, err
@@ -486,5 +764,13 @@ func (r *logginResponseWriter) Write(b []byte) (int, error) {
 
 func (r *logginResponseWriter) WriteHeader(statusCode int) {
 	r.ResponseWriter.WriteHeader(statusCode)
-	r.status = statusCode
+	if !r.wroteHeader {
+		r.status = statusCode
+		r.wroteHeader = true
+	}
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter.
+func (r *logginResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
 }
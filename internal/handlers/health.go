@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/health"
+)
+
+// Liveness обрабатывает GET запрос и сообщает, что процесс сервера работает.
+// Зависимости не проверяются, чтобы сбой базы данных не приводил к перезапуску сервера.
+//
+// @Summary Проверка работы сервера.
+// @Description Возвращает 200, пока сервер обрабатывает запросы. Не требует API ключа.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report "Сервер работает."
+// @Router /healthz [get]
+func (hq *HandleQueries) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, health.Report{Status: health.StatusUp})
+}
+
+// Readiness обрабатывает GET запрос и проверяет подключение к базе данных, версию схемы
+// и доступность внешнего API. Во время остановки сервера возвращает 503.
+//
+// @Summary Проверка готовности сервера принимать запросы.
+// @Description Возвращает состояние каждого компонента: database, migrations, external_api. Недоступность внешнего API снижает состояние до degraded, но не делает сервер неготовым. Не требует API ключа.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report "Сервер готов, состояние up или degraded."
+// @Failure 503 {object} health.Report "Сервер не готов или останавливается."
+// @Router /readyz [get]
+func (hq *HandleQueries) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := hq.Health.Check(r.Context())
+
+	code := http.StatusOK
+	if report.Status == health.StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSONStatus(w, r, code, report)
+}
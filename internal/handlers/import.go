@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"fmt"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/importer"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+)
+
+// ImportSongs обрабатывает POST запрос с аудиофайлами MP3 и FLAC в формате multipart/form-data.
+// Из каждого файла читаются теги ID3v2 или Vorbis comment (исполнитель, название, год и
+// несинхронизированный текст), по ним создаются или обновляются группы и песни.
+// Файлы читаются потоково, сохраняются только теги. Формат запроса: "?dryRun=true".
+//
+// @Summary Импортирует песни по тегам аудиофайлов.
+// @Description Читает теги загруженных MP3 (ID3v2) и FLAC (Vorbis comment) файлов и создаёт или обновляет группы и песни. Возвращает отчёт с файлами, в которых отсутствуют или конфликтуют теги.
+// @Tags library
+// @Accept  mpfd
+// @Produce json
+// @Param files formData file true "Аудиофайлы MP3 или FLAC, можно несколько."
+// @Param dryRun query bool false "Только проверить теги, не изменяя библиотеку."
+// @Success 200 {object} importer.Report "Отчёт об импорте."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, не multipart/form-data."
+// @Failure 413 {object} map[string]string "Размер запроса превышает IMPORT_MAX_SIZE."
+// @Failure 500 {string} string "Ошибка сервера при формировании ответа."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /library/import [post]
+func (hq *HandleQueries) ImportSongs(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if v := r.URL.Query().Get("dryRun"); v != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			logger.Ctx(r.Context()).Error("invalid dryRun parameter", logger.Err(err))
+			ErrReturn(fmt.Errorf("invalid dryRun parameter, expected true or false"), http.StatusBadRequest, w)
+			return
+		}
+	}
+
+	// Размер всего запроса ограничен, чтобы импорт не читал тело без конца.
+	r.Body = http.MaxBytesReader(w, r.Body, hq.ImportMaxSize)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		logger.Ctx(r.Context()).Error("invalid multipart request", logger.Err(err))
+		ErrReturn(fmt.Errorf("expected multipart/form-data request with audio files"), http.StatusBadRequest, w)
+		return
+	}
+
+	var store importer.Store
+	if !dryRun {
+		store = importer.NewDBStore(hq.DB)
+	}
+	batch := importer.NewBatch(store)
+
+	for {
+		part, errPart := mr.NextPart()
+		if errors.Is(errPart, io.EOF) {
+			break
+		}
+		var maxErr *http.MaxBytesError
+		if errors.As(errPart, &maxErr) {
+			logger.Ctx(r.Context()).Warn("import request is too large", logger.Int64("limit", hq.ImportMaxSize))
+			ErrReturn(fmt.Errorf("import request exceeds %d bytes", hq.ImportMaxSize), http.StatusRequestEntityTooLarge, w)
+			return
+		}
+		if errPart != nil {
+			logger.Ctx(r.Context()).Error("failed to read multipart body", logger.Err(errPart))
+			ErrReturn(fmt.Errorf("failed to read uploaded files"), http.StatusBadRequest, w)
+			return
+		}
+
+		// Обычные поля формы пропускаем.
+		if part.FileName() == "" {
+			continue
+		}
+
+		// Формат определяется по содержимому файла, а не по расширению.
+		batch.Add(r.Context(), part.FileName(), part)
+		if errClose := part.Close(); errClose != nil {
+			logger.Ctx(r.Context()).Error("failed to close multipart part", logger.Err(errClose))
+		}
+	}
+
+	report := batch.Report()
+	if !dryRun && report.Created+report.Updated > 0 {
+		hq.related.Invalidate()
+	}
+	logger.Ctx(r.Context()).Debug("import finished", logger.Int("created", report.Created),
+		logger.Int("updated", report.Updated), logger.Int("skipped", report.Skipped))
+
+	resJSON, errJSON := json.Marshal(report)
+	if errJSON != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
@@ -0,0 +1,736 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+var (
+	errPlaylistNotFound = errors.New("playlist does not exist")
+	errPlaylistNotOwned = errors.New("playlist belongs to another user")
+	errPlaylistChanged  = errors.New("playlist was changed by another request, reload it and retry")
+	errEntryNotFound    = errors.New("playlist entry does not exist")
+	errSongNotFound     = errors.New("song does not exist")
+)
+
+// playlistErrStatus возвращает код ответа для ошибки изменения плейлиста.
+func playlistErrStatus(err error) int {
+	switch {
+	case errors.Is(err, errPlaylistNotFound), errors.Is(err, errEntryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errPlaylistNotOwned), errors.Is(err, errNoAccount):
+		return http.StatusForbidden
+	case errors.Is(err, errPlaylistChanged):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, errSongNotFound):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// playlistETag возвращает ETag версии плейлиста.
+func playlistETag(version int32) string {
+	return fmt.Sprintf(`"v%d"`, version)
+}
+
+// editPlaylist изменяет плейлист из параметра "?id=" в транзакции. Плейлист блокируется
+// до конца транзакции, поэтому одновременные изменения выполняются по очереди и позиции
+// песен не перемешиваются. Если передан заголовок "If-Match", изменение выполняется только
+// для указанной версии плейлиста. После изменения версия увеличивается, состояние плейлиста
+// до и после изменения записывается в журнал аудита, а в ответ отправляется плейлист.
+// При deleted == true плейлист удаляется функцией edit.
+func (hq *HandleQueries) editPlaylist(w http.ResponseWriter, r *http.Request, deleted bool,
+	edit func(ctx context.Context, qtx *db.Queries, p db.Playlist) error) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	accountID, err := hq.callerAccount(r)
+	if err != nil {
+		hq.playlistError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := hq.beginTx(ctx)
+	if err != nil {
+		hq.playlistError(w, r, fmt.Errorf("error starting transaction: %w", err))
+		return
+	}
+	defer func() {
+		if errRb := tx.Rollback(); errRb != nil && !errors.Is(errRb, sql.ErrTxDone) {
+			logger.Ctx(ctx).Error("error rolling back transaction", logger.Err(errRb))
+		}
+	}()
+	qtx := db.New(tx)
+
+	p, err := qtx.LockPlaylist(ctx, id)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		hq.playlistError(w, r, errPlaylistNotFound)
+		return
+	case err != nil:
+		hq.playlistError(w, r, fmt.Errorf("failed to lock playlist: %w", err))
+		return
+	case p.AccountID != accountID && p.IsPublic:
+		hq.playlistError(w, r, errPlaylistNotOwned)
+		return
+	case p.AccountID != accountID:
+		hq.playlistError(w, r, errPlaylistNotFound)
+		return
+	}
+
+	if match := r.Header.Get("If-Match"); match != "" && match != "*" && match != playlistETag(p.Version) {
+		hq.playlistError(w, r, errPlaylistChanged)
+		return
+	}
+
+	before, err := playlistState(ctx, qtx, p)
+	if err != nil {
+		hq.playlistError(w, r, err)
+		return
+	}
+
+	if err = edit(ctx, qtx, p); err != nil {
+		hq.playlistError(w, r, err)
+		return
+	}
+
+	ev := audit.Event{
+		Entity:   audit.EntityPlaylist,
+		EntityID: id,
+		Action:   audit.ActionDelete,
+		Before:   before,
+	}
+	if !deleted {
+		if err = qtx.BumpPlaylistVersion(ctx, id); err != nil {
+			hq.playlistError(w, r, fmt.Errorf("failed to update playlist version: %w", err))
+			return
+		}
+
+		cur, errGet := qtx.GetPlaylist(ctx, id)
+		if errGet != nil {
+			hq.playlistError(w, r, fmt.Errorf("failed to get playlist: %w", errGet))
+			return
+		}
+		after, errState := playlistState(ctx, qtx, cur)
+		if errState != nil {
+			hq.playlistError(w, r, errState)
+			return
+		}
+		ev.Action, ev.After = audit.ActionUpdate, after
+	}
+
+	if err = audit.Record(ctx, qtx, ev); err != nil {
+		hq.playlistError(w, r, err)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		hq.playlistError(w, r, fmt.Errorf("error committing transaction: %w", err))
+		return
+	}
+
+	if deleted {
+		writeEmptyJSON(w, r)
+		return
+	}
+
+	if p, err = hq.GetPlaylist(ctx, id); err != nil {
+		hq.playlistError(w, r, fmt.Errorf("failed to get playlist: %w", err))
+		return
+	}
+	hq.writePlaylist(w, r, p, http.StatusOK, true)
+}
+
+// playlistState возвращает состояние плейлиста p со списком песен для журнала аудита.
+func playlistState(ctx context.Context, qtx *db.Queries, p db.Playlist) (audit.Playlist, error) {
+	entries, err := qtx.ListPlaylistEntries(ctx, p.ID)
+	if err != nil {
+		return audit.Playlist{}, fmt.Errorf("failed to list playlist entries: %w", err)
+	}
+	return audit.PlaylistState(p, entries), nil
+}
+
+// playlistError отправляет ответ с ошибкой изменения плейлиста.
+func (hq *HandleQueries) playlistError(w http.ResponseWriter, r *http.Request, err error) {
+	status := playlistErrStatus(err)
+	if status == http.StatusInternalServerError {
+		logger.Ctx(r.Context()).Error("playlist request failed", logger.Err(err))
+		w.WriteHeader(status)
+		return
+	}
+	ErrReturn(err, status, w)
+}
+
+// moveEntry переносит запись плейлиста на позицию index среди видимых записей и заново
+// нумерует позиции всех записей. Index считается по тому же списку, что возвращает API:
+// записи песен из корзины в нём не видны и остаются на своих местах.
+func moveEntry(ctx context.Context, qtx *db.Queries, playlistID, entryID int32, index int) error {
+	ids, err := qtx.ListPlaylistEntryIDs(ctx, playlistID)
+	if err != nil {
+		return fmt.Errorf("failed to get playlist entries: %w", err)
+	}
+
+	entries, err := qtx.ListPlaylistEntries(ctx, playlistID)
+	if err != nil {
+		return fmt.Errorf("failed to list playlist entries: %w", err)
+	}
+	visible := make([]int32, 0, len(entries))
+	for _, e := range entries {
+		visible = append(visible, e.ID)
+	}
+
+	ordered, ok := services.MoveVisibleID(ids, visible, entryID, index)
+	if !ok {
+		return errEntryNotFound
+	}
+
+	for i, id := range ordered {
+		if err = qtx.SetPlaylistEntryPosition(ctx, db.SetPlaylistEntryPositionParams{
+			PlaylistID: playlistID,
+			ID:         id,
+			Position:   int32(i),
+		}); err != nil {
+			return fmt.Errorf("failed to update playlist entry position: %w", err)
+		}
+	}
+	return nil
+}
+
+// writePlaylist отправляет плейлист вместе с песнями и его версией в заголовке "ETag".
+// Ссылка на плейлист попадает в ответ, только если его запросил владелец: owner.
+func (hq *HandleQueries) writePlaylist(w http.ResponseWriter, r *http.Request, p db.Playlist, status int, owner bool) {
+	entries, err := hq.ListPlaylistEntries(r.Context(), p.ID)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to get playlist entries", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	resJSON, errJSON := json.Marshal(playlistInfo(p, entries, owner))
+	if errJSON != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("ETag", playlistETag(p.Version))
+
+	w.WriteHeader(status)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// playlistInfo преобразует плейлист и его записи в ответ.
+// Ссылка на плейлист добавляется только для владельца: owner.
+func playlistInfo(p db.Playlist, entries []db.ListPlaylistEntriesRow, owner bool) models.Playlist {
+	info := models.Playlist{
+		ID:        p.ID,
+		Name:      p.Name,
+		Public:    p.IsPublic,
+		Version:   p.Version,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+	if owner && p.ShareToken.Valid {
+		info.ShareURL = "/shared/playlist?token=" + url.QueryEscape(p.ShareToken.String)
+	}
+	for i, e := range entries {
+		info.Songs = append(info.Songs, models.PlaylistEntry{
+			EntryID:  e.ID,
+			Position: i,
+			SongID:   e.SongID,
+			Group:    e.Group,
+			Song:     e.Song,
+			AddedAt:  e.AddedAt,
+		})
+	}
+	return info
+}
+
+// AddPlaylist обрабатывает POST запрос в формате JSON {"name": "Road trip", "public": false}
+// и создаёт пустой плейлист пользователя.
+//
+// @Summary Создаёт плейлист.
+// @Description Создаёт пустой плейлист пользователя, от имени которого выполняется запрос. По умолчанию плейлист приватный.
+// @Tags playlist
+// @Accept  json
+// @Produce json
+// @Param models.PlaylistParams body models.PlaylistParams true "Название и видимость плейлиста."
+// @Success 201 {object} models.Playlist "Плейлист создан."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, пустое название."
+// @Failure 403 {object} map[string]string "Ключ не привязан к учётной записи пользователя."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlists [post]
+func (hq *HandleQueries) AddPlaylist(w http.ResponseWriter, r *http.Request) {
+	var params models.PlaylistParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.Ctx(r.Context()).Error("failed to decode playlist params", logger.Err(err))
+		ErrReturn(fmt.Errorf("invalid JSON body"), http.StatusBadRequest, w)
+		return
+	}
+
+	if params.Name == nil || strings.TrimSpace(*params.Name) == "" {
+		ErrReturn(fmt.Errorf("name is required"), http.StatusBadRequest, w)
+		return
+	}
+
+	accountID, err := hq.callerAccount(r)
+	if err != nil {
+		hq.playlistError(w, r, err)
+		return
+	}
+
+	var p db.Playlist
+	err = hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		var errCreate error
+		p, errCreate = qtx.CreatePlaylist(r.Context(), db.CreatePlaylistParams{
+			AccountID: accountID,
+			Name:      strings.TrimSpace(*params.Name),
+			IsPublic:  params.Public != nil && *params.Public,
+		})
+		if errCreate != nil {
+			return fmt.Errorf("failed to create playlist: %w", errCreate)
+		}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntityPlaylist,
+			EntityID: p.ID,
+			Action:   audit.ActionCreate,
+			After:    audit.PlaylistState(p, nil),
+		})
+	})
+	if err != nil {
+		hq.playlistError(w, r, err)
+		return
+	}
+
+	hq.writePlaylist(w, r, p, http.StatusCreated, true)
+}
+
+// ShowPlaylists обрабатывает GET запрос и возвращает плейлисты пользователя без песен.
+//
+// @Summary Возвращает плейлисты пользователя.
+// @Description Возвращает плейлисты пользователя, от имени которого выполняется запрос, без списка песен.
+// @Tags playlist
+// @Produce json
+// @Success 200 {array} models.Playlist "Список плейлистов."
+// @Failure 403 {object} map[string]string "Ключ не привязан к учётной записи пользователя."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlists [get]
+func (hq *HandleQueries) ShowPlaylists(w http.ResponseWriter, r *http.Request) {
+	accountID, err := hq.callerAccount(r)
+	if err != nil {
+		hq.playlistError(w, r, err)
+		return
+	}
+
+	playlists, err := hq.ListPlaylists(r.Context(), accountID)
+	if err != nil {
+		hq.playlistError(w, r, fmt.Errorf("failed to list playlists: %w", err))
+		return
+	}
+
+	result := make([]models.Playlist, 0, len(playlists))
+	for _, p := range playlists {
+		result = append(result, playlistInfo(p, nil, true))
+	}
+
+	resJSON, errJSON := json.Marshal(result)
+	if errJSON != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(errJSON))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// ShowPlaylist обрабатывает GET запрос и возвращает плейлист с песнями по указанному ID: "?id=3".
+// Приватный плейлист доступен только владельцу, публичный - любому пользователю.
+//
+// @Summary Возвращает плейлист с песнями.
+// @Description Возвращает плейлист с песнями по порядку. Приватный плейлист доступен только владельцу. Версия плейлиста передаётся в заголовке ETag.
+// @Tags playlist
+// @Produce json
+// @Param id query int32 true "ID плейлиста."
+// @Success 200 {object} models.Playlist "Плейлист."
+// @Failure 400 {object} map[string]string "Некорректный ID плейлиста."
+// @Failure 404 {object} map[string]string "Плейлист не найден или недоступен."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist [get]
+func (hq *HandleQueries) ShowPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	p, owner, err := hq.readablePlaylist(r, id)
+	if err != nil {
+		hq.playlistError(w, r, err)
+		return
+	}
+
+	hq.writePlaylist(w, r, p, http.StatusOK, owner)
+}
+
+// readablePlaylist возвращает плейлист id, если владелец запроса может его просматривать:
+// публичный плейлист доступен всем, личный только владельцу. Значение owner сообщает,
+// что запрос сделал владелец плейлиста.
+func (hq *HandleQueries) readablePlaylist(r *http.Request, id int32) (p db.Playlist, owner bool, err error) {
+	p, err = hq.GetPlaylist(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, false, errPlaylistNotFound
+	}
+	if err != nil {
+		return p, false, fmt.Errorf("failed to get playlist: %w", err)
+	}
+
+	accountID, err := hq.callerAccount(r)
+	if err != nil && !errors.Is(err, errNoAccount) {
+		return p, false, err
+	}
+	owner = accountID == p.AccountID
+	if !p.IsPublic && !owner {
+		return p, false, errPlaylistNotFound
+	}
+	return p, owner, nil
+}
+
+// ShowSharedPlaylist обрабатывает GET запрос и возвращает плейлист по ссылке: "?token=...".
+// Ссылка работает без API ключа, пока владелец не отзовёт её.
+//
+// @Summary Возвращает плейлист по ссылке.
+// @Description Возвращает плейлист с песнями по токену из ссылки. Не требует API ключа.
+// @Tags playlist
+// @Produce json
+// @Param token query string true "Токен из ссылки на плейлист."
+// @Success 200 {object} models.Playlist "Плейлист."
+// @Failure 404 {object} map[string]string "Ссылка недействительна."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Router /shared/playlist [get]
+func (hq *HandleQueries) ShowSharedPlaylist(w http.ResponseWriter, r *http.Request) {
+	p, err := hq.sharedPlaylist(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		hq.playlistError(w, r, err)
+		return
+	}
+
+	hq.writePlaylist(w, r, p, http.StatusOK, false)
+}
+
+// sharedPlaylist возвращает плейлист по токену из ссылки.
+func (hq *HandleQueries) sharedPlaylist(ctx context.Context, token string) (db.Playlist, error) {
+	if token == "" {
+		return db.Playlist{}, errPlaylistNotFound
+	}
+
+	p, err := hq.GetPlaylistByShareToken(ctx, sql.NullString{String: token, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, errPlaylistNotFound
+	}
+	if err != nil {
+		return p, fmt.Errorf("failed to get playlist: %w", err)
+	}
+	return p, nil
+}
+
+// EditPlaylist обрабатывает PATCH запрос в формате JSON {"name": "Road trip 2", "public": true}
+// и переименовывает плейлист по указанному ID: "?id=3" или меняет его видимость.
+//
+// @Summary Изменяет название или видимость плейлиста.
+// @Description Изменяет название и/или видимость плейлиста. С заголовком If-Match изменение выполняется только для указанной версии.
+// @Tags playlist
+// @Accept  json
+// @Produce json
+// @Param id query int32 true "ID плейлиста."
+// @Param If-Match header string false "Ожидаемая версия плейлиста из ETag."
+// @Param models.PlaylistParams body models.PlaylistParams true "Новые название и/или видимость."
+// @Success 200 {object} models.Playlist "Плейлист изменён."
+// @Failure 400 {object} map[string]string "Некорректный запрос."
+// @Failure 403 {object} map[string]string "Плейлист принадлежит другому пользователю."
+// @Failure 404 {object} map[string]string "Плейлист не найден."
+// @Failure 412 {object} map[string]string "Плейлист изменён другим запросом."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist [patch]
+func (hq *HandleQueries) EditPlaylist(w http.ResponseWriter, r *http.Request) {
+	var params models.PlaylistParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.Ctx(r.Context()).Error("failed to decode playlist params", logger.Err(err))
+		ErrReturn(fmt.Errorf("invalid JSON body"), http.StatusBadRequest, w)
+		return
+	}
+	if params.Name != nil && strings.TrimSpace(*params.Name) == "" {
+		ErrReturn(fmt.Errorf("name must not be empty"), http.StatusBadRequest, w)
+		return
+	}
+
+	hq.editPlaylist(w, r, false, func(ctx context.Context, qtx *db.Queries, p db.Playlist) error {
+		update := db.UpdatePlaylistParams{ID: p.ID, Name: p.Name, IsPublic: p.IsPublic}
+		if params.Name != nil {
+			update.Name = strings.TrimSpace(*params.Name)
+		}
+		if params.Public != nil {
+			update.IsPublic = *params.Public
+		}
+		if err := qtx.UpdatePlaylist(ctx, update); err != nil {
+			return fmt.Errorf("failed to update playlist: %w", err)
+		}
+		return nil
+	})
+}
+
+// RemovePlaylist обрабатывает DELETE запрос и удаляет плейлист по указанному ID: "?id=3".
+//
+// @Summary Удаляет плейлист.
+// @Description Удаляет плейлист пользователя вместе со списком песен. Песни библиотеки не удаляются.
+// @Tags playlist
+// @Produce json
+// @Param id query int32 true "ID плейлиста."
+// @Param If-Match header string false "Ожидаемая версия плейлиста из ETag."
+// @Success 200 {object} map[string]interface{} "{}" "Плейлист удалён."
+// @Failure 400 {object} map[string]string "Некорректный ID плейлиста."
+// @Failure 403 {object} map[string]string "Плейлист принадлежит другому пользователю."
+// @Failure 404 {object} map[string]string "Плейлист не найден."
+// @Failure 412 {object} map[string]string "Плейлист изменён другим запросом."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist [delete]
+func (hq *HandleQueries) RemovePlaylist(w http.ResponseWriter, r *http.Request) {
+	hq.editPlaylist(w, r, true, func(ctx context.Context, qtx *db.Queries, p db.Playlist) error {
+		if err := qtx.DeletePlaylist(ctx, p.ID); err != nil {
+			return fmt.Errorf("failed to delete playlist: %w", err)
+		}
+		return nil
+	})
+}
+
+// AddPlaylistSong обрабатывает POST запрос в формате JSON {"songId": 21, "position": 0}
+// и добавляет песню в плейлист по указанному ID: "?id=3". Без position песня добавляется в конец.
+//
+// @Summary Добавляет песню в плейлист.
+// @Description Добавляет песню в плейлист в конец или на указанную позицию (с 0). Одна песня может быть добавлена несколько раз.
+// @Tags playlist
+// @Accept  json
+// @Produce json
+// @Param id query int32 true "ID плейлиста."
+// @Param If-Match header string false "Ожидаемая версия плейлиста из ETag."
+// @Param models.PlaylistSongParams body models.PlaylistSongParams true "ID песни и позиция."
+// @Success 200 {object} models.Playlist "Песня добавлена."
+// @Failure 400 {object} map[string]string "Некорректный запрос или песня не существует."
+// @Failure 403 {object} map[string]string "Плейлист принадлежит другому пользователю."
+// @Failure 404 {object} map[string]string "Плейлист не найден."
+// @Failure 412 {object} map[string]string "Плейлист изменён другим запросом."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/songs [post]
+func (hq *HandleQueries) AddPlaylistSong(w http.ResponseWriter, r *http.Request) {
+	var params models.PlaylistSongParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.Ctx(r.Context()).Error("failed to decode playlist song params", logger.Err(err))
+		ErrReturn(fmt.Errorf("invalid JSON body"), http.StatusBadRequest, w)
+		return
+	}
+	if params.SongID < 1 {
+		ErrReturn(fmt.Errorf("songId must be positive"), http.StatusBadRequest, w)
+		return
+	}
+
+	hq.editPlaylist(w, r, false, func(ctx context.Context, qtx *db.Queries, p db.Playlist) error {
+		if _, err := qtx.GetOne(ctx, params.SongID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errSongNotFound
+			}
+			return fmt.Errorf("failed to get song: %w", err)
+		}
+
+		entry, err := qtx.AddPlaylistEntry(ctx, db.AddPlaylistEntryParams{PlaylistID: p.ID, SongID: params.SongID})
+		if err != nil {
+			return fmt.Errorf("failed to add playlist entry: %w", err)
+		}
+
+		if params.Position != nil {
+			return moveEntry(ctx, qtx, p.ID, entry.ID, *params.Position)
+		}
+		return nil
+	})
+}
+
+// MovePlaylistSong обрабатывает PATCH запрос в формате JSON {"position": 0} и переносит
+// запись плейлиста "?id=3&entry=17" на указанную позицию.
+//
+// @Summary Переносит песню в плейлисте.
+// @Description Переносит запись плейлиста на позицию (с 0), остальные песни сдвигаются. Позиция за пределами плейлиста переносит песню в начало или конец.
+// @Tags playlist
+// @Accept  json
+// @Produce json
+// @Param id query int32 true "ID плейлиста."
+// @Param entry query int32 true "ID записи в плейлисте (entryId)."
+// @Param If-Match header string false "Ожидаемая версия плейлиста из ETag."
+// @Param models.PlaylistMoveParams body models.PlaylistMoveParams true "Новая позиция."
+// @Success 200 {object} models.Playlist "Песня перенесена."
+// @Failure 400 {object} map[string]string "Некорректный запрос."
+// @Failure 403 {object} map[string]string "Плейлист принадлежит другому пользователю."
+// @Failure 404 {object} map[string]string "Плейлист или запись не найдены."
+// @Failure 412 {object} map[string]string "Плейлист изменён другим запросом."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/songs [patch]
+func (hq *HandleQueries) MovePlaylistSong(w http.ResponseWriter, r *http.Request) {
+	entryID, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("entry"))
+	if err != nil || entryID < 1 {
+		ErrReturn(fmt.Errorf("entry < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	var params models.PlaylistMoveParams
+	if err = json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.Ctx(r.Context()).Error("failed to decode playlist move params", logger.Err(err))
+		ErrReturn(fmt.Errorf("invalid JSON body"), http.StatusBadRequest, w)
+		return
+	}
+
+	hq.editPlaylist(w, r, false, func(ctx context.Context, qtx *db.Queries, p db.Playlist) error {
+		return moveEntry(ctx, qtx, p.ID, entryID, params.Position)
+	})
+}
+
+// RemovePlaylistSong обрабатывает DELETE запрос и удаляет запись "?id=3&entry=17" из плейлиста.
+//
+// @Summary Удаляет песню из плейлиста.
+// @Description Удаляет запись из плейлиста по entryId. Другие вхождения той же песни остаются.
+// @Tags playlist
+// @Produce json
+// @Param id query int32 true "ID плейлиста."
+// @Param entry query int32 true "ID записи в плейлисте (entryId)."
+// @Param If-Match header string false "Ожидаемая версия плейлиста из ETag."
+// @Success 200 {object} models.Playlist "Песня удалена из плейлиста."
+// @Failure 400 {object} map[string]string "Некорректный запрос."
+// @Failure 403 {object} map[string]string "Плейлист принадлежит другому пользователю."
+// @Failure 404 {object} map[string]string "Плейлист или запись не найдены."
+// @Failure 412 {object} map[string]string "Плейлист изменён другим запросом."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/songs [delete]
+func (hq *HandleQueries) RemovePlaylistSong(w http.ResponseWriter, r *http.Request) {
+	entryID, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("entry"))
+	if err != nil || entryID < 1 {
+		ErrReturn(fmt.Errorf("entry < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	hq.editPlaylist(w, r, false, func(ctx context.Context, qtx *db.Queries, p db.Playlist) error {
+		affected, errDel := qtx.DeletePlaylistEntry(ctx, db.DeletePlaylistEntryParams{PlaylistID: p.ID, ID: entryID})
+		if errDel != nil {
+			return fmt.Errorf("failed to delete playlist entry: %w", errDel)
+		}
+		if affected == 0 {
+			return errEntryNotFound
+		}
+		return nil
+	})
+}
+
+// SharePlaylist обрабатывает POST запрос и создаёт ссылку на плейлист "?id=3", по которой
+// он доступен без API ключа. Повторный запрос заменяет ссылку, старая перестаёт работать.
+//
+// @Summary Создаёт ссылку на плейлист.
+// @Description Создаёт новую ссылку для доступа к плейлисту без API ключа. Предыдущая ссылка перестаёт работать.
+// @Tags playlist
+// @Produce json
+// @Param id query int32 true "ID плейлиста."
+// @Success 200 {object} models.Playlist "Ссылка создана, см. поле shareUrl."
+// @Failure 400 {object} map[string]string "Некорректный ID плейлиста."
+// @Failure 403 {object} map[string]string "Плейлист принадлежит другому пользователю."
+// @Failure 404 {object} map[string]string "Плейлист не найден."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/share [post]
+func (hq *HandleQueries) SharePlaylist(w http.ResponseWriter, r *http.Request) {
+	hq.editPlaylist(w, r, false, func(ctx context.Context, qtx *db.Queries, p db.Playlist) error {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return fmt.Errorf("failed to generate share token: %w", err)
+		}
+		return qtx.SetPlaylistShareToken(ctx, db.SetPlaylistShareTokenParams{
+			ID:         p.ID,
+			ShareToken: sql.NullString{String: base64.RawURLEncoding.EncodeToString(buf), Valid: true},
+		})
+	})
+}
+
+// UnsharePlaylist обрабатывает DELETE запрос и отзывает ссылку на плейлист "?id=3".
+//
+// @Summary Отзывает ссылку на плейлист.
+// @Description Отзывает ссылку на плейлист, после чего он доступен только владельцу или, если он публичный, пользователям с API ключом.
+// @Tags playlist
+// @Produce json
+// @Param id query int32 true "ID плейлиста."
+// @Success 200 {object} models.Playlist "Ссылка отозвана."
+// @Failure 400 {object} map[string]string "Некорректный ID плейлиста."
+// @Failure 403 {object} map[string]string "Плейлист принадлежит другому пользователю."
+// @Failure 404 {object} map[string]string "Плейлист не найден."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/share [delete]
+func (hq *HandleQueries) UnsharePlaylist(w http.ResponseWriter, r *http.Request) {
+	hq.editPlaylist(w, r, false, func(ctx context.Context, qtx *db.Queries, p db.Playlist) error {
+		return qtx.SetPlaylistShareToken(ctx, db.SetPlaylistShareTokenParams{ID: p.ID})
+	})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fmt"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/ratelimit"
+)
+
+// RateLimit (middleware) ограничивает частоту запросов клиента. Клиент определяется
+// по API ключу или пользователю SSO, а для анонимных запросов - по IP адресу, поэтому
+// middleware подключается после RequireRole. Отвечает 429 с заголовком "Retry-After"
+// и добавляет к ответам заголовки "RateLimit-*". При limiter == nil или нулевом лимите
+// ограничение отключено.
+func (hq *HandleQueries) RateLimit(limiter *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return hq.rateLimit(limiter, hq.rateLimitKey)
+}
+
+// RateLimitIP (middleware) ограничивает частоту запросов с одного IP адреса. Подключается
+// перед RequireRole: так ограничиваются и запросы без ключа или с неверным ключом, каждый
+// из которых проверяется по базе данных.
+func (hq *HandleQueries) RateLimitIP(limiter *ratelimit.Limiter) func(http.Handler) http.Handler {
+	return hq.rateLimit(limiter, func(r *http.Request) string {
+		return "ip:" + hq.clientIP(r)
+	})
+}
+
+// rateLimit ограничивает частоту запросов клиентов, которых различает функция key.
+func (hq *HandleQueries) rateLimit(limiter *ratelimit.Limiter, key func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		if limiter == nil {
+			return h
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := key(r)
+			d := limiter.Allow(client)
+			if d.Limit == 0 {
+				// Ограничение отключено в настройках.
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+
+			if !d.Allowed {
+				// Отказы видны в метриках по коду ответа, в журнал они пишутся только при отладке.
+				logger.Ctx(r.Context()).Debug("rate limit exceeded", logger.String("client", client))
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(d.RetryAfter))))
+				ErrReturn(fmt.Errorf("too many requests, retry later"), http.StatusTooManyRequests, w)
+				return
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitKey возвращает ключ клиента для ограничения частоты запросов.
+func (hq *HandleQueries) rateLimitKey(r *http.Request) string {
+	if p, ok := auth.PrincipalFromContext(r.Context()); ok {
+		switch {
+		case p.KeyID != 0:
+			return fmt.Sprintf("key:%d", p.KeyID)
+		case p.Subject != "":
+			return "user:" + p.Subject
+		default:
+			return "key:" + p.Name
+		}
+	}
+	return "ip:" + hq.clientIP(r)
+}
+
+// clientIP возвращает IP адрес клиента. Заголовки "X-Forwarded-For" и "X-Real-IP"
+// учитываются, только если приложение работает за доверенным прокси. Из "X-Forwarded-For"
+// берётся последний адрес: его дописал прокси, а адреса перед ним передал сам клиент.
+func (hq *HandleQueries) clientIP(r *http.Request) string {
+	if hq.RateLimitTrustProxy {
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			hops := strings.Split(fwd[len(fwd)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
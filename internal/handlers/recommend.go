@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/recommend"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+// defaultRelatedLimit число похожих песен по умолчанию.
+const defaultRelatedLimit = 10
+
+// loadSongFeatures загружает песни библиотеки вместе с тегами для индекса похожих песен.
+func (hq *HandleQueries) loadSongFeatures(ctx context.Context) ([]recommend.Song, error) {
+	rows, err := hq.ListSongFeatures(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list songs: %w", err)
+	}
+	tags, err := hq.ListAllSongTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list song tags: %w", err)
+	}
+
+	byID := make(map[int32][]string)
+	for _, t := range tags {
+		byID[t.SongID] = append(byID[t.SongID], t.Tag)
+	}
+
+	songs := make([]recommend.Song, 0, len(rows))
+	for _, row := range rows {
+		songs = append(songs, recommend.Song{
+			ID:          row.ID,
+			GroupID:     row.GroupID,
+			Group:       row.Group,
+			Song:        row.Song,
+			ReleaseDate: row.ReleaseDate,
+			Text:        row.Text,
+			Tags:        byID[row.ID],
+		})
+	}
+	return songs, nil
+}
+
+// RelatedSongs обрабатывает GET запрос и выводит песни, похожие на песню по указанному ID:
+// "?id=21&limit=10". Сходство считается по исполнителю, тегам, времени выпуска и тексту.
+//
+// @Summary Похожие песни.
+// @Description Выводит песни, похожие на указанную, по убыванию сходства от 0 до 1. Учитываются общий исполнитель, общие теги, близкая дата выпуска и сходство текстов (TF-IDF). Индекс строится в памяти и перестраивается после изменения библиотеки.
+// @Tags library
+// @Produce json
+// @Param id query int32 true "ID песни."
+// @Param limit query int false "Размер списка, не больше 50. Значение по умолчанию: 10."
+// @Success 200 {array} models.RelatedSong "Похожие песни."
+// @Failure 400 {object} map[string]string "Некорректный ID песни или песня не существует."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/related [get]
+func (hq *HandleQueries) RelatedSongs(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	limit, errLimit := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("limit"))
+	if errLimit != nil || limit <= 0 {
+		limit = defaultRelatedLimit
+	}
+
+	index, err := hq.related.Index(r.Context())
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to build related songs index", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	matches, ok := index.Related(id, int(limit))
+	if !ok {
+		logger.Ctx(r.Context()).Error("ID does not exist")
+		ErrReturn(fmt.Errorf("ID does not exist"), http.StatusBadRequest, w)
+		return
+	}
+
+	result := make([]models.RelatedSong, 0, len(matches))
+	for _, m := range matches {
+		result = append(result, models.RelatedSong{
+			ID:         m.Song.ID,
+			Group:      m.Song.Group,
+			Song:       m.Song.Song,
+			Score:      m.Score,
+			Reasons:    m.Reasons,
+			SharedTags: m.SharedTags,
+		})
+	}
+
+	writeJSON(w, r, result)
+}
+
+// ShowSongTags обрабатывает GET запрос и выводит теги песни по указанному ID: "?id=21".
+//
+// @Summary Теги песни.
+// @Description Выводит теги песни в алфавитном порядке.
+// @Tags library
+// @Produce json
+// @Param id query int32 true "ID песни."
+// @Success 200 {object} models.SongTags "Теги песни."
+// @Failure 400 {object} map[string]string "Некорректный ID песни или песня не существует."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/tags [get]
+func (hq *HandleQueries) ShowSongTags(w http.ResponseWriter, r *http.Request) {
+	id, ok := hq.existingSong(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := nonNil(hq.ListSongTags(r.Context(), id))
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to list song tags", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, models.SongTags{Tags: tags})
+}
+
+// SetSongTags обрабатывает PUT запрос в формате JSON {"tags": ["rock", "90s"]} и заменяет
+// теги песни по указанному ID: "?id=21". Пустой список удаляет все теги.
+//
+// @Summary Заменяет теги песни.
+// @Description Заменяет теги песни. Теги приводятся к нижнему регистру, повторы удаляются. Не больше 20 тегов длиной до 40 символов.
+// @Tags library
+// @Accept  json
+// @Produce json
+// @Param id query int32 true "ID песни."
+// @Param models.SongTags body models.SongTags true "Новые теги песни."
+// @Success 200 {object} models.SongTags "Сохранённые теги песни."
+// @Failure 400 {object} map[string]string "Некорректный ID песни, песня не существует или некорректные теги."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/tags [put]
+func (hq *HandleQueries) SetSongTags(w http.ResponseWriter, r *http.Request) {
+	var params models.SongTags
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		logger.Ctx(r.Context()).Error("failed to decode tags", logger.Err(err))
+		ErrReturn(fmt.Errorf("invalid JSON body"), http.StatusBadRequest, w)
+		return
+	}
+	tags, err := services.NormalizeTags(params.Tags)
+	if err != nil {
+		ErrReturn(err, http.StatusBadRequest, w)
+		return
+	}
+
+	id, ok := hq.existingSong(w, r)
+	if !ok {
+		return
+	}
+
+	if err = hq.replaceSongTags(r.Context(), id, tags); err != nil {
+		logger.Ctx(r.Context()).Error("failed to set song tags", logger.Int64("song", int64(id)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	hq.related.Invalidate()
+
+	writeJSON(w, r, models.SongTags{Tags: tags})
+}
+
+// replaceSongTags заменяет теги песни в одной транзакции и записывает изменение в журнал аудита.
+func (hq *HandleQueries) replaceSongTags(ctx context.Context, songID int32, tags []string) error {
+	tx, err := hq.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if errRb := tx.Rollback(); errRb != nil && !errors.Is(errRb, sql.ErrTxDone) {
+			logger.Ctx(ctx).Error("error rolling back transaction", logger.Err(errRb))
+		}
+	}()
+	qtx := db.New(tx)
+
+	prev, err := qtx.ListSongTags(ctx, songID)
+	if err != nil {
+		return fmt.Errorf("failed to get song tags: %w", err)
+	}
+
+	if err = qtx.DeleteSongTags(ctx, songID); err != nil {
+		return fmt.Errorf("failed to delete song tags: %w", err)
+	}
+	for _, tag := range tags {
+		if err = qtx.AddSongTag(ctx, db.AddSongTagParams{SongID: songID, Tag: tag}); err != nil {
+			return fmt.Errorf("failed to add song tag: %w", err)
+		}
+	}
+
+	if err = audit.Record(ctx, qtx, audit.Event{
+		Entity:   audit.EntityTags,
+		EntityID: songID,
+		Action:   audit.ActionUpdate,
+		Before:   nonNilTags(prev),
+		After:    nonNilTags(tags),
+	}); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// nonNilTags возвращает пустой список вместо nil, чтобы в журнале аудита песня без тегов
+// отличалась от отсутствующего состояния.
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// existingSong считывает ID песни из URL и проверяет, что песня существует.
+// При ошибке отправляет ответ и возвращает ok == false.
+func (hq *HandleQueries) existingSong(w http.ResponseWriter, r *http.Request) (int32, bool) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return 0, false
+	}
+
+	// Проверям существование песни и возвращаем ошибку, если её нет в базе данных.
+	if _, err = hq.GetOne(r.Context(), id); err != nil {
+		logger.Ctx(r.Context()).Error("ID does not exist")
+		ErrReturn(fmt.Errorf("ID does not exist"), http.StatusBadRequest, w)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/songbook"
+)
+
+// maxSongbookSongs максимальное количество песен в одном песеннике.
+const maxSongbookSongs = 500
+
+// GenerateSongbook обрабатывает GET запрос и формирует песенник для печати в формате HTML или Markdown:
+// оглавление и тексты выбранных песен, разбитые на куплеты так же, как в TextSongWithPagination.
+// Песни выбираются списком ID в указанном порядке, либо фильтрами ListSongsWithFilters.
+// Формат запроса: "?format=md&title=Уроки&ids=3,5,8".
+//
+// @Summary Формирует песенник для печати.
+// @Description Формирует самодостаточный HTML или Markdown документ с оглавлением и текстами выбранных песен, разбитыми на куплеты. Шаблоны могут быть переопределены оператором.
+// @Tags library
+// @Produce html,plain
+// @Param format query string false "Формат песенника: html или md. Значение по умолчанию: html."
+// @Param title query string false "Заголовок песенника."
+// @Param ids query string false "Список ID песен через запятую в нужном порядке."
+// @Param group query string false "Имя группы для фильтрации, если ids не указаны."
+// @Param song query string false "Название композиции для фильтрации, если ids не указаны."
+// @Param releaseDate query string false "Дата релиза для фильтрации, если ids не указаны. Формат: DD.MM.YYYY."
+// @Param text query string false "Слова в тексте песни для фильтрации, если ids не указаны."
+// @Success 200 {string} string "Песенник в указанном формате."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, неверный ID или отсутствие песен."
+// @Failure 500 {string} string "Ошибка сервера при формировании песенника."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /library/songbook [get]
+func (hq *HandleQueries) GenerateSongbook(w http.ResponseWriter, r *http.Request) {
+	format, err := songbook.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		logger.Ctx(r.Context()).Error("invalid songbook format", logger.Err(err))
+		ErrReturn(err, http.StatusBadRequest, w)
+		return
+	}
+
+	title := r.URL.Query().Get("title")
+	if title == "" {
+		title = "Песенник"
+	}
+
+	songs, errStatus, err := hq.songbookSongs(r)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to get songbook songs", logger.Err(err))
+		ErrReturn(err, errStatus, w)
+		return
+	}
+
+	// Формируем песенник в буфер, чтобы при ошибке шаблона вернуть корректный статус.
+	var buf bytes.Buffer
+	if err = hq.Songbook.Render(&buf, format, songbook.Book{
+		Title:       title,
+		GeneratedAt: time.Now(),
+		Songs:       songs,
+	}); err != nil {
+		logger.Ctx(r.Context()).Error("failed to render songbook", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = buf.WriteTo(w); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
+
+// songbookSongs получает песни для песенника по списку ID или по фильтрам.
+// В случае ошибки возвращает также HTTP статус для ответа.
+func (hq *HandleQueries) songbookSongs(r *http.Request) ([]songbook.Song, int, error) {
+	var songs []songbook.Song
+
+	if rawIDs := r.URL.Query().Get("ids"); rawIDs != "" {
+		ids, err := parseIDList(rawIDs)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		if len(ids) > maxSongbookSongs {
+			return nil, http.StatusBadRequest, fmt.Errorf("too many songs, maximum is %d", maxSongbookSongs)
+		}
+
+		rows, err := hq.ListByIDs(r.Context(), ids)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("failed to get songs: %w", err)
+		}
+
+		// Сохраняем порядок песен, указанный в запросе.
+		byID := make(map[int32]db.ListByIDsRow, len(rows))
+		for _, row := range rows {
+			byID[row.ID] = row
+		}
+		for _, id := range ids {
+			row, ok := byID[id]
+			if !ok {
+				return nil, http.StatusBadRequest, fmt.Errorf("song with ID %d does not exist", id)
+			}
+			songs = append(songs, songbook.NewSong(row.ID, row.Group, row.Song, row.ReleaseDate, row.Link, row.Text))
+		}
+		return songs, 0, nil
+	}
+
+	filter, err := songFilterFromQuery(r)
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("incorrect date format, expected DD.MM.YYYY: %w", err)
+	}
+
+	rows, err := hq.ListWithFilters(r.Context(), db.ListWithFiltersParams{
+		Column1:     sql.NullString{String: filter.Group, Valid: filter.Group != ""},
+		Column2:     sql.NullString{String: filter.Song, Valid: filter.Song != ""},
+		ReleaseDate: filter.ReleaseDate,
+		Column4:     sql.NullString{String: filter.Text, Valid: filter.Text != ""},
+		Limit:       maxSongbookSongs,
+	})
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to get songs: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("there are no songs for these filters")
+	}
+
+	for _, row := range rows {
+		songs = append(songs, songbook.NewSong(row.ID, row.Group, row.Song, row.ReleaseDate, row.Link, row.Text))
+	}
+	return songs, 0, nil
+}
+
+// parseIDList преобразует список ID через запятую в срез, пропуская повторы.
+func parseIDList(s string) ([]int32, error) {
+	var ids []int32
+	seen := make(map[int32]bool)
+	for _, part := range strings.Split(s, ",") {
+		id, err := services.StringToInt32WithOverflowCheck(strings.TrimSpace(part))
+		if err != nil || id < 1 {
+			return nil, fmt.Errorf("invalid song ID: %q", part)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
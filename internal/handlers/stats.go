@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+// Виды прослушиваний и просмотров песни.
+const (
+	playKindPlay    = "play"    // воспроизведение песни
+	playKindView    = "view"    // просмотр страницы песни в приложении
+	playKindCouplet = "couplet" // просмотр куплета через TextSongWithPagination
+)
+
+// maxStatsLimit ограничивает размер списков статистики и истории.
+const maxStatsLimit = 100
+
+// statsPeriods число дней в периоде статистики, 0 - за всё время.
+var statsPeriods = map[string]int{
+	"day":   1,
+	"week":  7,
+	"month": 30,
+	"year":  365,
+	"all":   0,
+}
+
+// recordPlay записывает прослушивание или просмотр песни и увеличивает её счётчики за день.
+// Для запросов без учётной записи пользователя событие сохраняется без неё.
+func (hq *HandleQueries) recordPlay(r *http.Request, songID int32, kind string) error {
+	accountID, err := hq.callerAccount(r)
+	if err != nil && !errors.Is(err, errNoAccount) {
+		return err
+	}
+
+	ctx := r.Context()
+	tx, err := hq.beginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if errRb := tx.Rollback(); errRb != nil && !errors.Is(errRb, sql.ErrTxDone) {
+			logger.Ctx(r.Context()).Error("error rolling back transaction", logger.Err(errRb))
+		}
+	}()
+	qtx := db.New(tx)
+
+	if err = qtx.AddPlayEvent(ctx, db.AddPlayEventParams{
+		SongID:    songID,
+		AccountID: sql.NullInt32{Int32: accountID, Valid: accountID != 0},
+		Kind:      kind,
+	}); err != nil {
+		return fmt.Errorf("failed to save play event: %w", err)
+	}
+
+	counters := db.IncrementDailyStatsParams{SongID: songID}
+	if kind == playKindPlay {
+		counters.Plays = 1
+	} else {
+		counters.Views = 1
+	}
+	if err = qtx.IncrementDailyStats(ctx, counters); err != nil {
+		return fmt.Errorf("failed to update daily stats: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+	return nil
+}
+
+// statsLimit считывает из URL размер списка, по умолчанию PAGINATION_LIMIT, не больше 100.
+func (hq *HandleQueries) statsLimit(r *http.Request) int32 {
+	limit, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = hq.settings().PaginationLimit
+	}
+	return min(limit, maxStatsLimit)
+}
+
+// RecordSongPlay обрабатывает POST запрос и записывает прослушивание или просмотр песни
+// по указанному ID: "?id=21&kind=play". Просмотры куплетов записываются автоматически.
+//
+// @Summary Записывает прослушивание песни.
+// @Description Записывает прослушивание (kind=play) или просмотр (kind=view) песни от имени пользователя и увеличивает дневные счётчики песни.
+// @Tags stats
+// @Produce json
+// @Param id query int32 true "ID песни."
+// @Param kind query string false "Вид события: play (по умолчанию) или view."
+// @Success 200 {object} map[string]interface{} "{}" "Событие записано."
+// @Failure 400 {object} map[string]string "Некорректный ID песни или вид события."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/play [post]
+func (hq *HandleQueries) RecordSongPlay(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	kind := r.URL.Query().Get("kind")
+	switch kind {
+	case "":
+		kind = playKindPlay
+	case playKindPlay, playKindView:
+	default:
+		ErrReturn(fmt.Errorf("unsupported kind '%s', expected play or view", kind), http.StatusBadRequest, w)
+		return
+	}
+
+	// Проверям существование песни и возвращаем ошибку, если её нет в базе данных.
+	if _, err = hq.GetOne(r.Context(), id); err != nil {
+		logger.Ctx(r.Context()).Error("ID does not exist")
+		ErrReturn(fmt.Errorf("ID does not exist"), http.StatusBadRequest, w)
+		return
+	}
+
+	if err = hq.recordPlay(r, id, kind); err != nil {
+		logger.Ctx(r.Context()).Error("failed to record play", logger.Int64("song", int64(id)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeEmptyJSON(w, r)
+}
+
+// TopChart обрабатывает GET запрос и выводит самые популярные песни или исполнителей
+// за период по сумме прослушиваний и просмотров. Формат запроса: "?by=artists&period=week&limit=10".
+//
+// @Summary Популярные песни и исполнители.
+// @Description Выводит самые популярные песни (by=songs) или исполнителей (by=artists) за период day, week, month, year или all по сумме прослушиваний и просмотров.
+// @Tags stats
+// @Produce json
+// @Param by query string false "songs (по умолчанию) или artists."
+// @Param period query string false "day, week (по умолчанию), month, year или all."
+// @Param limit query int false "Размер списка, не больше 100. Значение по умолчанию: PAGINATION_LIMIT."
+// @Success 200 {array} db.TopSongsRow "Популярные песни или исполнители (db.TopArtistsRow)."
+// @Failure 400 {object} map[string]string "Некорректный период или вид списка."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /stats/top [get]
+func (hq *HandleQueries) TopChart(w http.ResponseWriter, r *http.Request) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "week"
+	}
+	days, ok := statsPeriods[period]
+	if !ok {
+		ErrReturn(fmt.Errorf("unsupported period '%s', expected day, week, month, year or all", period), http.StatusBadRequest, w)
+		return
+	}
+
+	var since time.Time
+	if days > 0 {
+		now := time.Now()
+		since = time.Date(now.Year(), now.Month(), now.Day()-days+1, 0, 0, 0, 0, time.UTC)
+	}
+
+	var (
+		result interface{}
+		err    error
+	)
+	switch by := r.URL.Query().Get("by"); by {
+	case "", "songs":
+		result, err = nonNil(hq.TopSongs(r.Context(), db.TopSongsParams{Day: since, Limit: hq.statsLimit(r)}))
+	case "artists":
+		result, err = nonNil(hq.TopArtists(r.Context(), db.TopArtistsParams{Day: since, Limit: hq.statsLimit(r)}))
+	default:
+		ErrReturn(fmt.Errorf("unsupported by '%s', expected songs or artists", by), http.StatusBadRequest, w)
+		return
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to get top chart", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, result)
+}
+
+// ShowPlayHistory обрабатывает GET запрос и выводит историю прослушиваний и просмотров
+// пользователя, начиная с последних. Формат запроса: "?limit=20&offset=0".
+//
+// @Summary История прослушиваний пользователя.
+// @Description Выводит прослушивания и просмотры песен пользователем, от имени которого выполняется запрос, начиная с последних.
+// @Tags stats
+// @Produce json
+// @Param limit query int false "Размер страницы, не больше 100. Значение по умолчанию: PAGINATION_LIMIT."
+// @Param offset query int false "Смещение. Значение по умолчанию: 0."
+// @Success 200 {array} db.ListPlayHistoryRow "История прослушиваний."
+// @Failure 403 {object} map[string]string "Ключ не привязан к учётной записи пользователя."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /user/history [get]
+func (hq *HandleQueries) ShowPlayHistory(w http.ResponseWriter, r *http.Request) {
+	accountID, err := hq.callerAccount(r)
+	if errors.Is(err, errNoAccount) {
+		ErrReturn(err, http.StatusForbidden, w)
+		return
+	}
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to get caller account", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	offset, errOffset := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("offset"))
+	if errOffset != nil || offset < 0 {
+		offset = 0
+	}
+
+	history, err := nonNil(hq.ListPlayHistory(r.Context(), db.ListPlayHistoryParams{
+		AccountID: sql.NullInt32{Int32: accountID, Valid: true},
+		Limit:     hq.statsLimit(r),
+		Offset:    offset,
+	}))
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to get play history", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, history)
+}
+
+// nonNil заменяет пустой результат запроса пустым списком, чтобы в JSON был [] вместо null.
+func nonNil[T any](rows []T, err error) ([]T, error) {
+	if rows == nil {
+		rows = []T{}
+	}
+	return rows, err
+}
+
+// writeJSON отправляет успешный ответ в формате JSON.
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	writeJSONStatus(w, r, http.StatusOK, v)
+}
+
+// writeJSONStatus отправляет ответ в формате JSON с кодом code.
+func writeJSONStatus(w http.ResponseWriter, r *http.Request, code int, v interface{}) {
+	resJSON, err := json.Marshal(v)
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt json-marshal response", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	w.WriteHeader(code)
+
+	if _, err = w.Write(resJSON); err != nil {
+		logger.Ctx(r.Context()).Error("failed attempt WRITE response", logger.Err(err))
+		return
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/database"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+var (
+	errNotInTrash = errors.New("song is not in the trash")
+	errSongExists = errors.New("song already exists in the library for this group")
+)
+
+// purgeBatchSize сколько песен удаляется из корзины за один запрос к базе данных.
+const purgeBatchSize = 100
+
+// ShowTrash обрабатывает GET запрос и выводит песни в корзине, начиная с последних
+// удалённых. Формат запроса: "?limit=20&offset=0".
+//
+// @Summary Выводит песни в корзине.
+// @Description Выводит удалённые песни, которые ещё можно восстановить, начиная с последних удалённых.
+// @Tags library
+// @Produce json
+// @Param limit query int false "Размер страницы, не больше 100. Значение по умолчанию: PAGINATION_LIMIT."
+// @Param offset query int false "Смещение. Значение по умолчанию: 0."
+// @Success 200 {array} db.ListDeletedRow "Песни в корзине."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /library/trash [get]
+func (hq *HandleQueries) ShowTrash(w http.ResponseWriter, r *http.Request) {
+	offset, errOffset := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("offset"))
+	if errOffset != nil || offset < 0 {
+		offset = 0
+	}
+
+	songs, err := nonNil(hq.ListDeleted(r.Context(), db.ListDeletedParams{
+		Limit:  hq.statsLimit(r),
+		Offset: offset,
+	}))
+	if err != nil {
+		logger.Ctx(r.Context()).Error("failed to list trash", logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, songs)
+}
+
+// RestoreSong обрабатывает POST запрос и восстанавливает песню из корзины по указанному ID: "?id=21".
+//
+// @Summary Восстанавливает песню из корзины.
+// @Description Возвращает удалённую песню в библиотеку вместе с файлами, оценками, тегами и местами в плейлистах.
+// @Tags library
+// @Produce json
+// @Param id query int true "ID песни в корзине."
+// @Success 200 {object} map[string]interface{} "{}" "Песня восстановлена."
+// @Failure 400 {object} map[string]string "Некорректный запрос, например, песни нет в корзине."
+// @Failure 409 {object} map[string]string "В библиотеке уже есть песня с тем же названием у той же группы."
+// @Failure 500 {string} string "Ошибка сервера."
+// @Failure 429 {object} map[string]string "Превышен лимит запросов, см. заголовок Retry-After."
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /library/restore [post]
+func (hq *HandleQueries) RestoreSong(w http.ResponseWriter, r *http.Request) {
+	id, err := services.StringToInt32WithOverflowCheck(r.URL.Query().Get("id"))
+	if err != nil || id < 1 {
+		logger.Ctx(r.Context()).Error("ID < 1 or not a number", logger.Err(err))
+		ErrReturn(fmt.Errorf("ID < 1 or %w", err), http.StatusBadRequest, w)
+		return
+	}
+
+	err = hq.inTx(r.Context(), func(qtx *db.Queries) error {
+		song, errGet := qtx.GetDeleted(r.Context(), id)
+		if errors.Is(errGet, sql.ErrNoRows) {
+			return errNotInTrash
+		}
+		if errGet != nil {
+			return fmt.Errorf("failed to get deleted song: %w", errGet)
+		}
+
+		// Пока песня была в корзине, её могли добавить заново.
+		exists, errCheck := qtx.CheckSongWithID(r.Context(), db.CheckSongWithIDParams{GroupID: song.GroupID, SongKey: song.SongKey})
+		if errCheck != nil {
+			return fmt.Errorf("error checking song: %w", errCheck)
+		}
+		if exists {
+			return errSongExists
+		}
+
+		restored, errRestore := qtx.Restore(r.Context(), id)
+		// Ту же песню добавил запрос, выполненный одновременно с проверкой выше.
+		if database.IsUniqueViolation(errRestore) {
+			return errSongExists
+		}
+		if errRestore != nil {
+			return fmt.Errorf("failed to restore song: %w", errRestore)
+		}
+		// Песню восстановили или удалили окончательно одновременно с этим запросом.
+		if restored == 0 {
+			return errNotInTrash
+		}
+
+		active := song
+		active.DeletedAt = sql.NullTime{}
+		return audit.Record(r.Context(), qtx, audit.Event{
+			Entity:   audit.EntitySong,
+			EntityID: id,
+			Action:   audit.ActionRestore,
+			Before:   audit.SongState(song),
+			After:    audit.SongState(active),
+		})
+	})
+	switch {
+	case errors.Is(err, errNotInTrash):
+		ErrReturn(err, http.StatusBadRequest, w)
+		return
+	case errors.Is(err, errSongExists):
+		ErrReturn(err, http.StatusConflict, w)
+		return
+	case err != nil:
+		logger.Ctx(r.Context()).Error("failed to restore song", logger.Int64("song", int64(id)), logger.Err(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	hq.related.Invalidate()
+
+	writeEmptyJSON(w, r)
+}
+
+// PurgeTrash окончательно удаляет песни, перемещённые в корзину раньше before, вместе
+// с их файлами в хранилище. Возвращает число удалённых песен.
+func (hq *HandleQueries) PurgeTrash(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	for {
+		ids, err := hq.ListExpiredDeleted(ctx, db.ListExpiredDeletedParams{
+			DeletedAt: sql.NullTime{Time: before, Valid: true},
+			Limit:     purgeBatchSize,
+		})
+		if err != nil {
+			return purged, fmt.Errorf("failed to list expired songs: %w", err)
+		}
+
+		for _, id := range ids {
+			// Запоминаем файлы песни, записи о них удалятся вместе с песней.
+			keys, errKeys := hq.ListSongStorageKeys(ctx, id)
+			if errKeys != nil {
+				return purged, fmt.Errorf("failed to list files of song %d: %w", id, errKeys)
+			}
+
+			errPurge := hq.inTx(ctx, func(qtx *db.Queries) error {
+				song, errGet := qtx.GetDeleted(ctx, id)
+				if errors.Is(errGet, sql.ErrNoRows) {
+					return errNotInTrash
+				}
+				if errGet != nil {
+					return fmt.Errorf("failed to get deleted song %d: %w", id, errGet)
+				}
+
+				n, errDel := qtx.Purge(ctx, id)
+				if errDel != nil {
+					return fmt.Errorf("failed to purge song %d: %w", id, errDel)
+				}
+				if n == 0 {
+					return errNotInTrash
+				}
+				return audit.Record(ctx, qtx, audit.Event{
+					Entity:   audit.EntitySong,
+					EntityID: id,
+					Action:   audit.ActionPurge,
+					Before:   audit.SongState(song),
+				})
+			})
+			// Песню восстановили после выборки.
+			if errors.Is(errPurge, errNotInTrash) {
+				continue
+			}
+			if errPurge != nil {
+				return purged, errPurge
+			}
+			purged++
+
+			for _, key := range keys {
+				if errDel := hq.Blobs.Delete(ctx, key); errDel != nil {
+					logger.Ctx(ctx).Error(errDel.Error())
+				}
+			}
+		}
+
+		if len(ids) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
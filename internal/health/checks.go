@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/breaker"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/migration"
+)
+
+// Database проверяет подключение к базе данных.
+func Database(db *sql.DB) CheckFunc {
+	return func(ctx context.Context) Component {
+		if err := db.PingContext(ctx); err != nil {
+			return Component{Status: StatusDown, Error: err.Error()}
+		}
+
+		stats := db.Stats()
+		return Component{Status: StatusUp, Details: map[string]interface{}{
+			"openConnections": stats.OpenConnections,
+			"inUse":           stats.InUse,
+		}}
+	}
+}
+
+// Migrations проверяет, что к базе данных применены все миграции из migrationPath
+// и ни одна не осталась незавершённой.
+func Migrations(db *sql.DB, migrationPath string) CheckFunc {
+	return func(ctx context.Context) Component {
+		st, err := migration.Inspect(ctx, db, migrationPath)
+		if err != nil {
+			return Component{Status: StatusDown, Error: err.Error()}
+		}
+
+		res := Component{Status: StatusUp, Details: map[string]interface{}{
+			"version":  st.Version,
+			"expected": st.Latest,
+			"dirty":    st.Dirty,
+		}}
+		if errSt := st.Err(); errSt != nil {
+			res.Status = StatusDown
+			res.Error = errSt.Error()
+		}
+		return res
+	}
+}
+
+// Circuit сообщает, выполняются ли запросы через b. Пока запросы приостановлены,
+// компонент находится в состоянии degraded.
+func Circuit(b *breaker.Breaker) CheckFunc {
+	return func(context.Context) Component {
+		state := b.State()
+		res := Component{Status: StatusUp, Details: map[string]interface{}{
+			"state":    state,
+			"failures": b.Failures(),
+		}}
+		if state != breaker.StateClosed {
+			res.Status = StatusDegraded
+		}
+		return res
+	}
+}
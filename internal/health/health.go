@@ -0,0 +1,114 @@
+// Package health проверяет состояние приложения и его зависимостей для эндпойнтов
+// /healthz и /readyz.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status состояние приложения или компонента.
+type Status string
+
+const (
+	StatusUp       Status = "up"       // компонент работает
+	StatusDegraded Status = "degraded" // компонент работает с ограничениями
+	StatusDown     Status = "down"     // компонент недоступен
+)
+
+// Component результат проверки одного компонента.
+type Component struct {
+	Status  Status                 `json:"status" example:"up"`
+	Error   string                 `json:"error,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Report результат проверки приложения.
+type Report struct {
+	Status     Status               `json:"status" example:"up"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// CheckFunc проверяет компонент. Контекст отменяется по истечении времени проверки.
+type CheckFunc func(ctx context.Context) Component
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker выполняет проверки компонентов и хранит признак готовности принимать запросы.
+type Checker struct {
+	timeout atomic.Int64 // time.Duration
+	checks  []check
+	ready   atomic.Bool
+}
+
+// New создаёт Checker, каждая проверка которого ограничена timeout. Checker не готов
+// принимать запросы до вызова SetReady(true).
+func New(timeout time.Duration) *Checker {
+	c := &Checker{}
+	c.SetTimeout(timeout)
+	return c
+}
+
+// SetTimeout меняет время на проверки, действует со следующего вызова Check.
+func (c *Checker) SetTimeout(timeout time.Duration) {
+	c.timeout.Store(int64(timeout))
+}
+
+// Register добавляет проверку компонента. Недоступность critical компонента
+// делает приложение неготовым, остальных - только снижает его состояние до degraded.
+func (c *Checker) Register(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// SetReady меняет готовность принимать запросы, при остановке сервера - на false.
+func (c *Checker) SetReady(ready bool) {
+	c.ready.Store(ready)
+}
+
+// Ready сообщает, готово ли приложение принимать запросы.
+func (c *Checker) Ready() bool {
+	return c.ready.Load()
+}
+
+// Check выполняет все проверки одновременно и возвращает общее состояние.
+func (c *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(c.timeout.Load()))
+	defer cancel()
+
+	results := make([]Component, len(c.checks))
+	var wg sync.WaitGroup
+	for i, ch := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = ch.fn(ctx)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Components: make(map[string]Component, len(c.checks)+1)}
+	for i, ch := range c.checks {
+		res := results[i]
+		report.Components[ch.name] = res
+
+		switch {
+		case res.Status == StatusUp:
+		case ch.critical && res.Status == StatusDown:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+
+	if !c.Ready() {
+		report.Status = StatusDown
+		report.Components["server"] = Component{Status: StatusDown, Error: "server is not accepting requests"}
+	}
+	return report
+}
@@ -0,0 +1,369 @@
+// Package importer создаёт и обновляет песни библиотеки по тегам аудиофайлов.
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fmt"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audiotag"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/dedupe"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/tracing"
+)
+
+// Статусы обработки файла.
+const (
+	StatusCreated = "created" // добавлена новая песня
+	StatusUpdated = "updated" // обновлена существующая песня
+	StatusChecked = "checked" // теги проверены без записи в базу данных
+	StatusSkipped = "skipped" // файл пропущен из-за ошибки, отсутствующих или конфликтующих тегов
+)
+
+// SupportedExtensions расширения файлов, которые просматриваются при сканировании папки.
+var SupportedExtensions = []string{".mp3", ".flac"}
+
+// Song данные песни, полученные из тегов.
+type Song struct {
+	Group       string
+	Title       string
+	ReleaseDate time.Time // нулевое значение, если дата не указана
+	Text        string    // пустая строка, если текст не указан
+}
+
+// Store сохраняет песню и возвращает её ID и признак создания новой записи.
+type Store interface {
+	UpsertSong(ctx context.Context, song Song) (id int32, created bool, err error)
+}
+
+// FileReport результат обработки одного файла.
+type FileReport struct {
+	File      string   `json:"file"`
+	Status    string   `json:"status"`
+	SongID    int32    `json:"songId,omitempty"`
+	Group     string   `json:"group,omitempty"`
+	Song      string   `json:"song,omitempty"`
+	Missing   []string `json:"missing,omitempty"`
+	Conflicts []string `json:"conflicts,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// Report итоговый отчёт об импорте.
+type Report struct {
+	Created int          `json:"created"`
+	Updated int          `json:"updated"`
+	Checked int          `json:"checked"`
+	Skipped int          `json:"skipped"`
+	Files   []FileReport `json:"files"`
+}
+
+// Batch импортирует набор файлов и отслеживает конфликты между ними:
+// если одна и та же песня встречается в нескольких файлах с разными датой или текстом,
+// то повторный файл пропускается.
+type Batch struct {
+	store  Store
+	seen   map[string]Song
+	report Report
+}
+
+// NewBatch создаёт Batch. Если store == nil, теги только проверяются, без записи в базу данных.
+func NewBatch(store Store) *Batch {
+	return &Batch{
+		store: store,
+		seen:  make(map[string]Song),
+	}
+}
+
+// Report возвращает отчёт о всех обработанных файлах.
+func (b *Batch) Report() Report {
+	return b.report
+}
+
+// Add читает теги из r и создаёт или обновляет соответствующую песню.
+func (b *Batch) Add(ctx context.Context, name string, r io.Reader) FileReport {
+	fr := FileReport{File: name, Status: StatusSkipped}
+
+	tags, err := audiotag.Read(r)
+	if err != nil {
+		fr.Error = err.Error()
+		return b.add(fr)
+	}
+
+	song, missing, conflicts := songFromTags(tags)
+	fr.Group, fr.Song = song.Group, song.Title
+	fr.Missing, fr.Conflicts = missing, conflicts
+
+	// Без исполнителя и названия песню невозможно сопоставить с библиотекой.
+	if song.Group == "" || song.Title == "" || len(conflicts) > 0 {
+		return b.add(fr)
+	}
+
+	// Ключ совпадает с ключами библиотеки, поэтому "Muse " и "muse" - одна песня.
+	key := dedupe.Key(song.Group) + "\x00" + dedupe.Key(song.Title)
+	if prev, ok := b.seen[key]; ok {
+		if c := compareSongs(prev, song); len(c) > 0 {
+			fr.Conflicts = append(fr.Conflicts, c...)
+			return b.add(fr)
+		}
+	}
+	b.seen[key] = song
+
+	if b.store == nil {
+		fr.Status = StatusChecked
+		return b.add(fr)
+	}
+
+	id, created, err := b.store.UpsertSong(ctx, song)
+	if err != nil {
+		fr.Error = err.Error()
+		return b.add(fr)
+	}
+
+	fr.SongID = id
+	fr.Status = StatusUpdated
+	if created {
+		fr.Status = StatusCreated
+	}
+	return b.add(fr)
+}
+
+func (b *Batch) add(fr FileReport) FileReport {
+	switch fr.Status {
+	case StatusCreated:
+		b.report.Created++
+	case StatusUpdated:
+		b.report.Updated++
+	case StatusChecked:
+		b.report.Checked++
+	default:
+		b.report.Skipped++
+	}
+	b.report.Files = append(b.report.Files, fr)
+	return fr
+}
+
+// ScanDir рекурсивно обходит папку и импортирует все файлы с поддерживаемыми расширениями.
+func (b *Batch) ScanDir(ctx context.Context, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() || !IsSupported(path) {
+			return nil
+		}
+
+		file, errOpen := os.Open(path)
+		if errOpen != nil {
+			b.add(FileReport{File: path, Status: StatusSkipped, Error: errOpen.Error()})
+			return nil
+		}
+		defer file.Close()
+
+		b.Add(ctx, path, file)
+		return nil
+	})
+}
+
+// IsSupported проверяет, поддерживается ли файл по его расширению.
+func IsSupported(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range SupportedExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// songFromTags преобразует теги в песню и возвращает списки отсутствующих и конфликтующих тегов.
+func songFromTags(tags audiotag.Tags) (song Song, missing, conflicts []string) {
+	pick := func(name string, values []string) string {
+		distinct := distinctValues(values)
+		switch len(distinct) {
+		case 0:
+			missing = append(missing, name)
+			return ""
+		case 1:
+			return distinct[0]
+		default:
+			conflicts = append(conflicts, fmt.Sprintf("%s: %q", name, distinct))
+			return distinct[0]
+		}
+	}
+
+	song.Group = strings.TrimSpace(pick("artist", tags.Artist))
+	song.Title = strings.TrimSpace(pick("title", tags.Title))
+	song.Text = strings.TrimSpace(pick("lyrics", tags.Lyrics))
+
+	if len(tags.Date) == 0 {
+		missing = append(missing, "date")
+		return song, missing, conflicts
+	}
+
+	// Год и полная дата не конфликтуют, если совпадают по году: выбираем более точную.
+	years := make(map[int]bool)
+	precise := make(map[time.Time]bool)
+	for _, raw := range distinctValues(tags.Date) {
+		date, isPrecise, err := parseDate(raw)
+		if err != nil {
+			conflicts = append(conflicts, fmt.Sprintf("date: invalid value %q", raw))
+			continue
+		}
+		years[date.Year()] = true
+		if isPrecise {
+			precise[date] = true
+			song.ReleaseDate = date
+		} else if song.ReleaseDate.IsZero() {
+			song.ReleaseDate = date
+		}
+	}
+	if len(years) > 1 || len(precise) > 1 {
+		conflicts = append(conflicts, fmt.Sprintf("date: %q", distinctValues(tags.Date)))
+	}
+
+	return song, missing, conflicts
+}
+
+// compareSongs возвращает конфликты между данными одной песни из разных файлов.
+func compareSongs(prev, cur Song) []string {
+	var conflicts []string
+	if !prev.ReleaseDate.IsZero() && !cur.ReleaseDate.IsZero() && !prev.ReleaseDate.Equal(cur.ReleaseDate) {
+		conflicts = append(conflicts, fmt.Sprintf("date differs from another file: %s and %s",
+			prev.ReleaseDate.Format("02.01.2006"), cur.ReleaseDate.Format("02.01.2006")))
+	}
+	if prev.Text != "" && cur.Text != "" && prev.Text != cur.Text {
+		conflicts = append(conflicts, "lyrics differ from another file")
+	}
+	return conflicts
+}
+
+// parseDate разбирает дату из тегов: "2006", "2006-07" или "2006-07-16" (возможно со временем).
+// precise == true, если указан не только год.
+func parseDate(raw string) (date time.Time, precise bool, err error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) > 10 {
+		raw = raw[:10]
+	}
+
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		if date, err = time.Parse(layout, raw); err == nil {
+			return date, layout != "2006", nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("unsupported date format: %s", raw)
+}
+
+// distinctValues возвращает значения без повторов и пробелов по краям.
+func distinctValues(values []string) []string {
+	var res []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && !seen[v] {
+			seen[v] = true
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// DBStore сохраняет песни в базу данных.
+type DBStore struct {
+	conn *sql.DB
+}
+
+// NewDBStore создаёт DBStore.
+func NewDBStore(conn *sql.DB) *DBStore {
+	return &DBStore{conn: conn}
+}
+
+// UpsertSong в одной транзакции находит или создаёт группу и песню,
+// а затем обновляет дату выпуска и текст, если они указаны в тегах.
+// Созданные и изменённые песни записываются в журнал аудита.
+func (s *DBStore) UpsertSong(ctx context.Context, song Song) (id int32, created bool, err error) {
+	tx, err := tracing.BeginTx(ctx, s.conn, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	qtx := db.New(tx)
+
+	// Группы и песни сравниваются по ключам, "Muse" и "muse" - одна группа.
+	groupID, err := qtx.GetArtistID(ctx, dedupe.Key(song.Group))
+	if errors.Is(err, sql.ErrNoRows) {
+		artist, errIns := qtx.AddArtist(ctx, db.AddArtistParams{Group: song.Group, GroupKey: dedupe.Key(song.Group)})
+		if errIns != nil {
+			return 0, false, fmt.Errorf("error adding group: %w", errIns)
+		}
+		groupID, err = artist.ID, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error checking group: %w", err)
+	}
+
+	var before db.Library
+	id, err = qtx.GetSongID(ctx, db.GetSongIDParams{GroupID: groupID, SongKey: dedupe.Key(song.Title)})
+	if errors.Is(err, sql.ErrNoRows) {
+		inserted, errIns := qtx.AddSongWithID(ctx, db.AddSongWithIDParams{
+			GroupID: groupID,
+			Song:    song.Title,
+			SongKey: dedupe.Key(song.Title),
+		})
+		if errIns != nil {
+			return 0, false, fmt.Errorf("error adding song: %w", errIns)
+		}
+		id, created, err = inserted.ID, true, nil
+	} else if err == nil {
+		before, err = qtx.GetOne(ctx, id)
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error checking song: %w", err)
+	}
+
+	// Пустые значения не перезаписывают данные, уже сохранённые в библиотеке.
+	if err = qtx.Update(ctx, db.UpdateParams{
+		ID:      id,
+		Column2: song.ReleaseDate,
+		Column3: song.Text,
+		Column4: "",
+	}); err != nil {
+		return 0, false, fmt.Errorf("error updating song: %w", err)
+	}
+
+	after, err := qtx.GetOne(ctx, id)
+	if err != nil {
+		return 0, false, fmt.Errorf("error getting song: %w", err)
+	}
+	// Повторный импорт тех же файлов не меняет песни и не записывается в журнал.
+	ev := audit.Event{Entity: audit.EntitySong, EntityID: id, Action: audit.ActionCreate, After: audit.SongState(after)}
+	if !created {
+		ev.Action, ev.Before = audit.ActionUpdate, audit.SongState(before)
+	}
+	if created || audit.SongState(before) != audit.SongState(after) {
+		if err = audit.Record(ctx, qtx, ev); err != nil {
+			return 0, false, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return id, created, nil
+}
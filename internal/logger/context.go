@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Field типизированное поле структурированной записи журнала.
+type Field = zap.Field
+
+// String поле со строкой.
+func String(key, val string) Field { return zap.String(key, val) }
+
+// Int поле с целым числом.
+func Int(key string, val int) Field { return zap.Int(key, val) }
+
+// Int64 поле с целым числом int64.
+func Int64(key string, val int64) Field { return zap.Int64(key, val) }
+
+// Bool поле с логическим значением.
+func Bool(key string, val bool) Field { return zap.Bool(key, val) }
+
+// Duration поле с длительностью.
+func Duration(key string, val time.Duration) Field { return zap.Duration(key, val) }
+
+// Err поле "error" с текстом ошибки.
+func Err(err error) Field { return zap.Error(err) }
+
+// Any поле с произвольным значением, по возможности используйте типизированные поля.
+func Any(key string, val interface{}) Field { return zap.Any(key, val) }
+
+// Logger структурированный журнал с полями, общими для всех записей, например ID запроса.
+type Logger struct {
+	z *zap.Logger
+}
+
+// Debug записывает сообщение уровня DEBUG.
+func (l *Logger) Debug(msg string, fields ...Field) { l.z.Debug(msg, fields...) }
+
+// Info записывает сообщение уровня INFO.
+func (l *Logger) Info(msg string, fields ...Field) { l.z.Info(msg, fields...) }
+
+// Warn записывает сообщение уровня WARN.
+func (l *Logger) Warn(msg string, fields ...Field) { l.z.Warn(msg, fields...) }
+
+// Error записывает сообщение уровня ERROR.
+func (l *Logger) Error(msg string, fields ...Field) { l.z.Error(msg, fields...) }
+
+// With возвращает журнал, записи которого дополнительно содержат fields.
+func (l *Logger) With(fields ...Field) *Logger {
+	return &Logger{z: l.z.With(fields...)}
+}
+
+type fieldsKey struct{}
+
+// WithFields возвращает контекст, записи журнала Ctx(ctx) которого содержат fields,
+// вместе с полями, добавленными ранее.
+func WithFields(ctx context.Context, fields ...Field) context.Context {
+	prev := fieldsFromContext(ctx)
+	merged := make([]Field, 0, len(prev)+len(fields))
+	merged = append(merged, prev...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// Ctx возвращает журнал с полями из контекста, например ID запроса и трассировки.
+func Ctx(ctx context.Context) *Logger {
+	return &Logger{z: base().With(fieldsFromContext(ctx)...)}
+}
+
+func fieldsFromContext(ctx context.Context) []Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	return fields
+}
+
+// base возвращает текущий логгер zap.
+func base() *zap.Logger {
+	if s, ok := Zap.(*ZapStorage); ok && s.Logger != nil {
+		return s.Logger
+	}
+	return zap.NewNop()
+}
+
+// Replace заменяет логгер, используется в тестах. Логгер должен быть создан
+// с zap.AddCallerSkip(1), как в Initialize.
+func Replace(l *zap.Logger) {
+	Zap = &ZapStorage{l}
+}
@@ -9,6 +9,8 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// ZapService журнал с сообщениями из произвольных значений. Для записей с полями
+// и ID запроса используйте Ctx.
 type ZapService interface {
 	Debug(fields ...interface{})
 	Info(fields ...interface{})
@@ -22,20 +24,39 @@ type ZapStorage struct {
 
 var Zap ZapService = &ZapStorage{zap.NewNop()}
 
-// Initialize инициализирует логгер.
-func Initialize(level string) error {
+// atomicLevel уровень журнала, созданного Initialize. Меняется без пересоздания журнала.
+var atomicLevel = zap.NewAtomicLevel()
+
+// Форматы журнала.
+const (
+	FormatConsole = "console" // читаемые строки с цветными уровнями, поля в конце строки в JSON
+	FormatJSON    = "json"    // одна запись JSON на строку для сборщиков журналов
+)
+
+// Initialize инициализирует логгер. Пустой format означает FormatConsole.
+func Initialize(level, format string) error {
 	lvl, err := zap.ParseAtomicLevel(level)
 	if err != nil {
 		return fmt.Errorf("parse atomic level error: %w", err)
 	}
 
 	config := zap.NewDevelopmentConfig()
-	config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	config.Level = lvl
-	config.EncoderConfig.TimeKey = "timestamp"
-	config.EncoderConfig.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-		enc.AppendString(t.Format("2006-01-02 15:04:05"))
+	switch format {
+	case "", FormatConsole:
+		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		config.EncoderConfig.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+			enc.AppendString(t.Format("2006-01-02 15:04:05"))
+		}
+	case FormatJSON:
+		config = zap.NewProductionConfig()
+		config.Sampling = nil
+		config.EncoderConfig.EncodeTime = zapcore.RFC3339NanoTimeEncoder
+	default:
+		return fmt.Errorf("unknown log format '%s', expected console or json", format)
 	}
+	atomicLevel.SetLevel(lvl.Level())
+	config.Level = atomicLevel
+	config.EncoderConfig.TimeKey = "timestamp"
 	config.DisableStacktrace = true
 
 	logger, err := config.Build(zap.AddCaller(), zap.AddCallerSkip(1))
@@ -48,6 +69,16 @@ func Initialize(level string) error {
 	return nil
 }
 
+// SetLevel меняет уровень журнала, созданного Initialize, например при перезагрузке настроек.
+func SetLevel(lvl string) error {
+	parsed, err := zapcore.ParseLevel(lvl)
+	if err != nil {
+		return fmt.Errorf("parse level error: %w", err)
+	}
+	atomicLevel.SetLevel(parsed)
+	return nil
+}
+
 // Debug логирует сообщения уровня DEBUG.
 func (z *ZapStorage) Debug(fields ...interface{}) {
 	z.Logger.Sugar().Debugln(fields...)
@@ -0,0 +1,161 @@
+// Package metrics собирает метрики приложения в формате Prometheus: запросы к серверу,
+// пул соединений с базой данных, обращения к внешнему API и дополнение сведений о песнях.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace префикс имён метрик приложения.
+const namespace = "music_library"
+
+// unmatchedRoute метка запросов, не попавших ни в один маршрут, чтобы произвольные
+// адреса не создавали новых рядов метрик.
+const unmatchedRoute = "unmatched"
+
+// Исходы запроса во внешний API.
+const (
+	ExternalSuccess         = "success"          // получены сведения о песне
+	ExternalBadStatus       = "bad_status"       // API ответил кодом, отличным от 200
+	ExternalNetworkError    = "network_error"    // API недоступен
+	ExternalInvalidResponse = "invalid_response" // ответ не удалось прочитать или разобрать
+	ExternalCircuitOpen     = "circuit_open"     // запрос не выполнялся, API признан недоступным
+)
+
+// Результаты дополнения сведений о песне из внешнего API.
+const (
+	EnrichmentSuccess     = "success"     // дата выпуска, текст и ссылка сохранены
+	EnrichmentUnavailable = "unavailable" // внешний API не вернул сведения
+	EnrichmentFailed      = "failed"      // сведения получены, но не сохранены
+)
+
+// Registry реестр метрик приложения. Стандартные метрики Go и процесса регистрируются в нём же.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	externalRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "external_api_requests_total",
+		Help:      "Number of song details requests to the external API by outcome.",
+	}, []string{"outcome"})
+
+	externalDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "external_api_request_duration_seconds",
+		Help:      "Latency of song details requests to the external API by outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+
+	enrichments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "song_enrichment_total",
+		Help:      "Number of attempts to enrich added songs with external API details by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		externalRequests,
+		externalDuration,
+		enrichments,
+	)
+
+	// Создаём ряды заранее, чтобы счётчики были видны с нулевыми значениями.
+	for _, outcome := range []string{ExternalSuccess, ExternalBadStatus, ExternalNetworkError, ExternalInvalidResponse, ExternalCircuitOpen} {
+		externalRequests.WithLabelValues(outcome)
+	}
+	for _, result := range []string{EnrichmentSuccess, EnrichmentUnavailable, EnrichmentFailed} {
+		enrichments.WithLabelValues(result)
+	}
+}
+
+// Handler отдаёт метрики из Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDB добавляет статистику пула соединений sql.DB.Stats() с меткой db_name.
+func RegisterDB(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveExternalAPI учитывает запрос во внешний API.
+func ObserveExternalAPI(outcome string, elapsed time.Duration) {
+	externalRequests.WithLabelValues(outcome).Inc()
+	externalDuration.WithLabelValues(outcome).Observe(elapsed.Seconds())
+}
+
+// CountEnrichment учитывает попытку дополнить сведения о песне.
+func CountEnrichment(result string) {
+	enrichments.WithLabelValues(result).Inc()
+}
+
+// Middleware (middleware) учитывает число и длительность запросов. Маршрут берётся из
+// шаблона chi, например "/song/couplet", поэтому параметры не попадают в метки.
+func Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+		h.ServeHTTP(sw, r)
+
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := strconv.Itoa(sw.status)
+
+		httpRequests.WithLabelValues(r.Method, route, status).Inc()
+		httpDuration.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusWriter запоминает код ответа.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.status = statusCode
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap позволяет http.ResponseController добраться до исходного ResponseWriter.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
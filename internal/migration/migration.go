@@ -0,0 +1,256 @@
+// Package migration применяет миграции схемы базы данных и сообщает её текущую версию,
+// чтобы для этого не требовалась отдельная утилита migrate. Миграции берутся из папки
+// MIGRATION_PATH или, если путь не указан, встроенные в программу из db/migration.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	schema "github.com/Ra1nz0r/effective_mobile-1/db/migration"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Embedded название встроенных миграций в сообщениях.
+const Embedded = "embedded"
+
+// Entry миграция из папки с миграциями.
+type Entry struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// Status состояние схемы базы данных.
+type Status struct {
+	Version    uint    `json:"version"` // применённая версия, 0 - миграции не применялись
+	Dirty      bool    `json:"dirty"`   // миграция Version не завершилась
+	Latest     uint    `json:"latest"`  // версия последней миграции
+	Migrations []Entry `json:"migrations"`
+}
+
+// Pending сообщает, есть ли неприменённые миграции.
+func (s Status) Pending() bool {
+	return s.Version < s.Latest
+}
+
+// Err возвращает ошибку, если схема не совпадает с последней миграцией.
+func (s Status) Err() error {
+	switch {
+	case s.Dirty:
+		return fmt.Errorf("migration %d did not complete, fix the schema and run 'migrate force VERSION'", s.Version)
+	case s.Pending():
+		return fmt.Errorf("schema version %d, expected %d, run 'migrate up'", s.Version, s.Latest)
+	case s.Version > s.Latest:
+		return fmt.Errorf("schema version %d is newer than the latest migration %d", s.Version, s.Latest)
+	}
+	return nil
+}
+
+// setApplied отмечает применённые миграции и находит последнюю версию.
+func (s *Status) setApplied() {
+	for i := range s.Migrations {
+		e := &s.Migrations[i]
+		// Незавершённая миграция не считается применённой.
+		e.Applied = e.Version < s.Version || (e.Version == s.Version && !s.Dirty)
+		s.Latest = e.Version
+	}
+}
+
+// Logger выводит сообщения migrate о применённых миграциях.
+type Logger func(format string, v ...interface{})
+
+func (l Logger) Printf(format string, v ...interface{}) { l(format, v...) }
+
+func (l Logger) Verbose() bool { return true }
+
+// Migrator применяет миграции по пути migrationPath, например "file://db/migration".
+type Migrator struct {
+	m    *migrate.Migrate
+	path string
+}
+
+// New подключается к базе данных по databaseURL. Пустой migrationPath означает встроенные
+// миграции. Migrator нужно закрыть через Close.
+func New(migrationPath, databaseURL string) (*Migrator, error) {
+	src, err := openSource(migrationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance(sourceName(migrationPath), src, databaseURL)
+	if err != nil {
+		src.Close()
+		// migrate добавляет в ошибку адрес базы данных вместе с паролем.
+		if u, errURL := url.Parse(databaseURL); errURL == nil {
+			return nil, errors.New(strings.ReplaceAll(err.Error(), databaseURL, u.Redacted()))
+		}
+		return nil, err
+	}
+	return &Migrator{m: m, path: migrationPath}, nil
+}
+
+// openSource открывает папку с миграциями или встроенные миграции.
+func openSource(migrationPath string) (source.Driver, error) {
+	if migrationPath == "" {
+		return iofs.New(schema.FS, ".")
+	}
+	return source.Open(migrationPath)
+}
+
+// sourceName возвращает название источника миграций для сообщений.
+func sourceName(migrationPath string) string {
+	if migrationPath == "" {
+		return Embedded
+	}
+	return migrationPath
+}
+
+// Source возвращает путь до миграций или Embedded.
+func (mg *Migrator) Source() string {
+	return sourceName(mg.path)
+}
+
+// SetLogger включает вывод сообщений о применённых миграциях.
+func (mg *Migrator) SetLogger(l Logger) {
+	mg.m.Log = l
+}
+
+// Up применяет steps следующих миграций, при steps <= 0 - все.
+// Отсутствие новых миграций ошибкой не считается.
+func (mg *Migrator) Up(steps int) error {
+	if steps <= 0 {
+		return ignoreNoChange(mg.m.Up())
+	}
+	return ignoreNoChange(mg.m.Steps(steps))
+}
+
+// Down откатывает steps последних миграций.
+func (mg *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("number of migrations to roll back must be positive, got %d", steps)
+	}
+	return ignoreNoChange(mg.m.Steps(-steps))
+}
+
+// Goto применяет или откатывает миграции до версии version.
+func (mg *Migrator) Goto(version uint) error {
+	return ignoreNoChange(mg.m.Migrate(version))
+}
+
+// Force записывает версию схемы и снимает отметку о незавершённой миграции, не выполняя
+// миграций. Используется после ручного исправления схемы, когда миграция прервалась.
+func (mg *Migrator) Force(version uint) error {
+	return mg.m.Force(int(version))
+}
+
+// Status возвращает версию схемы и список миграций с отметкой о применении.
+func (mg *Migrator) Status() (Status, error) {
+	version, dirty, err := mg.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return Status{}, err
+	}
+
+	entries, err := List(mg.path)
+	if err != nil {
+		return Status{}, err
+	}
+
+	st := Status{Version: version, Dirty: dirty, Migrations: entries}
+	st.setApplied()
+	return st, nil
+}
+
+// Close закрывает подключения к базе данных и папке с миграциями.
+func (mg *Migrator) Close() error {
+	errSrc, errDB := mg.m.Close()
+	return errors.Join(errSrc, errDB)
+}
+
+// List возвращает миграции по пути migrationPath по возрастанию версии.
+// Пустой migrationPath означает встроенные миграции.
+func List(migrationPath string) ([]Entry, error) {
+	src, err := openSource(migrationPath)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	var entries []Entry
+	version, err := src.First()
+	for err == nil {
+		entry := Entry{Version: version}
+		if r, name, errRead := src.ReadUp(version); errRead == nil {
+			r.Close()
+			entry.Name = name
+		}
+		entries = append(entries, entry)
+
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Latest возвращает версию последней миграции по пути migrationPath.
+func Latest(migrationPath string) (uint, error) {
+	entries, err := List(migrationPath)
+	if err != nil {
+		return 0, err
+	}
+	if len(entries) == 0 {
+		return 0, fmt.Errorf("no migrations found in %s", sourceName(migrationPath))
+	}
+	return entries[len(entries)-1].Version, nil
+}
+
+// Inspect возвращает состояние схемы без подключения migrate к базе данных,
+// например для проверок готовности. Пустой migrationPath означает встроенные миграции.
+func Inspect(ctx context.Context, db *sql.DB, migrationPath string) (Status, error) {
+	entries, err := List(migrationPath)
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	version, dirty, err := DBVersion(ctx, db)
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	st := Status{Version: version, Dirty: dirty, Migrations: entries}
+	st.setApplied()
+	return st, nil
+}
+
+// DBVersion возвращает версию схемы базы данных и признак незавершённой миграции
+// из таблицы schema_migrations, которую ведёт migrate. Без таблицы версия равна 0.
+func DBVersion(ctx context.Context, db *sql.DB) (version uint, dirty bool, err error) {
+	var exists bool
+	if err = db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil || !exists {
+		return 0, false, err
+	}
+
+	err = db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
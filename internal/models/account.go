@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// AccountParams для создания учётной записи пользователя.
+type AccountParams struct {
+	Name string `json:"name"`
+}
+
+// Account учётная запись пользователя.
+type Account struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	SSO       bool      `json:"sso"` // учётная запись создана при первом входе через SSO
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// RatingParams для оценки песни от 1 до 5 звёзд.
+type RatingParams struct {
+	Stars int `json:"stars"`
+}
+
+// SongRating средняя оценка песни и оценка пользователя.
+type SongRating struct {
+	SongID      int32   `json:"songId"`
+	Rating      float64 `json:"rating"`
+	RatingCount int32   `json:"ratingCount"`
+	Stars       int     `json:"stars,omitempty"`
+}
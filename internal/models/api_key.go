@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// APIKeyParams для создания API ключа.
+type APIKeyParams struct {
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	AccountID int32  `json:"accountId,omitempty"` // учётная запись пользователя, от имени которого действует ключ
+}
+
+// APIKey сведения об API ключе без самого ключа.
+type APIKey struct {
+	ID        int32      `json:"id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	Prefix    string     `json:"prefix"`
+	AccountID int32      `json:"accountId,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// CreatedAPIKey ответ на создание API ключа. Ключ возвращается только один раз.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent запись журнала аудита об изменении одной сущности.
+type AuditEvent struct {
+	ID        int64           `json:"id"`
+	Time      time.Time       `json:"time"`
+	Actor     string          `json:"actor"` // user:<sub>, key:<название ключа>, cli:<команда> или system
+	Role      string          `json:"role,omitempty"`
+	APIKeyID  int32           `json:"apiKeyId,omitempty"`
+	AccountID int32           `json:"accountId,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+	Entity    string          `json:"entity"`
+	EntityID  int32           `json:"entityId"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"` // null при создании
+	After     json.RawMessage `json:"after" swaggertype:"object"`  // null при удалении
+}
@@ -0,0 +1,48 @@
+package models
+
+// MergeParams для объединения дубликатов: записи sourceIds вливаются в targetId и удаляются.
+type MergeParams struct {
+	TargetID  int32   `json:"targetId" example:"1"`
+	SourceIDs []int32 `json:"sourceIds" example:"2,3"`
+}
+
+// MergeResult итог объединения.
+type MergeResult struct {
+	TargetID    int32 `json:"targetId"`
+	MovedSongs  int   `json:"movedSongs"`  // песни, перенесённые к группе targetId
+	MergedSongs int   `json:"mergedSongs"` // песни, объединённые с песнями с тем же названием
+}
+
+// Duplicates возможные дубликаты групп и песен, начиная с самых похожих.
+type Duplicates struct {
+	Artists []DuplicateArtists `json:"artists"`
+	Songs   []DuplicateSongs   `json:"songs"`
+}
+
+// DuplicateArtists пара похожих групп. Score от 0 до 1, 1 - названия совпадают
+// без учёта регистра и лишних пробелов.
+type DuplicateArtists struct {
+	Score   float64           `json:"score"`
+	Artists []DuplicateArtist `json:"artists"`
+}
+
+// DuplicateArtist группа, у которой может быть дубликат.
+type DuplicateArtist struct {
+	ID    int32  `json:"id"`
+	Group string `json:"group"`
+	Songs int32  `json:"songs"` // песни в библиотеке, без корзины
+}
+
+// DuplicateSongs пара похожих песен одной группы.
+type DuplicateSongs struct {
+	Score float64         `json:"score"`
+	Songs []DuplicateSong `json:"songs"`
+}
+
+// DuplicateSong песня, у которой может быть дубликат.
+type DuplicateSong struct {
+	ID      int32  `json:"id"`
+	GroupID int32  `json:"groupId"`
+	Group   string `json:"group"`
+	Song    string `json:"song"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// PlaylistParams для создания и изменения плейлиста. Незаполненные поля при изменении
+// не меняются.
+type PlaylistParams struct {
+	Name   *string `json:"name"`
+	Public *bool   `json:"public"`
+}
+
+// PlaylistSongParams для добавления песни в плейлист. Без position песня добавляется в конец.
+type PlaylistSongParams struct {
+	SongID   int32 `json:"songId"`
+	Position *int  `json:"position,omitempty"`
+}
+
+// PlaylistMoveParams для перемещения песни в плейлисте на позицию, начиная с 0.
+type PlaylistMoveParams struct {
+	Position int `json:"position"`
+}
+
+// Playlist плейлист пользователя.
+type Playlist struct {
+	ID        int32           `json:"id"`
+	Name      string          `json:"name"`
+	Public    bool            `json:"public"`
+	ShareURL  string          `json:"shareUrl,omitempty"` // ссылка для доступа без учётной записи, только для владельца
+	Version   int32           `json:"version"`            // увеличивается при каждом изменении
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+	Songs     []PlaylistEntry `json:"songs,omitempty"`
+}
+
+// PlaylistEntry песня в плейлисте. Одна песня может встречаться в плейлисте несколько раз,
+// поэтому записи различаются по entryId.
+type PlaylistEntry struct {
+	EntryID  int32     `json:"entryId"`
+	Position int       `json:"position"`
+	SongID   int32     `json:"songId"`
+	Group    string    `json:"group"`
+	Song     string    `json:"song"`
+	AddedAt  time.Time `json:"addedAt"`
+}
@@ -0,0 +1,16 @@
+package models
+
+// SongTags теги песни, например жанр или настроение.
+type SongTags struct {
+	Tags []string `json:"tags"`
+}
+
+// RelatedSong похожая песня и признаки сходства: artist, tags, era и lyrics.
+type RelatedSong struct {
+	ID         int32    `json:"id"`
+	Group      string   `json:"group"`
+	Song       string   `json:"song"`
+	Score      float64  `json:"score"`
+	Reasons    []string `json:"reasons"`
+	SharedTags []string `json:"sharedTags,omitempty"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // AddParams для получения данных и добавления песни.
 type AddParams struct {
 	Group string `json:"group,omitempty"`
@@ -13,3 +15,28 @@ type SongDetail struct {
 	Text        string `json:"text,omitempty"`
 	Link        string `json:"link,omitempty"`
 }
+
+// ExportSong для выгрузки песни вместе с названием группы.
+type ExportSong struct {
+	ID          int32  `json:"id"`
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// AudioInfo сведения об аудиофайле песни.
+type AudioInfo struct {
+	SongID      int32     `json:"songId"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	UploadedAt  time.Time `json:"uploadedAt"`
+}
+
+// CoverURLs адреса обложки песни и её уменьшенных копий по размерам в пикселях.
+type CoverURLs struct {
+	Original   string            `json:"original"`
+	Thumbnails map[string]string `json:"thumbnails,omitempty"`
+}
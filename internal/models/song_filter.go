@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// SongFilter для фильтрации списка песен по группе, названию, дате релиза и тексту.
+// Пустые поля не участвуют в фильтрации.
+type SongFilter struct {
+	Group       string
+	Song        string
+	ReleaseDate time.Time
+	Text        string
+}
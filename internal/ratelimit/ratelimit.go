@@ -0,0 +1,146 @@
+// Package ratelimit ограничивает частоту запросов клиентов алгоритмом "token bucket".
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// sweepInterval как часто удаляются корзины неактивных клиентов.
+const sweepInterval = time.Minute
+
+// Decision результат проверки запроса.
+type Decision struct {
+	Allowed    bool          // запрос разрешён
+	Limit      int           // размер корзины, максимальное число запросов подряд
+	Remaining  int           // число запросов, доступных сразу после этого
+	Reset      time.Duration // время до полного восстановления корзины
+	RetryAfter time.Duration // время до следующего доступного запроса, если запрос отклонён
+}
+
+// Limiter хранит отдельную корзину для каждого клиента. Корзина пополняется на rps
+// запросов в секунду и вмещает не больше burst запросов. При rps <= 0 запросы не ограничиваются.
+type Limiter struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	rps       rate.Limit
+	burst     int
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New создаёт ограничитель. При rps <= 0 ограничение отключено, пока его не включит SetLimit.
+func New(rps float64, burst int) *Limiter {
+	l := &Limiter{
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+	l.setLimit(rps, burst)
+	return l
+}
+
+// SetLimit меняет лимит без сброса корзин клиентов: накопленные запросы сохраняются,
+// но не больше нового burst. При rps <= 0 ограничение отключается.
+func (l *Limiter) SetLimit(rps float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.setLimit(rps, burst)
+	if l.rps <= 0 {
+		clear(l.buckets)
+		return
+	}
+
+	now := l.now()
+	for _, b := range l.buckets {
+		b.limiter.SetLimitAt(now, l.rps)
+		b.limiter.SetBurstAt(now, l.burst)
+	}
+}
+
+func (l *Limiter) setLimit(rps float64, burst int) {
+	if rps <= 0 {
+		l.rps, l.burst = 0, 0
+		return
+	}
+	l.rps, l.burst = rate.Limit(rps), max(burst, 1)
+}
+
+// WithClock заменяет источник времени, используется в тестах.
+func (l *Limiter) WithClock(now func() time.Time) *Limiter {
+	l.now = now
+	return l
+}
+
+// Allow расходует один запрос из корзины клиента key. Без ограничения запрос
+// разрешается, а Limit равен 0.
+func (l *Limiter) Allow(key string) Decision {
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rps <= 0 {
+		return Decision{Allowed: true}
+	}
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.rps, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	d := Decision{Limit: l.burst}
+	d.Allowed = b.limiter.AllowN(now, 1)
+
+	tokens := b.limiter.TokensAt(now)
+	d.Remaining = int(math.Max(0, math.Floor(tokens)))
+	d.Reset = l.duration(float64(l.burst) - tokens)
+	if !d.Allowed {
+		d.RetryAfter = l.duration(1 - tokens)
+	}
+	return d
+}
+
+// duration возвращает время, за которое в корзину поступит tokens запросов.
+func (l *Limiter) duration(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / float64(l.rps) * float64(time.Second))
+}
+
+// sweep удаляет корзины клиентов, которые успели полностью восстановиться:
+// такая корзина ничем не отличается от новой.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	idle := l.duration(float64(l.burst))
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idle {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Len возвращает число отслеживаемых клиентов.
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
@@ -0,0 +1,66 @@
+package recommend
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Loader загружает признаки всех песен библиотеки.
+type Loader func(ctx context.Context) ([]Song, error)
+
+// Cache хранит построенный индекс и перестраивает его после изменения библиотеки
+// или по истечении ttl, если библиотеку изменили в обход сервера.
+type Cache struct {
+	ttl  time.Duration
+	load Loader
+	now  func() time.Time
+
+	mu      sync.Mutex
+	index   *Index
+	builtAt time.Time
+}
+
+// NewCache создаёт кэш индекса. При ttl <= 0 индекс перестраивается только после Invalidate.
+func NewCache(ttl time.Duration, load Loader) *Cache {
+	return &Cache{
+		ttl:  ttl,
+		load: load,
+		now:  time.Now,
+	}
+}
+
+// WithClock заменяет источник времени, используется в тестах.
+func (c *Cache) WithClock(now func() time.Time) *Cache {
+	c.now = now
+	return c
+}
+
+// Index возвращает актуальный индекс, при необходимости строит его заново.
+// Одновременные запросы ждут одного построения.
+func (c *Cache) Index(ctx context.Context) (*Index, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.index != nil && (c.ttl <= 0 || c.now().Sub(c.builtAt) < c.ttl) {
+		return c.index, nil
+	}
+
+	songs, err := c.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.index = Build(songs)
+	c.builtAt = c.now()
+	return c.index, nil
+}
+
+// Invalidate сбрасывает индекс, следующий запрос построит его заново.
+func (c *Cache) Invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.index = nil
+	c.mu.Unlock()
+}
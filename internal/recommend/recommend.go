@@ -0,0 +1,267 @@
+// Package recommend подбирает похожие песни по исполнителю, тегам, времени выпуска
+// и сходству текстов (TF-IDF). Индекс строится в памяти по всей библиотеке.
+package recommend
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Веса признаков сходства, в сумме 1.
+const (
+	weightLyrics = 0.5
+	weightArtist = 0.2
+	weightTags   = 0.2
+	weightEra    = 0.1
+)
+
+const (
+	// eraYears разница в годах, при которой сходство по времени выпуска становится нулевым.
+	eraYears = 10
+	// minTokenLen минимальная длина слова текста в символах, более короткие слова не учитываются.
+	minTokenLen = 3
+	// minLyricsScore минимальное сходство текстов, при котором оно указывается в причинах.
+	minLyricsScore = 0.05
+	// maxRelated число похожих песен, которое запоминается для каждой песни.
+	maxRelated = 50
+)
+
+// Причины, по которым песня считается похожей.
+const (
+	ReasonArtist = "artist"
+	ReasonTags   = "tags"
+	ReasonEra    = "era"
+	ReasonLyrics = "lyrics"
+)
+
+// Song признаки песни для построения индекса.
+type Song struct {
+	ID          int32
+	GroupID     int32
+	Group       string
+	Song        string
+	ReleaseDate time.Time
+	Text        string
+	Tags        []string
+}
+
+// Match похожая песня.
+type Match struct {
+	Song       *Song
+	Score      float64  // сходство от 0 до 1
+	Reasons    []string // признаки, по которым песни похожи
+	SharedTags []string // общие теги
+}
+
+// term вес слова в векторе TF-IDF.
+type term struct {
+	id     int
+	weight float64
+}
+
+// Index индекс похожих песен. Безопасен для одновременного использования.
+type Index struct {
+	songs   []Song
+	byID    map[int32]int
+	vectors [][]term
+	tags    []map[string]struct{}
+
+	mu      sync.Mutex
+	related map[int32][]Match
+}
+
+// Build строит индекс по песням библиотеки.
+func Build(songs []Song) *Index {
+	idx := &Index{
+		songs:   songs,
+		byID:    make(map[int32]int, len(songs)),
+		vectors: make([][]term, len(songs)),
+		tags:    make([]map[string]struct{}, len(songs)),
+		related: make(map[int32][]Match),
+	}
+
+	// Считаем число вхождений слов в каждый текст и число текстов с каждым словом.
+	dict := make(map[string]int)
+	counts := make([]map[int]int, len(songs))
+	var df []int
+	for i, s := range songs {
+		idx.byID[s.ID] = i
+
+		idx.tags[i] = make(map[string]struct{}, len(s.Tags))
+		for _, t := range s.Tags {
+			idx.tags[i][t] = struct{}{}
+		}
+
+		counts[i] = make(map[int]int)
+		for _, tok := range Tokenize(s.Text) {
+			id, ok := dict[tok]
+			if !ok {
+				id = len(dict)
+				dict[tok] = id
+				df = append(df, 0)
+			}
+			if counts[i][id] == 0 {
+				df[id]++
+			}
+			counts[i][id]++
+		}
+	}
+
+	// Слова, которые есть во всех текстах, получают нулевой вес и не влияют на сходство.
+	n := float64(len(songs))
+	for i, c := range counts {
+		vec := make([]term, 0, len(c))
+		var norm float64
+		for id, cnt := range c {
+			w := (1 + math.Log(float64(cnt))) * math.Log(n/float64(df[id]))
+			if w <= 0 {
+				continue
+			}
+			vec = append(vec, term{id: id, weight: w})
+			norm += w * w
+		}
+		norm = math.Sqrt(norm)
+		for j := range vec {
+			vec[j].weight /= norm
+		}
+		sort.Slice(vec, func(a, b int) bool { return vec[a].id < vec[b].id })
+		idx.vectors[i] = vec
+	}
+
+	return idx
+}
+
+// Len возвращает число песен в индексе.
+func (idx *Index) Len() int {
+	return len(idx.songs)
+}
+
+// Related возвращает не больше limit песен, похожих на песню id, по убыванию сходства.
+// Если песни нет в индексе, ok == false.
+func (idx *Index) Related(id int32, limit int) (matches []Match, ok bool) {
+	i, ok := idx.byID[id]
+	if !ok {
+		return nil, false
+	}
+
+	idx.mu.Lock()
+	matches, cached := idx.related[id]
+	if !cached {
+		matches = idx.rank(i)
+		idx.related[id] = matches
+	}
+	idx.mu.Unlock()
+
+	if limit >= 0 && limit < len(matches) {
+		matches = matches[:limit]
+	}
+	return matches, true
+}
+
+// rank сравнивает песню i со всеми остальными и возвращает лучшие maxRelated.
+func (idx *Index) rank(i int) []Match {
+	base := &idx.songs[i]
+
+	var matches []Match
+	for j := range idx.songs {
+		if j == i {
+			continue
+		}
+		other := &idx.songs[j]
+
+		var (
+			m     = Match{Song: other}
+			score float64
+		)
+		if other.GroupID == base.GroupID {
+			score += weightArtist
+			m.Reasons = append(m.Reasons, ReasonArtist)
+		}
+		if m.SharedTags = idx.sharedTags(i, j); len(m.SharedTags) > 0 {
+			union := len(idx.tags[i]) + len(idx.tags[j]) - len(m.SharedTags)
+			score += weightTags * float64(len(m.SharedTags)) / float64(union)
+			m.Reasons = append(m.Reasons, ReasonTags)
+		}
+		if era := eraSimilarity(base.ReleaseDate, other.ReleaseDate); era > 0 {
+			score += weightEra * era
+			m.Reasons = append(m.Reasons, ReasonEra)
+		}
+		if lyrics := cosine(idx.vectors[i], idx.vectors[j]); lyrics > 0 {
+			score += weightLyrics * lyrics
+			if lyrics >= minLyricsScore {
+				m.Reasons = append(m.Reasons, ReasonLyrics)
+			}
+		}
+
+		if m.Score = math.Round(score*1000) / 1000; m.Score <= 0 {
+			continue
+		}
+		matches = append(matches, m)
+	}
+
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].Score != matches[b].Score {
+			return matches[a].Score > matches[b].Score
+		}
+		return matches[a].Song.ID < matches[b].Song.ID
+	})
+	if len(matches) > maxRelated {
+		matches = matches[:maxRelated]
+	}
+	return matches
+}
+
+// sharedTags возвращает общие теги песен i и j в алфавитном порядке.
+func (idx *Index) sharedTags(i, j int) []string {
+	var shared []string
+	for t := range idx.tags[i] {
+		if _, ok := idx.tags[j][t]; ok {
+			shared = append(shared, t)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// eraSimilarity сходство по времени выпуска: 1 для одного дня, 0 при разнице от eraYears лет.
+func eraSimilarity(a, b time.Time) float64 {
+	years := math.Abs(a.Sub(b).Hours()) / 24 / 365.25
+	return math.Max(0, 1-years/eraYears)
+}
+
+// cosine скалярное произведение нормированных векторов, отсортированных по id слова.
+func cosine(a, b []term) float64 {
+	var sum float64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].id < b[j].id:
+			i++
+		case a[i].id > b[j].id:
+			j++
+		default:
+			sum += a[i].weight * b[j].weight
+			i++
+			j++
+		}
+	}
+	return sum
+}
+
+// Tokenize разбивает текст на слова в нижнем регистре, пропуская слова короче minTokenLen символов.
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	tokens := words[:0]
+	for _, w := range words {
+		if w = strings.Trim(w, "'"); len([]rune(w)) >= minTokenLen {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,166 @@
+// Package reload перезагружает настройки работающего сервера по SIGHUP и при изменении
+// файлов настроек. Применяются только настройки, которые меняются без перезапуска.
+package reload
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce пауза после последнего изменения файла перед перезагрузкой:
+// редакторы и Kubernetes записывают файл за несколько событий.
+const DefaultDebounce = 500 * time.Millisecond
+
+// Источники перезагрузки для журнала.
+const (
+	TriggerSignal = "SIGHUP"
+	TriggerFile   = "file"
+)
+
+// Reloader загружает настройки заново и применяет изменения к действующим настройкам.
+type Reloader struct {
+	opts     config.Options
+	live     *config.Live
+	debounce time.Duration
+
+	mu    sync.Mutex // перезагрузки выполняются по очереди
+	hooks []func(config.Config)
+}
+
+// New создаёт Reloader, который загружает настройки из источников opts в live.
+func New(opts config.Options, live *config.Live) *Reloader {
+	return &Reloader{opts: opts, live: live, debounce: DefaultDebounce}
+}
+
+// WithDebounce меняет паузу перед перезагрузкой после изменения файла, используется в тестах.
+func (rl *Reloader) WithDebounce(d time.Duration) *Reloader {
+	rl.debounce = d
+	return rl
+}
+
+// OnReload добавляет функцию, которая применяет новые настройки к компонентам сервера,
+// например к ограничителям частоты. Вызывается после каждой перезагрузки с изменениями.
+func (rl *Reloader) OnReload(fn func(config.Config)) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.hooks = append(rl.hooks, fn)
+}
+
+// Reload загружает настройки и применяет изменившиеся перезагружаемые значения. При ошибке
+// загрузки или проверки настроек действующие настройки не меняются.
+func (rl *Reloader) Reload(ctx context.Context, trigger string) ([]config.Change, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	log := logger.Ctx(ctx).With(logger.String("trigger", trigger))
+
+	next, err := config.Load(rl.opts)
+	if err != nil {
+		log.Error("configuration reload failed, keeping the current configuration", logger.Err(err))
+		return nil, err
+	}
+
+	changes, restart := rl.live.Update(next)
+	if len(restart) > 0 {
+		log.Warn("configuration changes require a restart", logger.Any("keys", restart))
+	}
+	if len(changes) == 0 {
+		log.Info("configuration reloaded, nothing changed")
+		return nil, nil
+	}
+
+	// Записываем изменения до применения: новый LOG_LEVEL может скрыть запись уровня INFO.
+	described := make([]string, len(changes))
+	for i, c := range changes {
+		described[i] = fmt.Sprintf("%s: %s -> %s", c.Key, c.Old, c.New)
+	}
+	log.Info("configuration reloaded", logger.Any("changes", described))
+
+	cur := rl.live.Load()
+	for _, fn := range rl.hooks {
+		fn(cur)
+	}
+	return changes, nil
+}
+
+// Watch перезагружает настройки по SIGHUP и при изменении файла .env или файла настроек,
+// пока не отменён ctx. Следит за папками файлов, а не за самими файлами, чтобы замечать
+// замену файла переименованием.
+func (rl *Reloader) Watch(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config files: %w", err)
+	}
+	defer watcher.Close()
+
+	files := rl.files()
+	for dir := range dirs(files) {
+		if errAdd := watcher.Add(dir); errAdd != nil {
+			return fmt.Errorf("failed to watch %s: %w", dir, errAdd)
+		}
+	}
+
+	// Таймер запускается событием файла и перезапускается каждым следующим.
+	timer := time.NewTimer(rl.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			// Ошибку записывает в журнал Reload.
+			_, _ = rl.Reload(ctx, TriggerSignal)
+		case ev, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if files[filepath.Clean(ev.Name)] && !ev.Has(fsnotify.Chmod) {
+				timer.Reset(rl.debounce)
+			}
+		case errWatch, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Ctx(ctx).Error("config file watch error", logger.Err(errWatch))
+		case <-timer.C:
+			_, _ = rl.Reload(ctx, TriggerFile)
+		}
+	}
+}
+
+// files возвращает пути файлов настроек, за которыми нужно следить.
+func (rl *Reloader) files() map[string]bool {
+	files := map[string]bool{filepath.Clean(filepath.Join(rl.opts.Dir, ".env")): true}
+	if rl.opts.File != "" {
+		files[filepath.Clean(rl.opts.File)] = true
+	}
+	return files
+}
+
+// dirs возвращает существующие папки files.
+func dirs(files map[string]bool) map[string]bool {
+	res := make(map[string]bool)
+	for f := range files {
+		dir := filepath.Dir(f)
+		if _, err := os.Stat(dir); err == nil {
+			res[dir] = true
+		}
+	}
+	return res
+}
@@ -0,0 +1,79 @@
+// Package requestid присваивает запросам ID для связи записей журнала одного запроса
+// между собой и с журналами клиента и внешнего API.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header заголовок с ID запроса во входящих запросах, ответах и запросах во внешний API.
+const Header = "X-Request-ID"
+
+// maxLen максимальная длина ID, полученного от клиента.
+const maxLen = 128
+
+type ctxKey struct{}
+
+// New создаёт случайный ID из 32 шестнадцатеричных символов.
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err) // crypto/rand не возвращает ошибок на поддерживаемых платформах
+	}
+	return hex.EncodeToString(b)
+}
+
+// NewContext возвращает контекст с ID запроса.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext возвращает ID запроса из контекста, "" если его нет.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// Valid проверяет ID, полученный от клиента: непустая строка до 128 символов из букв
+// латиницы, цифр и знаков "-", "_", ".", ":", чтобы ID нельзя было использовать для
+// подделки записей журнала.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Middleware (middleware) берёт ID запроса из заголовка X-Request-ID или создаёт новый,
+// возвращает его в ответе и добавляет в записи журнала logger.Ctx вместе с ID трассировки.
+func Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !Valid(id) {
+			id = New()
+		}
+		w.Header().Set(Header, id)
+
+		ctx := NewContext(r.Context(), id)
+		fields := []logger.Field{logger.String("request_id", id)}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			fields = append(fields, logger.String("trace_id", sc.TraceID().String()))
+		}
+
+		h.ServeHTTP(w, r.WithContext(logger.WithFields(ctx, fields...)))
+	})
+}
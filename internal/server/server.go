@@ -4,92 +4,245 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"fmt"
 
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/breaker"
 	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/database"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/dedupe"
 	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/health"
 	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/metrics"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/migration"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/ratelimit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/reload"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/requestid"
 	srv "github.com/Ra1nz0r/effective_mobile-1/internal/services"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/songbook"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/storage"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/tracing"
 	"github.com/go-chi/chi/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-// Run запускает сервер.
-func Run() {
-	// Загружаем переменные окружения из '.env' файла.
-	cfg, errLoad := config.LoadConfig(".")
-	if errLoad != nil {
-		log.Fatal(fmt.Errorf("unable to load config: %w", errLoad))
-	}
+// serviceName имя сервиса в трассировках.
+const serviceName = "music-library"
 
-	// Инициализируем логгер, с указанием уровня логирования.
-	if errLog := logger.Initialize(cfg.LogLevel); errLog != nil {
-		log.Fatal(fmt.Errorf("failed to initialize the logger: %w", errLog))
+// Run запускает сервер с настройками cfg и останавливает его по SIGINT или SIGTERM.
+// По SIGHUP и при изменении файлов настроек из opts перезагружает настройки,
+// которые меняются без перезапуска.
+func Run(cfg config.Config, opts config.Options) {
+	// Настраиваем трассировку OpenTelemetry.
+	shutdownTracing, errTracing := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.TracingOTLPEndpoint,
+		OTLPInsecure: cfg.TracingOTLPInsecure,
+		ServiceName:  serviceName,
+		SampleRatio:  cfg.TracingSampleRatio,
+	})
+	if errTracing != nil {
+		logger.Zap.Fatal(fmt.Errorf("failed to configure tracing: %w", errTracing))
 	}
 
 	// Конфигурируем путь для подключения к PostgreSQL.
-	dbURL := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s?sslmode=disable",
-		cfg.DatabaseUser,
-		cfg.DatabasePassword,
-		cfg.DatabaseHost,
-		cfg.DatabasePort,
-		cfg.DatabaseName,
-	)
+	dbURL := cfg.DatabaseURL()
 
 	logger.Zap.Debug("Connecting to the database.")
-	// Открываем подключение к базе данных.
-	connect, errConn := sql.Open(cfg.DatabaseDriver, dbURL)
+	// Открываем пул соединений и ждём базу данных, если она ещё запускается.
+	connect, errConn := database.Connect(context.Background(), cfg)
 	if errConn != nil {
-		logger.Zap.Fatal(fmt.Errorf("unable to create connection to database: %w", errConn))
+		logger.Zap.Fatal(errConn)
 	}
 
 	// Передаём подключение и настройки приложения нашим обработчикам.
 	queries := hd.NewHandlerQueries(connect, cfg)
 
-	logger.Zap.Debug("Checking the existence of a TABLE in the database.")
-	// Проверяем существование TABLE в базе данных.
-	exists, errExs := srv.TableExists(connect, cfg.DatabaseName)
-	if errExs != nil {
-		logger.Zap.Fatal(fmt.Errorf("failed to check if TABLE exists: %w", errExs))
+	// Загружаем шаблоны песенника, переопределённые оператором, либо встроенные.
+	book, errBook := songbook.NewRenderer(cfg.SongbookTemplatesPath)
+	if errBook != nil {
+		logger.Zap.Fatal(fmt.Errorf("failed to load songbook templates: %w", errBook))
 	}
+	queries.Songbook = book
 
-	// Создаём TABLE, если он не существует.
-	if !exists {
-		logger.Zap.Debug(fmt.Sprintf("Creating TABLE in '%s' database.", cfg.DatabaseName))
-		if errRunMigr := srv.RunMigrations(dbURL, cfg.MigrationPath); errRunMigr != nil {
-			logger.Zap.Fatal(fmt.Errorf("failed to run migrations: %w", errConn))
+	// Открываем локальное хранилище аудиофайлов и обложек.
+	blobs, errBlobs := storage.NewLocalStore(cfg.StoragePath)
+	if errBlobs != nil {
+		logger.Zap.Fatal(fmt.Errorf("failed to open blob storage: %w", errBlobs))
+	}
+	queries.Blobs = blobs
+
+	// Подключаем проверку JWT от SSO, если указан набор ключей.
+	if cfg.JWTJWKS != "" {
+		jwtValidator, errJWT := newJWTValidator(cfg)
+		if errJWT != nil {
+			logger.Zap.Fatal(fmt.Errorf("failed to configure JWT validation: %w", errJWT))
 		}
+		queries.JWT = jwtValidator
 	}
 
+	// Проверяем версию схемы и при AUTO_MIGRATE применяем недостающие миграции.
+	if errMigr := checkMigrations(cfg, dbURL, connect); errMigr != nil {
+		logger.Zap.Fatal(errMigr)
+	}
+
+	// Приостанавливаем запросы во внешний API после нескольких ошибок подряд.
+	srv.ExternalAPIBreaker = breaker.New(cfg.ExternalAPIFailures, cfg.ExternalAPICooldown)
+
+	// Проверки зависимостей для /readyz.
+	checker := health.New(cfg.HealthCheckTimeout)
+	checker.Register("database", true, health.Database(connect))
+	checker.Register("migrations", true, health.Migrations(connect, cfg.MigrationPath))
+	checker.Register("external_api", false, health.Circuit(srv.ExternalAPIBreaker))
+	queries.Health = checker
+
 	logger.Zap.Debug("Running handlers.")
 
+	// Учитываем статистику пула соединений с базой данных в метриках.
+	if errMetrics := metrics.RegisterDB(connect, cfg.DatabaseName); errMetrics != nil {
+		logger.Zap.Fatal(fmt.Errorf("failed to register database metrics: %w", errMetrics))
+	}
+
 	// Создаём router и endpoints.
 	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Use(requestid.Middleware)
+	r.Use(metrics.Middleware)
+	r.Use(queries.AccessLog)
 
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("doc.json"),
 	))
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/healthz", queries.Liveness)
+	r.Get("/readyz", queries.Readiness)
+
+	// Ограничиваем частоту запросов отдельно для чтения и изменения библиотеки,
+	// а до проверки ключа - общее число запросов с одного IP.
+	readLimit := ratelimit.New(cfg.RateLimitReadRPS, cfg.RateLimitReadBurst)
+	writeLimit := ratelimit.New(cfg.RateLimitWriteRPS, cfg.RateLimitWriteBurst)
+	ipLimit := ratelimit.New(cfg.RateLimitIPRPS, cfg.RateLimitIPBurst)
+
+	// Применяем перезагруженные настройки к работающим компонентам.
+	reloader := reload.New(opts, queries.Live)
+	reloader.OnReload(func(c config.Config) {
+		if errLevel := logger.SetLevel(c.LogLevel); errLevel != nil {
+			logger.Zap.Error(errLevel)
+		}
+		readLimit.SetLimit(c.RateLimitReadRPS, c.RateLimitReadBurst)
+		writeLimit.SetLimit(c.RateLimitWriteRPS, c.RateLimitWriteBurst)
+		ipLimit.SetLimit(c.RateLimitIPRPS, c.RateLimitIPBurst)
+		checker.SetTimeout(c.HealthCheckTimeout)
+		database.Configure(connect, database.Pool(c))
+	})
+
+	// Запросы к API с одного IP ограничиваются до проверки ключа, чтобы подбор ключей
+	// и запросы без ключа не нагружали базу данных.
+	api := r.With(queries.RateLimitIP(ipLimit))
 
-	r.Group(func(r chi.Router) { // исправить эндпойнты на другие
-		r.Use(queries.WithRequestDetails)
+	api.Group(func(r chi.Router) { // исправить эндпойнты на другие
+		r.Use(queries.RequireRole(auth.RoleEditor))
+		r.Use(queries.RateLimit(writeLimit))
 
 		r.Delete("/library/delete", queries.DeleteSong)
+		r.Post("/library/restore", queries.RestoreSong)
 		r.Post("/library/add", queries.AddSongInLibrary)
 		r.Put("/library/update", queries.UpdateSong)
+		r.Post("/library/import", queries.ImportSongs)
+		r.Put("/song/audio", queries.UploadSongAudio)
+		r.Delete("/song/audio", queries.DeleteSongAudio)
+		r.Put("/song/cover", queries.UploadSongCover)
+		r.Delete("/song/cover", queries.DeleteSongCover)
+		r.Put("/song/tags", queries.SetSongTags)
+		r.Post("/library/merge/artists", queries.MergeArtists)
+		r.Post("/library/merge/songs", queries.MergeSongs)
+	})
+
+	// Корзину и возможные дубликаты видят только те, кто может удалять, восстанавливать
+	// и объединять песни.
+	api.Group(func(r chi.Router) {
+		r.Use(queries.RequireRole(auth.RoleEditor))
+		r.Use(queries.RateLimit(readLimit))
+
+		r.Get("/library/trash", queries.ShowTrash)
+		r.Get("/library/duplicates", queries.ShowDuplicates)
 	})
 
-	r.Group(func(r chi.Router) {
-		r.Use(queries.WithResponseDetails)
+	api.Group(func(r chi.Router) {
+		r.Use(queries.RequireRole(auth.RoleReader))
+		r.Use(queries.RateLimit(readLimit))
 
 		r.Get("/library/list", queries.ListSongsWithFilters)
+		r.Get("/library/export", queries.ExportSongs)
+		r.Get("/library/playlist", queries.ExportPlaylist)
+		r.Get("/library/songbook", queries.GenerateSongbook)
 		r.Get("/song/couplet", queries.TextSongWithPagination)
+		r.Get("/song/audio", queries.StreamSongAudio)
+		r.Head("/song/audio", queries.StreamSongAudio)
+		r.Get("/song/cover", queries.GetSongCover)
+		r.Head("/song/cover", queries.GetSongCover)
+		r.Get("/song/tags", queries.ShowSongTags)
+		r.Get("/song/related", queries.RelatedSongs)
+		r.Get("/playlists", queries.ShowPlaylists)
+		r.Get("/playlist", queries.ShowPlaylist)
+		r.Get("/stats/top", queries.TopChart)
+		r.Get("/user/history", queries.ShowPlayHistory)
+	})
+
+	api.Group(func(r chi.Router) {
+		r.Use(queries.RequireRole(auth.RoleAdmin))
+		r.Use(queries.RateLimit(writeLimit))
+
+		r.Post("/auth/keys", queries.AddAPIKey)
+		r.Get("/auth/keys", queries.ShowAPIKeys)
+		r.Delete("/auth/keys", queries.DeleteAPIKey)
+		r.Post("/users", queries.AddUserAccount)
+		r.Get("/users", queries.ShowUserAccounts)
+	})
+
+	// Журнал аудита только читается, поэтому к нему применяется ограничение на чтение.
+	api.Group(func(r chi.Router) {
+		r.Use(queries.RequireRole(auth.RoleAdmin))
+		r.Use(queries.RateLimit(readLimit))
+
+		r.Get("/audit", queries.ShowAuditLog)
+	})
+
+	// Прослушивания, избранное, оценки и плейлисты доступны любому пользователю, но учитываются
+	// как изменения.
+	api.Group(func(r chi.Router) {
+		r.Use(queries.RequireRole(auth.RoleReader))
+		r.Use(queries.RateLimit(writeLimit))
+
+		r.Post("/song/play", queries.RecordSongPlay)
+		r.Put("/song/favorite", queries.FavoriteSong)
+		r.Delete("/song/favorite", queries.UnfavoriteSong)
+		r.Put("/song/rating", queries.RateSong)
+		r.Delete("/song/rating", queries.UnrateSong)
+		r.Post("/playlists", queries.AddPlaylist)
+		r.Patch("/playlist", queries.EditPlaylist)
+		r.Delete("/playlist", queries.RemovePlaylist)
+		r.Post("/playlist/songs", queries.AddPlaylistSong)
+		r.Patch("/playlist/songs", queries.MovePlaylistSong)
+		r.Delete("/playlist/songs", queries.RemovePlaylistSong)
+		r.Post("/playlist/share", queries.SharePlaylist)
+		r.Delete("/playlist/share", queries.UnsharePlaylist)
+	})
+
+	// Плейлист по ссылке доступен без API ключа, клиент определяется по IP адресу.
+	api.Group(func(r chi.Router) {
+		r.Use(queries.RateLimit(readLimit))
+
+		r.Get("/shared/playlist", queries.ShowSharedPlaylist)
 	})
 
 	logger.Zap.Debug("Configuring and starting the server.")
@@ -104,6 +257,8 @@ func Run() {
 
 	logger.Zap.Info(fmt.Sprintf("Server is running on: '%s'", cfg.ServerHost))
 
+	checker.SetReady(true)
+
 	go func() {
 		if errListn := srv.ListenAndServe(); !errors.Is(errListn, http.ErrServerClosed) {
 			logger.Zap.Fatal(fmt.Errorf("HTTP server error: %w", errListn))
@@ -111,9 +266,25 @@ func Run() {
 		logger.Zap.Info("Stopped serving new connections.")
 	}()
 
+	// Фоновые задачи останавливаются вместе с сервером.
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	go func() {
+		if errWatch := reloader.Watch(bgCtx); errWatch != nil {
+			logger.Zap.Error(fmt.Errorf("configuration reload is unavailable: %w", errWatch))
+		}
+	}()
+	go database.LogStats(bgCtx, connect, cfg.DatabaseStatsInterval)
+	go purgeTrash(bgCtx, queries, cfg.TrashPurgeInterval)
+
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	<-sigChan
+	stopBackground()
+
+	// Снимаем готовность и даём балансировщику время перестать направлять запросы.
+	checker.SetReady(false)
+	logger.Zap.Info("Readiness is off, draining connections.")
+	time.Sleep(queries.Live.Load().ShutdownDrainDelay)
 
 	shutdownCtx, shutdownRelease := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownRelease()
@@ -121,5 +292,114 @@ func Run() {
 	if errShut := srv.Shutdown(shutdownCtx); errShut != nil {
 		logger.Zap.Fatal(fmt.Errorf("HTTP shutdown error: %w", errShut))
 	}
+
+	// Отправляем накопленные spans до завершения процесса.
+	if errShut := shutdownTracing(shutdownCtx); errShut != nil {
+		logger.Zap.Error(fmt.Errorf("tracing shutdown error: %w", errShut))
+	}
 	logger.Zap.Info("Graceful shutdown complete.")
 }
+
+// checkMigrations сообщает версию схемы базы данных. При cfg.AutoMigrate применяет
+// недостающие миграции, иначе сервер запускается, но /readyz отвечает 503, пока схему
+// не обновят командой migrate up. Со схемой после прерванной миграции сервер не запускается.
+// На актуальной схеме заполняет ключи названий, которые не заполнила миграция.
+func checkMigrations(cfg config.Config, dbURL string, connect *sql.DB) (err error) {
+	mg, err := migration.New(cfg.MigrationPath, dbURL)
+	if err != nil {
+		return fmt.Errorf("failed to open migrations: %w", err)
+	}
+	defer func() {
+		if errClose := mg.Close(); errClose != nil && err == nil {
+			err = fmt.Errorf("failed to close migrations: %w", errClose)
+		}
+	}()
+
+	st, err := mg.Status()
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	logger.Zap.Info(fmt.Sprintf("Database schema version %d, latest migration %d (%s).", st.Version, st.Latest, mg.Source()))
+
+	switch {
+	case st.Dirty:
+		return st.Err()
+	case st.Pending() && cfg.AutoMigrate:
+		mg.SetLogger(func(format string, v ...interface{}) {
+			logger.Zap.Info(strings.TrimSpace(fmt.Sprintf(format, v...)))
+		})
+		if errUp := mg.Up(0); errUp != nil {
+			return fmt.Errorf("failed to run migrations: %w", errUp)
+		}
+		logger.Zap.Info(fmt.Sprintf("Database schema migrated to version %d.", st.Latest))
+	case st.Err() != nil:
+		logger.Zap.Error(st.Err())
+		return nil
+	}
+
+	filled, err := dedupe.FillKeys(context.Background(), db.New(connect))
+	if err != nil {
+		return fmt.Errorf("failed to fill name keys: %w", err)
+	}
+	if filled > 0 {
+		logger.Zap.Info(fmt.Sprintf("Filled %d group and song name keys.", filled))
+	}
+	return nil
+}
+
+// purgeTrash каждые interval окончательно удаляет песни, которые пробыли в корзине
+// дольше TRASH_RETENTION. При interval <= 0 корзина не очищается.
+func purgeTrash(ctx context.Context, queries *hd.HandleQueries, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			retention := queries.Live.Load().TrashRetention
+			purged, err := queries.PurgeTrash(ctx, time.Now().Add(-retention))
+			if err != nil {
+				logger.Ctx(ctx).Error("trash purge failed", logger.Int("purged", purged), logger.Err(err))
+				continue
+			}
+			if purged > 0 {
+				logger.Ctx(ctx).Info("trash purged", logger.Int("purged", purged), logger.Duration("retention", retention))
+			}
+		}
+	}
+}
+
+// newJWTValidator создаёт проверку JWT по настройкам приложения.
+func newJWTValidator(cfg config.Config) (*auth.JWTValidator, error) {
+	mapping, err := auth.ParseRoleMapping(cfg.JWTRoleMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaultRole auth.Role
+	if cfg.JWTDefaultRole != "" {
+		if defaultRole, err = auth.ParseRole(cfg.JWTDefaultRole); err != nil {
+			return nil, fmt.Errorf("invalid JWT_DEFAULT_ROLE: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	return auth.NewJWTValidator(ctx, auth.JWTOptions{
+		JWKS:            cfg.JWTJWKS,
+		Issuer:          cfg.JWTIssuer,
+		Audience:        cfg.JWTAudience,
+		RolesClaim:      cfg.JWTRolesClaim,
+		RoleMapping:     mapping,
+		DefaultRole:     defaultRole,
+		Leeway:          30 * time.Second,
+		RefreshInterval: time.Minute,
+	})
+}
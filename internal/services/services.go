@@ -1,23 +1,37 @@
 package services
 
 import (
-	"database/sql"
+	"context"
 	"encoding/json"
 	"io"
 	"math"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"fmt"
 
+	"github.com/Ra1nz0r/effective_mobile-1/internal/breaker"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/metrics"
 	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
-	"github.com/golang-migrate/migrate/v4"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/requestid"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/tracing"
 )
 
+// externalClient выполняет запросы во внешний API и создаёт для них spans трассировки.
+var externalClient = &http.Client{Transport: tracing.Transport(nil)}
+
+// ExternalAPIBreaker приостанавливает запросы во внешний API, пока он недоступен.
+// Настраивается при запуске сервера, nil - запросы выполняются всегда.
+var ExternalAPIBreaker *breaker.Breaker
+
 // FetchSongDetails делает запрос во внешний API и возвращает полученные сведения.
 // Формат запроса: http://localhost:7777/info?group=nGroup&song=nSong.
-func FetchSongDetails(nGroup, nSong, externalAPIURL string) (*models.SongDetail, error) {
+// Исход и длительность запроса учитываются в метриках, ID запроса и контекст трассировки передаются в API.
+// Пока ExternalAPIBreaker приостановил запросы, сразу возвращается breaker.ErrOpen.
+func FetchSongDetails(ctx context.Context, nGroup, nSong, externalAPIURL string) (*models.SongDetail, error) {
 	// Проверяем и парсим внешний URL
 	parsedURL, err := url.Parse(externalAPIURL)
 	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
@@ -31,13 +45,44 @@ func FetchSongDetails(nGroup, nSong, externalAPIURL string) (*models.SongDetail,
 
 	fullURL := fmt.Sprintf("%s?group=%s&song=%s", parsedURL.String(), url.PathEscape(nGroup), url.PathEscape(nSong))
 
-	resp, errResp := http.Get(fullURL)
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if errReq != nil {
+		return nil, errReq
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+
+	start := time.Now()
+	outcome := metrics.ExternalInvalidResponse
+	defer func() { metrics.ObserveExternalAPI(outcome, time.Since(start)) }()
+
+	if errOpen := ExternalAPIBreaker.Allow(); errOpen != nil {
+		outcome = metrics.ExternalCircuitOpen
+		return nil, errOpen
+	}
+
+	resp, errResp := externalClient.Do(req)
 	if errResp != nil {
+		outcome = metrics.ExternalNetworkError
+		if ctx.Err() != nil {
+			ExternalAPIBreaker.Ignore()
+		} else {
+			ExternalAPIBreaker.Failure()
+		}
 		return nil, errResp
 	}
 	defer resp.Body.Close()
 
+	// Ответы 5xx говорят о сбое API, остальные коды - о том, что сведений о песне нет.
+	if resp.StatusCode >= http.StatusInternalServerError {
+		ExternalAPIBreaker.Failure()
+	} else {
+		ExternalAPIBreaker.Success()
+	}
+
 	if resp.StatusCode != http.StatusOK {
+		outcome = metrics.ExternalBadStatus
 		return nil, fmt.Errorf("API returned status: %s", resp.Status)
 	}
 
@@ -51,42 +96,10 @@ func FetchSongDetails(nGroup, nSong, externalAPIURL string) (*models.SongDetail,
 		return nil, errJSON
 	}
 
+	outcome = metrics.ExternalSuccess
 	return &songDetail, nil
 }
 
-// RunMigrations запускает миграцию Up по указанному пути.
-func RunMigrations(databaseURL, migrationPath string) error {
-	m, err := migrate.New(migrationPath, databaseURL)
-	if err != nil {
-		return err
-	}
-
-	// Применение миграций
-	err = m.Up()
-	if err != nil && err != migrate.ErrNoChange {
-		return err
-	}
-
-	return nil
-}
-
-// TableExists проверяет существование table в базе данных.
-func TableExists(db *sql.DB, tableName string) (bool, error) {
-	var exists bool
-	query := `
-		SELECT EXISTS (
-			SELECT FROM pg_tables
-			WHERE schemaname = 'public' OR schemaname = 'private'
-			AND tablename = $1
-		);`
-	// Используем параметризованный запрос, где $1 — это плейсхолдер для tableName
-	err := db.QueryRow(query, tableName).Scan(&exists)
-	if err != nil {
-		return false, err
-	}
-	return exists, nil
-}
-
 // StringToInt32WithOverflowCheck преобразует строку в int32 с проверкой переполнения
 func StringToInt32WithOverflowCheck(s string) (int32, error) {
 	// Преобразуем строку в int64
@@ -103,3 +116,89 @@ func StringToInt32WithOverflowCheck(s string) (int32, error) {
 	// Возвращаем преобразованное значение
 	return int32(id64), nil
 }
+
+// SplitCouplets разбивает текст песни на куплеты по символу "\n\n".
+func SplitCouplets(text string) []string {
+	return strings.Split(text, "\n\n")
+}
+
+// MoveID переносит id в списке ids на позицию index и возвращает новый порядок.
+// Позиция за пределами списка приводится к его началу или концу.
+func MoveID(ids []int32, id int32, index int) ([]int32, bool) {
+	from := -1
+	for i, v := range ids {
+		if v == id {
+			from = i
+			break
+		}
+	}
+	if from < 0 {
+		return ids, false
+	}
+
+	result := make([]int32, 0, len(ids))
+	result = append(result, ids[:from]...)
+	result = append(result, ids[from+1:]...)
+
+	index = max(0, min(index, len(result)))
+	result = append(result[:index], append([]int32{id}, result[index:]...)...)
+	return result, true
+}
+
+// MoveVisibleID переносит id на позицию index среди видимых записей visible.
+// Скрытые записи из all, которых нет в visible, остаются на своих местах,
+// а видимые записи занимают оставшиеся места в новом порядке.
+func MoveVisibleID(all, visible []int32, id int32, index int) ([]int32, bool) {
+	moved, ok := MoveID(visible, id, index)
+	if !ok {
+		return all, false
+	}
+
+	shown := make(map[int32]bool, len(visible))
+	for _, v := range visible {
+		shown[v] = true
+	}
+
+	result := make([]int32, 0, len(all))
+	next := 0
+	for _, v := range all {
+		if shown[v] && next < len(moved) {
+			result = append(result, moved[next])
+			next++
+			continue
+		}
+		result = append(result, v)
+	}
+	return result, true
+}
+
+// Ограничения тегов песни.
+const (
+	maxSongTags   = 20
+	maxSongTagLen = 40
+)
+
+// NormalizeTags приводит теги к нижнему регистру, убирает пробелы по краям, пустые
+// значения и повторы. Возвращает ошибку, если тегов или символов в теге слишком много.
+func NormalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	result := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.Join(strings.Fields(t), " "))
+		if t == "" {
+			continue
+		}
+		if len([]rune(t)) > maxSongTagLen {
+			return nil, fmt.Errorf("tag '%s' is longer than %d characters", t, maxSongTagLen)
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		result = append(result, t)
+	}
+	if len(result) > maxSongTags {
+		return nil, fmt.Errorf("too many tags, maximum is %d", maxSongTags)
+	}
+	return result, nil
+}
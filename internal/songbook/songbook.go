@@ -0,0 +1,185 @@
+package songbook
+
+import (
+	"embed"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"fmt"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+)
+
+// Имена файлов шаблонов. Оператор может переопределить любой из них,
+// положив файл с тем же именем в папку SONGBOOK_TEMPLATES_PATH.
+const (
+	HTMLTemplateName     = "songbook.html.tmpl"
+	MarkdownTemplateName = "songbook.md.tmpl"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Format формат песенника.
+type Format string
+
+const (
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "md"
+)
+
+// ParseFormat проверяет и возвращает формат песенника. Пустая строка означает HTML.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "", "html":
+		return FormatHTML, nil
+	case "md", "markdown":
+		return FormatMarkdown, nil
+	default:
+		return "", fmt.Errorf("unsupported songbook format: %s", s)
+	}
+}
+
+// ContentType возвращает MIME тип для формата песенника.
+func (f Format) ContentType() string {
+	if f == FormatMarkdown {
+		return "text/markdown; charset=UTF-8"
+	}
+	return "text/html; charset=UTF-8"
+}
+
+// Song песня в песеннике, текст которой разбит на куплеты.
+type Song struct {
+	ID          int32
+	Group       string
+	Title       string
+	ReleaseDate time.Time
+	Link        string
+	Couplets    []string
+}
+
+// Anchor возвращает якорь песни для ссылок из оглавления.
+func (s Song) Anchor() string {
+	return fmt.Sprintf("song-%d", s.ID)
+}
+
+// Book данные, передаваемые в шаблон песенника.
+type Book struct {
+	Title       string
+	GeneratedAt time.Time
+	Songs       []Song
+}
+
+// NewSong создаёт песню для песенника, разбивая текст на куплеты так же,
+// как и при постраничном выводе текста песни.
+func NewSong(id int32, group, title string, releaseDate time.Time, link, text string) Song {
+	var couplets []string
+	if strings.TrimSpace(text) != "" {
+		couplets = services.SplitCouplets(text)
+	}
+
+	return Song{
+		ID:          id,
+		Group:       group,
+		Title:       title,
+		ReleaseDate: releaseDate,
+		Link:        link,
+		Couplets:    couplets,
+	}
+}
+
+// Renderer формирует песенник по шаблонам.
+type Renderer struct {
+	html     *htmltemplate.Template
+	markdown *texttemplate.Template
+}
+
+// NewRenderer загружает шаблоны песенника. Если overrideDir не пуст и содержит файл
+// шаблона, то используется он, иначе встроенный шаблон по умолчанию.
+func NewRenderer(overrideDir string) (*Renderer, error) {
+	htmlSrc, err := readTemplate(overrideDir, HTMLTemplateName)
+	if err != nil {
+		return nil, err
+	}
+
+	mdSrc, err := readTemplate(overrideDir, MarkdownTemplateName)
+	if err != nil {
+		return nil, err
+	}
+
+	html, err := htmltemplate.New(HTMLTemplateName).Funcs(htmltemplate.FuncMap{
+		"date":  formatDate,
+		"lines": lines,
+	}).Parse(htmlSrc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", HTMLTemplateName, err)
+	}
+
+	markdown, err := texttemplate.New(MarkdownTemplateName).Funcs(texttemplate.FuncMap{
+		"date":     formatDate,
+		"lines":    lines,
+		"mdEscape": markdownEscape,
+		"inc":      func(i int) int { return i + 1 },
+	}).Parse(mdSrc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", MarkdownTemplateName, err)
+	}
+
+	return &Renderer{html: html, markdown: markdown}, nil
+}
+
+// Render записывает в w песенник в указанном формате.
+func (r *Renderer) Render(w io.Writer, format Format, book Book) error {
+	if format == FormatMarkdown {
+		return r.markdown.Execute(w, book)
+	}
+	return r.html.Execute(w, book)
+}
+
+// readTemplate читает шаблон из папки оператора, а при его отсутствии из встроенных шаблонов.
+func readTemplate(overrideDir, name string) (string, error) {
+	if overrideDir != "" {
+		data, err := os.ReadFile(filepath.Join(overrideDir, name))
+		if err == nil {
+			return string(data), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+	}
+
+	data, err := fs.ReadFile(defaultTemplates, "templates/"+name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read default template %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// formatDate приводит дату к формату DD.MM.YYYY.
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("02.01.2006")
+}
+
+// lines разбивает куплет на строки.
+func lines(s string) []string {
+	return strings.Split(strings.TrimRight(s, "\n"), "\n")
+}
+
+// markdownEscaper экранирует символы, имеющие особое значение в Markdown.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`,
+)
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"fmt"
+)
+
+// LocalStore хранит объекты в файлах внутри корневой папки.
+type LocalStore struct {
+	root string
+}
+
+// NewLocalStore создаёт LocalStore, при необходимости создавая корневую папку.
+func NewLocalStore(root string) (*LocalStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("storage root path is empty")
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage root: %w", err)
+	}
+	return &LocalStore{root: root}, nil
+}
+
+// Put записывает объект во временный файл и атомарно переименовывает его,
+// поэтому читатели никогда не видят частично записанный объект.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader) (obj Object, err error) {
+	name, err := s.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	if err = os.MkdirAll(filepath.Dir(name), 0o750); err != nil {
+		return Object{}, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return Object{}, fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return Object{}, fmt.Errorf("failed to sync blob: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return Object{}, fmt.Errorf("failed to close blob: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), name); err != nil {
+		return Object{}, fmt.Errorf("failed to store blob: %w", err)
+	}
+
+	return Object{Key: key, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// Open открывает файл объекта.
+func (s *LocalStore) Open(_ context.Context, key string) (io.ReadSeekCloser, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %w", err)
+	}
+	return file, nil
+}
+
+// Delete удаляет файл объекта.
+func (s *LocalStore) Delete(_ context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+// path преобразует ключ в путь к файлу, не позволяя выйти за пределы корневой папки.
+func (s *LocalStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean == "/" || strings.Contains(key, "\\") || clean != "/"+key {
+		return "", fmt.Errorf("invalid blob key: %q", key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)), nil
+}
+
+// contextReader прерывает чтение при отмене контекста.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
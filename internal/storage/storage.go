@@ -0,0 +1,29 @@
+// Package storage хранит двоичные объекты (аудиофайлы, изображения) по ключу.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound возвращается, если объекта с указанным ключом нет в хранилище.
+var ErrNotFound = errors.New("blob not found")
+
+// Object сведения о сохранённом объекте.
+type Object struct {
+	Key    string // ключ объекта в хранилище
+	Size   int64  // размер в байтах
+	SHA256 string // контрольная сумма SHA-256 в шестнадцатеричном виде
+}
+
+// BlobStore хранилище двоичных объектов. Ключи имеют вид "audio/12/1700000000.mp3",
+// части ключа разделяются символом "/".
+type BlobStore interface {
+	// Put сохраняет содержимое r под ключом key, заменяя существующий объект.
+	Put(ctx context.Context, key string, r io.Reader) (Object, error)
+	// Open открывает объект для чтения с возможностью перемещения по нему.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete удаляет объект. Удаление отсутствующего объекта не является ошибкой.
+	Delete(ctx context.Context, key string) error
+}
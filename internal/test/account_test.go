@@ -0,0 +1,319 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expectSong(mock sqlmock.Sqlmock, id int32) {
+	mock.ExpectQuery(`FROM library`).WithArgs(id).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "group_id", "song", "releaseDate", "text", "link", "deleted_at", "song_key"}).
+			AddRow(id, 1, "Supermassive Black Hole", time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC), "", "", nil, "supermassive black hole"))
+}
+
+func TestRateSong(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		principal   auth.Principal
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+		wantRating  *models.SongRating
+	}{
+		{
+			name:      "Rating by a key linked to an account.",
+			body:      `{"stars": 4}`,
+			principal: auth.Principal{KeyID: 3, AccountID: 7, Name: "alice phone", Role: auth.RoleReader},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				expectSong(mock, 21)
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT stars`).WithArgs(int32(7), int32(21)).WillReturnRows(sqlmock.NewRows([]string{"stars"}))
+				mock.ExpectExec(`INSERT INTO rating`).WithArgs(int32(7), int32(21), int16(4)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAuditState(mock, audit.EntityRating, 21, audit.ActionCreate,
+					`null`, `{"accountId":7,"songId":21,"stars":4}`)
+				mock.ExpectCommit()
+				mock.ExpectQuery(`FROM rating`).WithArgs(int32(21)).
+					WillReturnRows(sqlmock.NewRows([]string{"rating", "rating_count"}).AddRow(4.5, 2))
+			},
+			wantStatus: http.StatusOK,
+			wantRating: &models.SongRating{SongID: 21, Rating: 4.5, RatingCount: 2, Stars: 4},
+		},
+		{
+			name:      "SSO user gets an account on first use.",
+			body:      `{"stars": 5}`,
+			principal: auth.Principal{Subject: "sso-42", Name: "bob", Role: auth.RoleReader},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				expectSong(mock, 21)
+				mock.ExpectQuery(`INSERT INTO account`).WithArgs("bob", "sso-42").WillReturnRows(
+					sqlmock.NewRows([]string{"id", "name", "subject", "created_at"}).AddRow(9, "bob", "sso-42", time.Now()))
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT stars`).WithArgs(int32(9), int32(21)).WillReturnRows(sqlmock.NewRows([]string{"stars"}))
+				mock.ExpectExec(`INSERT INTO rating`).WithArgs(int32(9), int32(21), int16(5)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, audit.EntityRating, 21, audit.ActionCreate)
+				mock.ExpectCommit()
+				mock.ExpectQuery(`FROM rating`).WithArgs(int32(21)).
+					WillReturnRows(sqlmock.NewRows([]string{"rating", "rating_count"}).AddRow(5.0, 1))
+			},
+			wantStatus: http.StatusOK,
+			wantRating: &models.SongRating{SongID: 21, Rating: 5, RatingCount: 1, Stars: 5},
+		},
+		{
+			name:      "Repeated rating replaces the previous one.",
+			body:      `{"stars": 2}`,
+			principal: auth.Principal{KeyID: 3, AccountID: 7, Name: "alice phone", Role: auth.RoleReader},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				expectSong(mock, 21)
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT stars`).WithArgs(int32(7), int32(21)).WillReturnRows(sqlmock.NewRows([]string{"stars"}).AddRow(4))
+				mock.ExpectExec(`INSERT INTO rating`).WithArgs(int32(7), int32(21), int16(2)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAuditState(mock, audit.EntityRating, 21, audit.ActionUpdate,
+					`{"accountId":7,"songId":21,"stars":4}`, `{"accountId":7,"songId":21,"stars":2}`)
+				mock.ExpectCommit()
+				mock.ExpectQuery(`FROM rating`).WithArgs(int32(21)).
+					WillReturnRows(sqlmock.NewRows([]string{"rating", "rating_count"}).AddRow(3.0, 2))
+			},
+			wantStatus: http.StatusOK,
+			wantRating: &models.SongRating{SongID: 21, Rating: 3, RatingCount: 2, Stars: 2},
+		},
+		{
+			name:       "Stars out of range.",
+			body:       `{"stars": 6}`,
+			principal:  auth.Principal{KeyID: 3, AccountID: 7, Role: auth.RoleReader},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:      "Key without an account.",
+			body:      `{"stars": 3}`,
+			principal: auth.Principal{Name: "config", Role: auth.RoleAdmin},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				expectSong(mock, 21)
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			if tt.buildEXPECT != nil {
+				tt.buildEXPECT(mock)
+			}
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(http.MethodPut, "/song/rating?id=21", strings.NewReader(tt.body))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			rec := httptest.NewRecorder()
+			queries.RateSong(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantRating != nil {
+				var got models.SongRating
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, *tt.wantRating, got)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestFavoriteSong(t *testing.T) {
+	alice := auth.Principal{KeyID: 3, AccountID: 7, Name: "alice phone", Role: auth.RoleReader}
+
+	tests := []struct {
+		name        string
+		buildEXPECT func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name: "Song is added and recorded in the audit log.",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO favorite`).WithArgs(int32(7), int32(21)).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAuditState(mock, audit.EntityFavorite, 21, audit.ActionCreate, `null`, `{"accountId":7,"songId":21}`)
+			},
+		},
+		{
+			name: "Song is already a favorite.",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`INSERT INTO favorite`).WithArgs(int32(7), int32(21)).WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			expectSong(mock, 21)
+			mock.ExpectBegin()
+			tt.buildEXPECT(mock)
+			mock.ExpectCommit()
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(http.MethodPut, "/song/favorite?id=21", nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), alice))
+			rec := httptest.NewRecorder()
+			queries.FavoriteSong(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUnfavoriteSong(t *testing.T) {
+	alice := auth.Principal{KeyID: 3, AccountID: 7, Name: "alice phone", Role: auth.RoleReader}
+
+	tests := []struct {
+		name        string
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+	}{
+		{
+			name: "Song is removed and recorded in the audit log.",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`DELETE FROM favorite`).WithArgs(int32(7), int32(21)).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAuditState(mock, audit.EntityFavorite, 21, audit.ActionDelete, `{"accountId":7,"songId":21}`, `null`)
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Song is not a favorite.",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`DELETE FROM favorite`).WithArgs(int32(7), int32(21)).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			expectSong(mock, 21)
+			mock.ExpectBegin()
+			tt.buildEXPECT(mock)
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(http.MethodDelete, "/song/favorite?id=21", nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), alice))
+			rec := httptest.NewRecorder()
+			queries.UnfavoriteSong(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUnrateSong(t *testing.T) {
+	alice := auth.Principal{KeyID: 3, AccountID: 7, Name: "alice phone", Role: auth.RoleReader}
+
+	tests := []struct {
+		name        string
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+	}{
+		{
+			name: "Rating is deleted and recorded in the audit log.",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT stars`).WithArgs(int32(7), int32(21)).WillReturnRows(sqlmock.NewRows([]string{"stars"}).AddRow(4))
+				mock.ExpectExec(`DELETE FROM rating`).WithArgs(int32(7), int32(21)).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAuditState(mock, audit.EntityRating, 21, audit.ActionDelete, `{"accountId":7,"songId":21,"stars":4}`, `null`)
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Song is not rated.",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT stars`).WithArgs(int32(7), int32(21)).WillReturnRows(sqlmock.NewRows([]string{"stars"}))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			expectSong(mock, 21)
+			mock.ExpectBegin()
+			tt.buildEXPECT(mock)
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(http.MethodDelete, "/song/rating?id=21", nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), alice))
+			rec := httptest.NewRecorder()
+			queries.UnrateSong(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestListSongsFavoritesAndSort(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	queries := hd.NewHandlerQueries(conn, config.Config{PaginationLimit: 10})
+	send := func(target string, p auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(auth.WithPrincipal(req.Context(), p))
+		rec := httptest.NewRecorder()
+		queries.ListSongsWithFilters(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, send("/library/list?sort=stars", auth.Principal{Role: auth.RoleReader}).Code)
+	assert.Equal(t, http.StatusForbidden, send("/library/list?favorites=true", auth.Principal{Name: "config", Role: auth.RoleAdmin}).Code)
+
+	columns := []string{"id", "group", "song", "releaseDate", "text", "link", "rating", "rating_count"}
+	mock.ExpectQuery(`FROM library`).
+		WithArgs(nil, nil, time.Time{}, nil, int32(10), int32(0), int32(7), true).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(21, "Muse", "Starlight", time.Date(2006, 9, 4, 0, 0, 0, 0, time.UTC), "", "", 4.5, 2))
+	mock.ExpectQuery(`FROM cover`).WillReturnRows(sqlmock.NewRows([]string{"song_id"}))
+
+	rec := send("/library/list?favorites=true&sort=rating", auth.Principal{KeyID: 3, AccountID: 7, Role: auth.RoleReader})
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var songs []map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &songs))
+	require.Len(t, songs, 1)
+	assert.Equal(t, 4.5, songs[0]["rating"])
+	assert.Equal(t, float64(2), songs[0]["rating_count"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
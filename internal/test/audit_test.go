@@ -0,0 +1,270 @@
+package test
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jsonArg совпадает с аргументом запроса, если он содержит тот же JSON без учёта форматирования.
+type jsonArg string
+
+func (a jsonArg) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	if !ok {
+		return false
+	}
+	var got, want interface{}
+	if json.Unmarshal(b, &got) != nil || json.Unmarshal([]byte(a), &want) != nil {
+		return false
+	}
+	return reflect.DeepEqual(got, want)
+}
+
+// expectAudit ожидает запись в журнал аудита о действии action с сущностью entity.
+func expectAudit(mock sqlmock.Sqlmock, entity string, id int32, action string) {
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			entity, id, action, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectAuditState ожидает запись в журнал аудита с состояниями before и after в JSON.
+func expectAuditState(mock sqlmock.Sqlmock, entity string, id int32, action, before, after string) {
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			entity, id, action, jsonArg(before), jsonArg(after)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestAuditRecord(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	song := db.Library{ID: 21, GroupID: 1, Song: "Starlight", ReleaseDate: time.Date(2006, 9, 4, 0, 0, 0, 0, time.UTC)}
+	trashed := song
+	trashed.DeletedAt.Time, trashed.DeletedAt.Valid = deletedAt, true
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		args []driver.Value // исполнитель, роль, ID ключа, ID учётной записи, ID запроса
+	}{
+		{
+			name: "API key.",
+			ctx: requestid.NewContext(auth.WithPrincipal(context.Background(),
+				auth.Principal{KeyID: 5, AccountID: 3, Name: "ci", Role: auth.RoleEditor}), "req-1"),
+			args: []driver.Value{"key:ci", "editor", int64(5), int64(3), "req-1"},
+		},
+		{
+			name: "SSO user.",
+			ctx: auth.WithPrincipal(context.Background(),
+				auth.Principal{AccountID: 3, Subject: "u-42", Name: "Alice", Role: auth.RoleAdmin}),
+			args: []driver.Value{"user:u-42", "admin", nil, int64(3), ""},
+		},
+		{
+			name: "Command line.",
+			ctx:  audit.WithActor(context.Background(), "cli:import"),
+			args: []driver.Value{"cli:import", "", nil, nil, ""},
+		},
+		{
+			name: "Server itself.",
+			ctx:  context.Background(),
+			args: []driver.Value{audit.System, "", nil, nil, ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			args := append(tt.args, "song", int32(21), "delete",
+				jsonArg(`{"id":21,"groupId":1,"song":"Starlight","releaseDate":"04.09.2006"}`),
+				jsonArg(`{"id":21,"groupId":1,"song":"Starlight","releaseDate":"04.09.2006","deletedAt":"2024-05-01T12:00:00Z"}`))
+			mock.ExpectExec(`INSERT INTO audit_log`).WithArgs(args...).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			err = audit.Record(tt.ctx, db.New(conn), audit.Event{
+				Entity:   audit.EntitySong,
+				EntityID: 21,
+				Action:   audit.ActionDelete,
+				Before:   audit.SongState(song),
+				After:    audit.SongState(trashed),
+			})
+			require.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAuditRecordCreate(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Для созданной сущности состояние до изменения записывается как null.
+	mock.ExpectExec(`INSERT INTO audit_log`).
+		WithArgs(audit.System, "", nil, nil, "", "song_tags", int32(21), "create", jsonArg(`null`), jsonArg(`["rock"]`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = audit.Record(context.Background(), db.New(conn), audit.Event{
+		Entity:   audit.EntityTags,
+		EntityID: 21,
+		Action:   audit.ActionCreate,
+		After:    []string{"rock"},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateSongAudit(t *testing.T) {
+	songColumns := []string{"id", "group_id", "song", "releaseDate", "text", "link", "deleted_at", "song_key"}
+	expectRelease := func(mock sqlmock.Sqlmock, release time.Time) {
+		mock.ExpectQuery(`FROM library`).WithArgs(int32(21)).WillReturnRows(
+			sqlmock.NewRows(songColumns).AddRow(21, 1, "Starlight", release, "", "", nil, "starlight"))
+	}
+
+	tests := []struct {
+		name       string
+		auditErr   error
+		wantStatus int
+	}{
+		{
+			name:       "Update is recorded with the song before and after.",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Update is rolled back when it cannot be recorded.",
+			auditErr:   errors.New("audit_log is unavailable"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			mock.ExpectBegin()
+			expectRelease(mock, time.Date(2006, 9, 4, 0, 0, 0, 0, time.UTC))
+			mock.ExpectExec(`UPDATE library`).WillReturnResult(sqlmock.NewResult(0, 1))
+			expectRelease(mock, time.Date(2006, 9, 5, 0, 0, 0, 0, time.UTC))
+			record := mock.ExpectExec(`INSERT INTO audit_log`).
+				WithArgs("key:ci", "editor", int64(5), nil, "req-1", "song", int32(21), "update",
+					jsonArg(`{"id":21,"groupId":1,"song":"Starlight","releaseDate":"04.09.2006"}`),
+					jsonArg(`{"id":21,"groupId":1,"song":"Starlight","releaseDate":"05.09.2006"}`))
+			if tt.auditErr != nil {
+				record.WillReturnError(tt.auditErr)
+				mock.ExpectRollback()
+			} else {
+				record.WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			}
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(http.MethodPut, "/library/update",
+				strings.NewReader(`{"id": 21, "releaseDate": "05.09.2006"}`))
+			ctx := auth.WithPrincipal(req.Context(), auth.Principal{KeyID: 5, Name: "ci", Role: auth.RoleEditor})
+			req = req.WithContext(requestid.NewContext(ctx, "req-1"))
+			rec := httptest.NewRecorder()
+			queries.UpdateSong(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestShowAuditLog(t *testing.T) {
+	columns := []string{"id", "created_at", "actor", "role", "api_key_id", "account_id", "request_id",
+		"entity", "entity_id", "action", "before", "after"}
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		target      string
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:   "Filtered by song and period.",
+			target: "/audit?entity=song&entityId=21&action=update&from=2024-05-01T00:00:00Z&to=2024-05-02T00:00:00Z&limit=5",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM audit_log`).
+					WithArgs("song", int32(21), "update", "", "",
+						time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+						int32(5), int32(0)).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(7, at, "key:ci", "editor", 5, nil, "req-1", "song", 21, "update",
+							`{"id": 21, "text": ""}`, `{"id": 21, "text": "Verse"}`))
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `[{"id":7,"time":"2024-05-01T12:00:00Z","actor":"key:ci","role":"editor","apiKeyId":5,
+				"requestId":"req-1","entity":"song","entityId":21,"action":"update",
+				"before":{"id":21,"text":""},"after":{"id":21,"text":"Verse"}}]`,
+		},
+		{
+			name:   "Without filters.",
+			target: "/audit",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM audit_log`).
+					WithArgs("", int32(0), "", "", "", time.Time{}, sqlmock.AnyArg(), int32(10), int32(0)).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[]`,
+		},
+		{
+			name:       "Invalid time.",
+			target:     "/audit?from=01.05.2024",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Invalid entity ID.",
+			target:     "/audit?entityId=abc",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			if tt.buildEXPECT != nil {
+				tt.buildEXPECT(mock)
+			}
+
+			queries := hd.NewHandlerQueries(conn, config.Config{PaginationLimit: 10})
+
+			rec := httptest.NewRecorder()
+			queries.ShowAuditLog(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, auth.RoleAdmin.Allows(auth.RoleEditor))
+	assert.True(t, auth.RoleEditor.Allows(auth.RoleReader))
+	assert.True(t, auth.RoleReader.Allows(auth.RoleReader))
+	assert.False(t, auth.RoleReader.Allows(auth.RoleEditor))
+	assert.False(t, auth.RoleEditor.Allows(auth.RoleAdmin))
+	assert.False(t, auth.Role("guest").Allows(auth.Role("guest")))
+
+	role, err := auth.ParseRole(" Editor ")
+	require.NoError(t, err)
+	assert.Equal(t, auth.RoleEditor, role)
+
+	_, err = auth.ParseRole("root")
+	assert.ErrorIs(t, err, auth.ErrUnknownRole)
+}
+
+func TestGenerateKey(t *testing.T) {
+	key, prefix, err := auth.GenerateKey()
+	require.NoError(t, err)
+	assert.Contains(t, key, prefix)
+	assert.Less(t, len(prefix), len(key))
+
+	other, _, err := auth.GenerateKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+	assert.NotEqual(t, auth.HashKey(key), auth.HashKey(other))
+	assert.Len(t, auth.HashKey(key), 64)
+}
+
+func TestRequireRole(t *testing.T) {
+	const adminKey = "config-admin-key"
+	keyColumns := []string{"id", "name", "role", "prefix", "key_hash", "created_at", "revoked_at", "account_id"}
+
+	tests := []struct {
+		name        string
+		header      http.Header
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+	}{
+		{
+			name:       "No key.",
+			header:     http.Header{},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Admin key from config.",
+			header:     http.Header{"X-Api-Key": {adminKey}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "Editor key from database.",
+			header: http.Header{"Authorization": {"Bearer eml_editor"}},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM api_key`).WithArgs(auth.HashKey("eml_editor")).WillReturnRows(
+					sqlmock.NewRows(keyColumns).AddRow(2, "editor", "editor", "eml_edit", auth.HashKey("eml_editor"), time.Now(), nil, nil))
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "Reader key is not enough.",
+			header: http.Header{"X-Api-Key": {"eml_reader"}},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM api_key`).WithArgs(auth.HashKey("eml_reader")).WillReturnRows(
+					sqlmock.NewRows(keyColumns).AddRow(1, "reader", "reader", "eml_read", auth.HashKey("eml_reader"), time.Now(), nil, nil))
+			},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:   "Unknown or revoked key.",
+			header: http.Header{"X-Api-Key": {"eml_revoked"}},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM api_key`).WithArgs(auth.HashKey("eml_revoked")).WillReturnRows(sqlmock.NewRows(keyColumns))
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "Database error.",
+			header: http.Header{"X-Api-Key": {"eml_editor"}},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM api_key`).WithArgs(auth.HashKey("eml_editor")).WillReturnError(sql.ErrConnDone)
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			if tt.buildEXPECT != nil {
+				tt.buildEXPECT(mock)
+			}
+
+			queries := hd.NewHandlerQueries(conn, config.Config{AdminAPIKey: adminKey})
+			h := queries.RequireRole(auth.RoleEditor)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, ok := auth.PrincipalFromContext(r.Context())
+				assert.True(t, ok)
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodDelete, "/library/delete?id=1", nil)
+			req.Header = tt.header
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
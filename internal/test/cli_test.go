@@ -0,0 +1,106 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCLI выполняет команду с настройками из корня проекта.
+func runCLI(args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = cli.Main(append([]string{"-config", "../.."}, args...), &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestCLIUsage(t *testing.T) {
+	code, _, stderr := runCLI("help")
+	assert.Equal(t, cli.ExitOK, code)
+	for _, cmd := range []string{"serve", "migrate up", "migrate down", "migrate goto", "migrate status",
+		"import", "export", "seed", "config check"} {
+		assert.Contains(t, stderr, cmd)
+	}
+
+	code, _, stderr = runCLI("migrate", "sideways")
+	assert.Equal(t, cli.ExitUsage, code)
+	assert.Contains(t, stderr, `unknown command "migrate sideways"`)
+}
+
+func TestCLIArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"down without count", []string{"migrate", "down"}, "expected number of migrations to roll back"},
+		{"down with zero", []string{"migrate", "down", "0"}, `invalid number of migrations "0"`},
+		{"up with extra args", []string{"migrate", "up", "1", "2"}, "unexpected arguments: 2"},
+		{"goto without version", []string{"migrate", "goto"}, "expected target version"},
+		{"goto negative", []string{"migrate", "goto", "-1"}, ""},
+		{"import without dirs", []string{"import"}, "expected at least one directory"},
+		{"export unknown format", []string{"export", "-format", "xml"}, "xml"},
+		{"export bad date", []string{"export", "-releaseDate", "2006-07-16"}, "incorrect date format"},
+		{"serve with args", []string{"serve", "now"}, "unexpected arguments: now"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runCLI(tt.args...)
+			assert.Equal(t, cli.ExitUsage, code)
+			assert.Contains(t, stderr, tt.want)
+		})
+	}
+}
+
+func TestSeed(t *testing.T) {
+	store := &mockImportStore{songs: map[string]int32{"Muse/Starlight": 7}}
+
+	created, updated, err := cli.Seed(context.Background(), store, strings.NewReader(`[
+		{"group": "Muse", "song": "Supermassive Black Hole", "releaseDate": "16.07.2006", "text": "Verse"},
+		{"group": "Muse", "song": "Starlight"}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+	assert.Equal(t, 1, updated)
+
+	require.Len(t, store.saved, 2)
+	assert.Equal(t, time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC), store.saved[0].ReleaseDate)
+	assert.Equal(t, "Verse", store.saved[0].Text)
+	assert.True(t, store.saved[1].ReleaseDate.IsZero())
+
+	_, _, err = cli.Seed(context.Background(), store, strings.NewReader(`[{"group": "Muse", "song": "Uprising", "releaseDate": "2009"}]`))
+	assert.ErrorContains(t, err, "song 1: incorrect date format")
+
+	_, _, err = cli.Seed(context.Background(), store, strings.NewReader(`[{"group": "Muse"}]`))
+	assert.ErrorContains(t, err, "group and song are required")
+}
+
+func TestCLIConfigShow(t *testing.T) {
+	code, stdout, _ := runCLI("-set", "ADMIN_API_KEY=topsecret", "-set", "PAGINATION_LIMIT=15", "config", "show")
+	assert.Equal(t, cli.ExitOK, code)
+	assert.Contains(t, stdout, "ADMIN_API_KEY=[redacted]\n")
+	assert.Contains(t, stdout, "PAGINATION_LIMIT=15\n")
+	assert.NotContains(t, stdout, "topsecret")
+
+	// Неверные настройки выводятся вместе со списком ошибок.
+	code, stdout, stderr := runCLI("-set", "PAGINATION_LIMIT=0", "config", "show")
+	assert.Equal(t, cli.ExitError, code)
+	assert.Contains(t, stdout, "PAGINATION_LIMIT=0\n")
+	assert.Contains(t, stderr, "PAGINATION_LIMIT: must be positive, got 0")
+
+	// Остальные команды с неверными настройками не выполняются.
+	code, _, stderr = runCLI("-set", "PAGINATION_LIMIT=0", "-set", "LOG_FORMAT=xml", "serve")
+	assert.Equal(t, cli.ExitError, code)
+	assert.Contains(t, stderr, "PAGINATION_LIMIT: must be positive, got 0")
+	assert.Contains(t, stderr, `LOG_FORMAT: must be console or json, got "xml"`)
+
+	code, _, stderr = runCLI("-set", "PAGINATION_LIMIT", "config", "show")
+	assert.Equal(t, cli.ExitUsage, code)
+	assert.Contains(t, stderr, `expected KEY=VALUE, got "PAGINATION_LIMIT"`)
+}
@@ -0,0 +1,70 @@
+package test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/cover"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 128})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+var coverOptions = cover.Options{
+	MinDimension:   100,
+	MaxDimension:   1000,
+	ThumbnailSizes: []int{256, 64, 64, 0, 1024},
+}
+
+func TestProcessCover(t *testing.T) {
+	data := encodePNG(t, 600, 300)
+
+	images, err := cover.Process(data, coverOptions)
+	require.NoError(t, err)
+	require.Len(t, images, 4)
+
+	// Оригинал сохраняется без изменений.
+	assert.Equal(t, 0, images[0].Size)
+	assert.Equal(t, "image/png", images[0].ContentType)
+	assert.Equal(t, data, images[0].Data)
+
+	// Копии отсортированы, вписаны в квадрат и не увеличиваются.
+	want := []struct{ size, w, h int }{{64, 64, 32}, {256, 256, 128}, {1024, 600, 300}}
+	for i, wt := range want {
+		img := images[i+1]
+		assert.Equal(t, wt.size, img.Size)
+		assert.Equal(t, "image/jpeg", img.ContentType)
+		assert.Equal(t, wt.w, img.Width)
+		assert.Equal(t, wt.h, img.Height)
+
+		decoded, errDec := jpeg.Decode(bytes.NewReader(img.Data))
+		require.NoError(t, errDec)
+		assert.Equal(t, image.Rect(0, 0, wt.w, wt.h), decoded.Bounds())
+	}
+}
+
+func TestProcessCoverValidation(t *testing.T) {
+	_, err := cover.Process([]byte("GIF89a...."), coverOptions)
+	assert.ErrorIs(t, err, cover.ErrUnsupportedFormat)
+
+	_, err = cover.Process(encodePNG(t, 50, 400), coverOptions)
+	assert.ErrorIs(t, err, cover.ErrInvalidDimensions)
+
+	_, err = cover.Process(encodePNG(t, 1200, 400), coverOptions)
+	assert.ErrorIs(t, err, cover.ErrInvalidDimensions)
+}
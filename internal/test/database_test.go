@@ -0,0 +1,92 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/database"
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabasePingRetry(t *testing.T) {
+	logs := observeLogs(t)
+	connect, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer connect.Close()
+
+	// База данных отвечает с третьей попытки.
+	starting := errors.New("the database system is starting up")
+	mock.ExpectPing().WillReturnError(starting)
+	mock.ExpectPing().WillReturnError(starting)
+	mock.ExpectPing()
+
+	retry := database.RetryOptions{Timeout: 5 * time.Second, InitialDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+	require.NoError(t, database.Ping(context.Background(), connect, retry))
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	warns := logs.FilterMessage("database is unavailable, retrying").All()
+	require.Len(t, warns, 2)
+	assert.Equal(t, 2*time.Millisecond, warns[1].ContextMap()["retry_in"])
+	assert.Equal(t, 1, logs.FilterMessage("connected to the database").Len())
+}
+
+func TestDatabasePingTimeout(t *testing.T) {
+	observeLogs(t)
+	connect, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer connect.Close()
+
+	for range 100 {
+		mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	}
+
+	retry := database.RetryOptions{Timeout: 30 * time.Millisecond, InitialDelay: 5 * time.Millisecond}
+	err = database.Ping(context.Background(), connect, retry)
+	assert.ErrorContains(t, err, "database is unavailable after")
+	assert.ErrorContains(t, err, "connection refused")
+}
+
+func TestDatabasePool(t *testing.T) {
+	logs := observeLogs(t)
+	connect, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer connect.Close()
+
+	database.Configure(connect, database.Pool(config.Config{DatabaseMaxOpenConns: 7, DatabaseMaxIdleConns: 3}))
+	assert.Equal(t, 7, connect.Stats().MaxOpenConnections)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		database.LogStats(ctx, connect, 5*time.Millisecond)
+		close(done)
+	}()
+	require.Eventually(t, func() bool {
+		return logs.FilterMessage("database pool stats").Len() > 0
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	entry := logs.FilterMessage("database pool stats").All()[0]
+	assert.Equal(t, int64(7), entry.ContextMap()["max_open"])
+
+	// Простаивающих соединений не может быть больше, чем соединений всего.
+	err = config.Config{DatabaseMaxOpenConns: 2, DatabaseMaxIdleConns: 5}.Validate()
+	assert.ErrorContains(t, err, "DB_MAX_IDLE_CONNS: must not be greater than DB_MAX_OPEN_CONNS 2, got 5")
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	assert.True(t, database.IsUniqueViolation(&pgconn.PgError{Code: "23505"}))
+	assert.True(t, database.IsUniqueViolation(fmt.Errorf("error adding group: %w", &pq.Error{Code: "23505"})))
+	assert.False(t, database.IsUniqueViolation(&pgconn.PgError{Code: "23503"}))
+	assert.False(t, database.IsUniqueViolation(errors.New("duplicate key")))
+	assert.False(t, database.IsUniqueViolation(nil))
+}
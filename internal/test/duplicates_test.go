@@ -0,0 +1,344 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/dedupe"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/storage"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameKey(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Case.", in: "Muse", want: "muse"},
+		{name: "Trailing space.", in: "Muse ", want: "muse"},
+		{name: "Inner whitespace.", in: " The \tKillers\n", want: "the killers"},
+		{name: "Fullwidth letters.", in: "ＭＵＳＥ", want: "muse"},
+		// Свёртка регистра отличается от lower: "ß" сворачивается в "ss", а конечная сигма "ς" в "σ".
+		{name: "Sharp s.", in: "Weißes Fleisch", want: "weisses fleisch"},
+		{name: "Sharp s in upper case.", in: "WEISSES FLEISCH", want: "weisses fleisch"},
+		{name: "Final sigma.", in: "Σίσυφος", want: "σίσυφοσ"},
+		{name: "Final sigma in upper case.", in: "ΣΊΣΥΦΟΣ", want: "σίσυφοσ"},
+		{name: "Ligature.", in: "ﬁre", want: "fire"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, dedupe.Key(tt.in))
+		})
+	}
+}
+
+func TestFillKeys(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	mock.ExpectQuery(`FROM artist\s+WHERE group_key = ''`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "group"}).AddRow(1, "Weißes Fleisch").AddRow(2, " ").AddRow(3, "WEISSES FLEISCH"))
+	mock.ExpectExec(`UPDATE artist`).WithArgs(int32(1), "weisses fleisch").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE artist`).WithArgs(int32(3), "weisses fleisch").WillReturnError(&pgconn.PgError{Code: "23505"})
+	mock.ExpectQuery(`FROM library\s+WHERE song_key = ''`).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "song"}).AddRow(21, "Σίσυφος"))
+	mock.ExpectExec(`UPDATE library`).WithArgs(int32(21), "σίσυφοσ").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	// Ключ названия из одних пробелов остаётся пустым, как и ключ дубликата группы 1.
+	filled, err := dedupe.FillKeys(context.Background(), db.New(conn))
+	require.NoError(t, err)
+	assert.Equal(t, 2, filled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Одновременные добавления той же группы или песни упираются в уникальные ключи.
+func TestAddSongUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name        string
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+	}{
+		{
+			name: "Group added concurrently.",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM artist`).WithArgs("muse").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectQuery(`INSERT INTO artist`).WithArgs("muse", "muse").WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "Song added concurrently.",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM artist`).WithArgs("muse").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`SELECT EXISTS`).WithArgs(int32(1), "starlight").WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectQuery(`INSERT INTO library`).WithArgs(int32(1), "Starlight", "starlight").WillReturnError(&pgconn.PgError{Code: "23505"})
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			mock.ExpectBegin()
+			tt.buildEXPECT(mock)
+			mock.ExpectRollback()
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(http.MethodPost, "/library/add", strings.NewReader(`{"group": "muse", "song": "Starlight"}`))
+			rec := httptest.NewRecorder()
+			queries.AddSongInLibrary(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, dedupe.Similarity("the killers", "killers, the"))
+	assert.Equal(t, 1.0, dedupe.Similarity("ac/dc", "ac dc"))
+	assert.InDelta(t, 0.91, dedupe.Similarity("the killers", "the killerz"), 0.01)
+	assert.Less(t, dedupe.Similarity("muse", "queen"), 0.5)
+
+	pairs := dedupe.Pairs([]dedupe.Item{
+		{ID: 3, Name: "The Killerz"},
+		{ID: 1, Name: "The Killers"},
+		{ID: 2, Name: "the killers "},
+		{ID: 4, Name: "Queen"},
+	}, 0.85)
+	require.Len(t, pairs, 3)
+	assert.Equal(t, dedupe.Pair{A: 1, B: 2, Score: 1}, pairs[0])
+	assert.Equal(t, []int32{1, 3}, []int32{pairs[1].A, pairs[1].B})
+	assert.Equal(t, []int32{2, 3}, []int32{pairs[2].A, pairs[2].B})
+}
+
+func TestShowDuplicates(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:   "Groups and songs of one group.",
+			target: "/library/duplicates",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM artist\s+LEFT JOIN library`).WillReturnRows(
+					sqlmock.NewRows([]string{"id", "group", "songs"}).
+						AddRow(1, "Muse", 3).AddRow(2, "muse ", 1).AddRow(3, "Queen", 1))
+				mock.ExpectQuery(`FROM library\s+JOIN artist`).WillReturnRows(
+					sqlmock.NewRows([]string{"id", "group_id", "group", "song"}).
+						AddRow(21, 1, "Muse", "Starlight").
+						AddRow(22, 1, "Muse", "Starlight ").
+						AddRow(23, 1, "Muse", "Uprising").
+						AddRow(31, 3, "Queen", "Starlight"))
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{
+				"artists": [{"score": 1, "artists": [
+					{"id": 1, "group": "Muse", "songs": 3},
+					{"id": 2, "group": "muse ", "songs": 1}]}],
+				"songs": [{"score": 1, "songs": [
+					{"id": 21, "groupId": 1, "group": "Muse", "song": "Starlight"},
+					{"id": 22, "groupId": 1, "group": "Muse", "song": "Starlight "}]}]
+			}`,
+		},
+		{
+			name:       "Invalid minimum score.",
+			target:     "/library/duplicates?minScore=2",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			if tt.buildEXPECT != nil {
+				tt.buildEXPECT(mock)
+			}
+
+			queries := hd.NewHandlerQueries(conn, config.Config{PaginationLimit: 10})
+
+			rec := httptest.NewRecorder()
+			queries.ShowDuplicates(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// expectMergeSong ожидает объединение песни source с песней target, у source остаются файлы keys.
+func expectMergeSong(mock sqlmock.Sqlmock, source, target int32, keys ...string) {
+	for _, query := range []string{
+		`INSERT INTO favorite`,
+		`INSERT INTO rating`,
+		`INSERT INTO song_tag`,
+		`UPDATE playlist_entry`,
+		`UPDATE play_event`,
+		`INSERT INTO song_daily_stats`,
+		`UPDATE audio`,
+		`UPDATE cover`,
+		`UPDATE library\s+SET "text"`,
+	} {
+		mock.ExpectExec(query).WithArgs(source, target).WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	rows := sqlmock.NewRows([]string{"storage_key"})
+	for _, key := range keys {
+		rows.AddRow(key)
+	}
+	mock.ExpectQuery(`SELECT storage_key`).WithArgs(source).WillReturnRows(rows)
+	mock.ExpectExec(`DELETE FROM library`).WithArgs(source).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSong(mock, target)
+	expectAudit(mock, "song", source, "merge")
+}
+
+func TestMergeArtists(t *testing.T) {
+	artistColumns := []string{"id", "group", "group_key"}
+	songColumns := []string{"id", "group_id", "song", "releaseDate", "text", "link", "deleted_at", "song_key"}
+	released := time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		body        string
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name: "Songs are merged or moved to the target group.",
+			body: `{"targetId": 1, "sourceIds": [2, 2]}`,
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`FROM artist`).WithArgs(int32(1)).
+					WillReturnRows(sqlmock.NewRows(artistColumns).AddRow(1, "Muse", "muse"))
+				mock.ExpectQuery(`FROM artist`).WithArgs(int32(2)).
+					WillReturnRows(sqlmock.NewRows(artistColumns).AddRow(2, "muse ", "muse"))
+				mock.ExpectQuery(`WHERE group_id = \$1\s+ORDER BY id`).WithArgs(int32(2)).
+					WillReturnRows(sqlmock.NewRows(songColumns).
+						AddRow(22, 2, "Supermassive Black Hole ", released, "", "", nil, "supermassive black hole").
+						AddRow(24, 2, "Uprising", released, "", "", time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), "uprising"))
+				// У группы 1 уже есть эта песня, песни объединяются.
+				mock.ExpectQuery(`AND song_key = \$2`).WithArgs(int32(1), "supermassive black hole").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21))
+				expectMergeSong(mock, 22, 21)
+				// Песня в корзине переносится без проверки.
+				mock.ExpectExec(`UPDATE library\s+SET group_id`).WithArgs(int32(24), int32(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, "song", 24, "update")
+				mock.ExpectExec(`DELETE FROM artist`).WithArgs(int32(2)).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`INSERT INTO audit_log`).
+					WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+						"artist", int32(2), "merge", jsonArg(`{"id":2,"group":"muse "}`), jsonArg(`{"id":1,"group":"Muse"}`)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{"targetId":1,"movedSongs":1,"mergedSongs":1}`,
+		},
+		{
+			name: "Source group does not exist.",
+			body: `{"targetId": 1, "sourceIds": [5]}`,
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`FROM artist`).WithArgs(int32(1)).
+					WillReturnRows(sqlmock.NewRows(artistColumns).AddRow(1, "Muse", "muse"))
+				mock.ExpectQuery(`FROM artist`).WithArgs(int32(5)).WillReturnRows(sqlmock.NewRows(artistColumns))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Group merged into itself.",
+			body:       `{"targetId": 1, "sourceIds": [1]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "No source groups.",
+			body:       `{"targetId": 1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			if tt.buildEXPECT != nil {
+				tt.buildEXPECT(mock)
+			}
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			rec := httptest.NewRecorder()
+			queries.MergeArtists(rec, httptest.NewRequest(http.MethodPost, "/library/merge/artists", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMergeSongs(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	audio, err := store.Put(ctx, "audio/22/1.mp3", strings.NewReader("mp3"))
+	require.NoError(t, err)
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// У песни 21 уже есть аудиофайл, поэтому файл песни 22 удаляется после объединения.
+	mock.ExpectBegin()
+	expectSong(mock, 21)
+	expectSong(mock, 22)
+	expectMergeSong(mock, 22, 21, audio.Key)
+	mock.ExpectCommit()
+
+	queries := hd.NewHandlerQueries(conn, config.Config{})
+	queries.Blobs = store
+
+	rec := httptest.NewRecorder()
+	queries.MergeSongs(rec, httptest.NewRequest(http.MethodPost, "/library/merge/songs",
+		strings.NewReader(`{"targetId": 21, "sourceIds": [22]}`)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"targetId":21,"movedSongs":0,"mergedSongs":1}`, rec.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = store.Open(ctx, audio.Key)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
@@ -0,0 +1,212 @@
+package test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/Ra1nz0r/effective_mobile-1/db/sqlc"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/export"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockExportSource отдаёт песни порциями, имитируя постраничный запрос к базе данных.
+type mockExportSource struct {
+	songs []db.ExportWithFiltersRow
+	calls []db.ExportWithFiltersParams
+	err   error
+}
+
+func (m *mockExportSource) ExportWithFilters(_ context.Context, arg db.ExportWithFiltersParams) ([]db.ExportWithFiltersRow, error) {
+	m.calls = append(m.calls, arg)
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	var res []db.ExportWithFiltersRow
+	for _, s := range m.songs {
+		if s.ID > arg.ID && int32(len(res)) < arg.Limit {
+			res = append(res, s)
+		}
+	}
+	return res, nil
+}
+
+func newMockExportSource(n int) *mockExportSource {
+	m := &mockExportSource{}
+	for i := 1; i <= n; i++ {
+		m.songs = append(m.songs, db.ExportWithFiltersRow{
+			ID:          int32(i),
+			Group:       "Muse",
+			Song:        "Song, \"quoted\"",
+			ReleaseDate: time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC),
+			Text:        "Ooh baby\n\nOoh",
+			Link:        "https://example.com",
+		})
+	}
+	return m
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    export.Format
+		wantErr bool
+	}{
+		{in: "", want: export.FormatNDJSON},
+		{in: "NDJSON", want: export.FormatNDJSON},
+		{in: "csv", want: export.FormatCSV},
+		{in: "json", want: export.FormatJSON},
+		{in: "xml", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := export.ParseFormat(tt.in)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExportNDJSONInBatches(t *testing.T) {
+	src := newMockExportSource(5)
+
+	var buf bytes.Buffer
+	total, err := export.New(src, 2).Songs(context.Background(), &buf, export.FormatNDJSON, models.SongFilter{Group: "Muse"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 5, total)
+
+	// Пять песен порциями по две: 2 + 2 + 1.
+	require.Len(t, src.calls, 3)
+	assert.Equal(t, int32(0), src.calls[0].ID)
+	assert.Equal(t, int32(2), src.calls[1].ID)
+	assert.Equal(t, int32(4), src.calls[2].ID)
+	assert.Equal(t, "Muse", src.calls[0].Column2.String)
+	assert.True(t, src.calls[0].Column2.Valid)
+	assert.False(t, src.calls[0].Column3.Valid)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+
+	var first models.ExportSong
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, int32(1), first.ID)
+	assert.Equal(t, "2006-07-16", first.ReleaseDate)
+	assert.Equal(t, "Ooh baby\n\nOoh", first.Text)
+}
+
+func TestExportJSONArray(t *testing.T) {
+	var buf bytes.Buffer
+	total, err := export.New(newMockExportSource(3), 10).Songs(context.Background(), &buf, export.FormatJSON, models.SongFilter{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	var songs []models.ExportSong
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &songs))
+	assert.Len(t, songs, 3)
+}
+
+func TestExportEmptyJSONArray(t *testing.T) {
+	var buf bytes.Buffer
+	total, err := export.New(newMockExportSource(0), 10).Songs(context.Background(), &buf, export.FormatJSON, models.SongFilter{}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.JSONEq(t, "[]", buf.String())
+}
+
+func TestExportCSVWithGzip(t *testing.T) {
+	var buf bytes.Buffer
+	total, err := export.New(newMockExportSource(2), 10).Songs(context.Background(), &buf, export.FormatCSV, models.SongFilter{}, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	gz, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	data, err := io.ReadAll(gz)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"id", "group", "song", "releaseDate", "text", "link"}, records[0])
+	assert.Equal(t, []string{"1", "Muse", "Song, \"quoted\"", "2006-07-16", "Ooh baby\n\nOoh", "https://example.com"}, records[1])
+}
+
+func TestExportSourceError(t *testing.T) {
+	src := &mockExportSource{err: errors.New("connection refused")}
+
+	var buf bytes.Buffer
+	_, err := export.New(src, 10).Songs(context.Background(), &buf, export.FormatNDJSON, models.SongFilter{}, false)
+	assert.ErrorContains(t, err, "connection refused")
+}
+
+func TestExportPlaylistM3U8(t *testing.T) {
+	src := newMockExportSource(3)
+	src.songs[1].Link = ""
+	src.songs[2].Song = "Starlight\nLive"
+
+	var buf bytes.Buffer
+	total, err := export.New(src, 10).Playlist(context.Background(), &buf, export.PlaylistM3U8, "Best of Muse", models.SongFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	want := "#EXTM3U\n" +
+		"#PLAYLIST:Best of Muse\n" +
+		"#EXTINF:-1,Muse - Song, \"quoted\"\n" +
+		"https://example.com\n" +
+		"#EXTINF:-1,Muse - Starlight Live\n" +
+		"https://example.com\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestExportPlaylistXSPF(t *testing.T) {
+	src := newMockExportSource(2)
+	src.songs[0].Group = "Simon & Garfunkel"
+
+	var buf bytes.Buffer
+	total, err := export.New(src, 10).Playlist(context.Background(), &buf, export.PlaylistXSPF, "Mix", models.SongFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	var playlist struct {
+		XMLName xml.Name `xml:"http://xspf.org/ns/0/ playlist"`
+		Title   string   `xml:"title"`
+		Tracks  []struct {
+			Location string `xml:"location"`
+			Creator  string `xml:"creator"`
+			Title    string `xml:"title"`
+		} `xml:"trackList>track"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &playlist))
+	assert.Equal(t, "Mix", playlist.Title)
+	require.Len(t, playlist.Tracks, 2)
+	assert.Equal(t, "Simon & Garfunkel", playlist.Tracks[0].Creator)
+	assert.Equal(t, "https://example.com", playlist.Tracks[0].Location)
+}
+
+func TestParsePlaylistFormat(t *testing.T) {
+	f, err := export.ParsePlaylistFormat("")
+	assert.NoError(t, err)
+	assert.Equal(t, export.PlaylistM3U8, f)
+
+	f, err = export.ParsePlaylistFormat("XSPF")
+	assert.NoError(t, err)
+	assert.Equal(t, export.PlaylistXSPF, f)
+
+	_, err = export.ParsePlaylistFormat("pls")
+	assert.Error(t, err)
+}
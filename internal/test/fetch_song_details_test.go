@@ -1,6 +1,7 @@
 package test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -34,7 +35,7 @@ func TestSuccess(t *testing.T) {
 	defer mockServer.Close()
 
 	// Вызываем тестируемую функцию
-	result, err := services.FetchSongDetails("Muse", "Supermassive Black Hole", mockServer.URL)
+	result, err := services.FetchSongDetails(context.Background(), "Muse", "Supermassive Black Hole", mockServer.URL)
 
 	// Проверяем, что ошибки нет
 	assert.NoError(t, err)
@@ -48,7 +49,7 @@ func TestHttpError(t *testing.T) {
 	mockServer.Close()
 
 	// Вызываем тестируемую функцию
-	_, err := services.FetchSongDetails("Muse", "Supermassive Black Hole", mockServer.URL)
+	_, err := services.FetchSongDetails(context.Background(), "Muse", "Supermassive Black Hole", mockServer.URL)
 
 	// Ожидаем ошибку запроса
 	assert.Error(t, err)
@@ -62,7 +63,7 @@ func TestStatusNotOK(t *testing.T) {
 	defer mockServer.Close()
 
 	// Вызываем тестируемую функцию
-	_, err := services.FetchSongDetails("Muse", "Supermassive Black Hole", mockServer.URL)
+	_, err := services.FetchSongDetails(context.Background(), "Muse", "Supermassive Black Hole", mockServer.URL)
 
 	// Ожидаем ошибку из-за неправильного статуса
 	assert.EqualError(t, err, "API returned status: 500 Internal Server Error")
@@ -79,7 +80,7 @@ func TestBodyReadError(t *testing.T) {
 	defer mockServer.Close()
 
 	// Вызываем тестируемую функцию
-	_, err := services.FetchSongDetails("Muse", "Supermassive Black Hole", mockServer.URL)
+	_, err := services.FetchSongDetails(context.Background(), "Muse", "Supermassive Black Hole", mockServer.URL)
 
 	// Ожидаем ошибку при чтении тела ответа
 	assert.Error(t, err)
@@ -95,7 +96,7 @@ func TestJSONUnmarshalError(t *testing.T) {
 	defer mockServer.Close()
 
 	// Вызываем тестируемую функцию
-	_, err := services.FetchSongDetails("Muse", "Supermassive Black Hole", mockServer.URL)
+	_, err := services.FetchSongDetails(context.Background(), "Muse", "Supermassive Black Hole", mockServer.URL)
 
 	// Ожидаем ошибку при парсинге JSON
 	assert.Error(t, err)
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/breaker"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/health"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/migration"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMigrationPath = "file://../../db/migration"
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	b := breaker.New(2, 30*time.Second).WithClock(func() time.Time { return now })
+
+	require.NoError(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, breaker.StateClosed, b.State())
+
+	// Успешный запрос сбрасывает счётчик ошибок.
+	b.Success()
+	b.Failure()
+	assert.Equal(t, breaker.StateClosed, b.State())
+	b.Failure()
+	assert.Equal(t, breaker.StateOpen, b.State())
+	assert.ErrorIs(t, b.Allow(), breaker.ErrOpen)
+
+	// После паузы пропускается только один пробный запрос.
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, breaker.StateHalfOpen, b.State())
+	require.NoError(t, b.Allow())
+	assert.ErrorIs(t, b.Allow(), breaker.ErrOpen)
+
+	// Ошибка пробного запроса снова приостанавливает запросы.
+	b.Failure()
+	assert.Equal(t, breaker.StateOpen, b.State())
+
+	now = now.Add(30 * time.Second)
+	require.NoError(t, b.Allow())
+	b.Success()
+	assert.Equal(t, breaker.StateClosed, b.State())
+	require.NoError(t, b.Allow())
+}
+
+func TestBreakerExternalAPI(t *testing.T) {
+	calls := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer mockServer.Close()
+
+	prev := services.ExternalAPIBreaker
+	services.ExternalAPIBreaker = breaker.New(2, time.Minute)
+	t.Cleanup(func() { services.ExternalAPIBreaker = prev })
+
+	for range 3 {
+		_, err := services.FetchSongDetails(context.Background(), "Muse", "Supermassive Black Hole", mockServer.URL)
+		require.Error(t, err)
+	}
+
+	_, err := services.FetchSongDetails(context.Background(), "Muse", "Supermassive Black Hole", mockServer.URL)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.Equal(t, 2, calls)
+}
+
+// expectSchemaTable ожидает проверку существования таблицы schema_migrations.
+func expectSchemaTable(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT to_regclass\('schema_migrations'\) IS NOT NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+}
+
+func readinessReport(t *testing.T, checker *health.Checker) (int, health.Report) {
+	t.Helper()
+
+	queries := hd.NewHandlerQueries(nil, config.Config{})
+	queries.Health = checker
+
+	rec := httptest.NewRecorder()
+	queries.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	return rec.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	conn, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	latest, err := migration.Latest(testMigrationPath)
+	require.NoError(t, err)
+
+	open := breaker.New(1, time.Minute)
+	open.Failure()
+
+	checker := health.New(time.Second)
+	checker.Register("database", true, health.Database(conn))
+	checker.Register("migrations", true, health.Migrations(conn, testMigrationPath))
+	checker.Register("external_api", false, health.Circuit(open))
+
+	// До запуска сервера приложение не готово.
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectPing()
+	expectSchemaTable(mock)
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest, false))
+	code, report := readinessReport(t, checker)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusDown, report.Components["server"].Status)
+
+	// Недоступный внешний API не мешает принимать запросы.
+	checker.SetReady(true)
+	mock.ExpectPing()
+	expectSchemaTable(mock)
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest, false))
+	code, report = readinessReport(t, checker)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusDegraded, report.Status)
+	assert.Equal(t, health.StatusUp, report.Components["database"].Status)
+	assert.Equal(t, health.StatusUp, report.Components["migrations"].Status)
+	assert.Equal(t, health.StatusDegraded, report.Components["external_api"].Status)
+	assert.Equal(t, "open", report.Components["external_api"].Details["state"])
+
+	// Незавершённая миграция и недоступная база данных делают приложение неготовым.
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	expectSchemaTable(mock)
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest, true))
+	code, report = readinessReport(t, checker)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusDown, report.Components["database"].Status)
+	assert.Equal(t, "connection refused", report.Components["database"].Error)
+	assert.Equal(t, health.StatusDown, report.Components["migrations"].Status)
+
+	// Во время остановки сервера готовность снимается.
+	checker.SetReady(false)
+	mock.ExpectPing()
+	expectSchemaTable(mock)
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest, false))
+	code, _ = readinessReport(t, checker)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrationsBehind(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	latest, err := migration.Latest(testMigrationPath)
+	require.NoError(t, err)
+
+	expectSchemaTable(mock)
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(latest-1, false))
+
+	res := health.Migrations(conn, testMigrationPath)(context.Background())
+	assert.Equal(t, health.StatusDown, res.Status)
+	assert.Equal(t, latest-1, res.Details["version"])
+	assert.Equal(t, latest, res.Details["expected"])
+}
+
+func TestLiveness(t *testing.T) {
+	queries := hd.NewHandlerQueries(nil, config.Config{})
+
+	rec := httptest.NewRecorder()
+	queries.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"up"}`, rec.Body.String())
+}
@@ -0,0 +1,236 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audiotag"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/importer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// id3Frame собирает фрейм ID3v2.3 или ID3v2.4.
+func id3Frame(version byte, id string, data []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(id)
+	size := make([]byte, 4)
+	if version == 4 {
+		size = syncsafeBytes(len(data))
+	} else {
+		binary.BigEndian.PutUint32(size, uint32(len(data)))
+	}
+	buf.Write(size)
+	buf.Write([]byte{0, 0})
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// id3Tag собирает тег ID3v2 с padding и «аудиоданными» после него.
+func id3Tag(version byte, frames ...[]byte) []byte {
+	body := bytes.Join(frames, nil)
+	body = append(body, make([]byte, 16)...)
+
+	var buf bytes.Buffer
+	buf.WriteString("ID3")
+	buf.Write([]byte{version, 0, 0})
+	buf.Write(syncsafeBytes(len(body)))
+	buf.Write(body)
+	buf.Write([]byte{0xFF, 0xFB, 0x90, 0x00})
+	return buf.Bytes()
+}
+
+func syncsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+}
+
+func utf16WithBOM(s string) []byte {
+	b := []byte{0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(u), byte(u>>8))
+	}
+	return b
+}
+
+// flacFile собирает FLAC файл с блоками STREAMINFO и VORBIS_COMMENT.
+func flacFile(comments ...string) []byte {
+	var vc bytes.Buffer
+	writeString := func(s string) {
+		_ = binary.Write(&vc, binary.LittleEndian, uint32(len(s)))
+		vc.WriteString(s)
+	}
+	writeString("reference libFLAC 1.4.3")
+	_ = binary.Write(&vc, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		writeString(c)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("fLaC")
+	buf.Write([]byte{0, 0, 0, 34})
+	buf.Write(make([]byte, 34))
+	n := vc.Len()
+	buf.Write([]byte{0x80 | 4, byte(n >> 16), byte(n >> 8), byte(n)})
+	buf.Write(vc.Bytes())
+	return buf.Bytes()
+}
+
+func TestReadID3v23UTF16(t *testing.T) {
+	lyrics := append([]byte{1, 'e', 'n', 'g'}, utf16WithBOM("")...)
+	lyrics = append(lyrics, 0, 0)
+	lyrics = append(lyrics, utf16WithBOM("Ooh baby\r\n\r\nOoh")...)
+
+	file := id3Tag(3,
+		id3Frame(3, "TPE1", append([]byte{1}, utf16WithBOM("Мумий Тролль")...)),
+		id3Frame(3, "TIT2", append([]byte{0}, []byte("Utekay")...)),
+		id3Frame(3, "TYER", append([]byte{0}, []byte("1997")...)),
+		id3Frame(3, "USLT", lyrics),
+	)
+
+	tags, err := audiotag.Read(bytes.NewReader(file))
+	require.NoError(t, err)
+	assert.Equal(t, "id3v2.3", tags.Format)
+	assert.Equal(t, []string{"Мумий Тролль"}, tags.Artist)
+	assert.Equal(t, []string{"Utekay"}, tags.Title)
+	assert.Equal(t, []string{"1997"}, tags.Date)
+	assert.Equal(t, []string{"Ooh baby\n\nOoh"}, tags.Lyrics)
+}
+
+func TestReadID3v24MultipleValues(t *testing.T) {
+	file := id3Tag(4,
+		id3Frame(4, "TPE1", append([]byte{3}, []byte("Muse\x00Queen")...)),
+		id3Frame(4, "TIT2", append([]byte{3}, []byte("Supermassive Black Hole")...)),
+		id3Frame(4, "TDRC", append([]byte{3}, []byte("2006-07-16")...)),
+	)
+
+	tags, err := audiotag.Read(bytes.NewReader(file))
+	require.NoError(t, err)
+	assert.Equal(t, "id3v2.4", tags.Format)
+	assert.Equal(t, []string{"Muse", "Queen"}, tags.Artist)
+	assert.Equal(t, []string{"2006-07-16"}, tags.Date)
+	assert.Empty(t, tags.Lyrics)
+}
+
+func TestReadFLAC(t *testing.T) {
+	file := flacFile("ARTIST=Pink Floyd", "title=Time", "DATE=1973-03-01", "UNSYNCEDLYRICS=Ticking away", "GENRE=Rock")
+
+	tags, err := audiotag.Read(bytes.NewReader(file))
+	require.NoError(t, err)
+	assert.Equal(t, "flac", tags.Format)
+	assert.Equal(t, []string{"Pink Floyd"}, tags.Artist)
+	assert.Equal(t, []string{"Time"}, tags.Title)
+	assert.Equal(t, []string{"1973-03-01"}, tags.Date)
+	assert.Equal(t, []string{"Ticking away"}, tags.Lyrics)
+}
+
+func TestReadUnsupported(t *testing.T) {
+	_, err := audiotag.Read(strings.NewReader("RIFF....WAVE"))
+	assert.ErrorIs(t, err, audiotag.ErrUnsupportedFormat)
+
+	_, err = audiotag.Read(strings.NewReader(""))
+	assert.ErrorIs(t, err, audiotag.ErrUnsupportedFormat)
+}
+
+// mockImportStore запоминает сохранённые песни.
+type mockImportStore struct {
+	songs map[string]int32
+	saved []importer.Song
+}
+
+func (m *mockImportStore) UpsertSong(_ context.Context, song importer.Song) (int32, bool, error) {
+	m.saved = append(m.saved, song)
+	key := song.Group + "/" + song.Title
+	if id, ok := m.songs[key]; ok {
+		return id, false, nil
+	}
+	id := int32(len(m.songs) + 1)
+	m.songs[key] = id
+	return id, true, nil
+}
+
+func TestImportBatch(t *testing.T) {
+	store := &mockImportStore{songs: map[string]int32{"Muse/Starlight": 7}}
+	batch := importer.NewBatch(store)
+	ctx := context.Background()
+
+	created := batch.Add(ctx, "smbh.flac", bytes.NewReader(flacFile(
+		"ARTIST=Muse", "TITLE=Supermassive Black Hole", "DATE=2006", "DATE=2006-07-16", "LYRICS=Ooh baby")))
+	assert.Equal(t, importer.StatusCreated, created.Status)
+	assert.Empty(t, created.Conflicts)
+
+	updated := batch.Add(ctx, "starlight.flac", bytes.NewReader(flacFile("ARTIST=Muse", "TITLE=Starlight")))
+	assert.Equal(t, importer.StatusUpdated, updated.Status)
+	assert.Equal(t, int32(7), updated.SongID)
+	assert.Equal(t, []string{"lyrics", "date"}, updated.Missing)
+
+	noTitle := batch.Add(ctx, "untitled.flac", bytes.NewReader(flacFile("ARTIST=Muse")))
+	assert.Equal(t, importer.StatusSkipped, noTitle.Status)
+	assert.Contains(t, noTitle.Missing, "title")
+
+	conflict := batch.Add(ctx, "two-artists.flac", bytes.NewReader(flacFile("ARTIST=Muse", "ARTIST=Queen", "TITLE=Uprising")))
+	assert.Equal(t, importer.StatusSkipped, conflict.Status)
+	require.Len(t, conflict.Conflicts, 1)
+	assert.Contains(t, conflict.Conflicts[0], "artist")
+
+	badDate := batch.Add(ctx, "dates.flac", bytes.NewReader(flacFile("ARTIST=Muse", "TITLE=Hysteria", "DATE=2003", "DATE=2004-01-01")))
+	assert.Equal(t, importer.StatusSkipped, badDate.Status)
+
+	// Названия сравниваются по ключам библиотеки, регистр и лишние пробелы не важны.
+	duplicate := batch.Add(ctx, "copy/smbh.flac", bytes.NewReader(flacFile(
+		"ARTIST=MUSE", "TITLE=Supermassive  black hole", "DATE=2006-07-17")))
+	assert.Equal(t, importer.StatusSkipped, duplicate.Status)
+	assert.Contains(t, duplicate.Conflicts[0], "another file")
+
+	broken := batch.Add(ctx, "notes.txt", strings.NewReader("just text"))
+	assert.Equal(t, importer.StatusSkipped, broken.Status)
+	assert.NotEmpty(t, broken.Error)
+
+	report := batch.Report()
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 5, report.Skipped)
+	assert.Len(t, report.Files, 7)
+
+	require.Len(t, store.saved, 2)
+	assert.Equal(t, time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC), store.saved[0].ReleaseDate)
+	assert.Equal(t, "Ooh baby", store.saved[0].Text)
+}
+
+func TestImportBatchDryRun(t *testing.T) {
+	batch := importer.NewBatch(nil)
+	res := batch.Add(context.Background(), "a.flac", bytes.NewReader(flacFile("ARTIST=Muse", "TITLE=Uprising", "DATE=2009")))
+	assert.Equal(t, importer.StatusChecked, res.Status)
+	assert.Equal(t, 1, batch.Report().Checked)
+}
+
+func TestImportSongsTooLarge(t *testing.T) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, name := range []string{"a.flac", "b.flac"} {
+		part, err := mw.CreateFormFile("files", name)
+		require.NoError(t, err)
+		_, err = part.Write(bytes.Repeat([]byte{0}, 4096))
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+
+	queries := hd.NewHandlerQueries(nil, config.Config{ImportMaxSize: 1024})
+
+	req := httptest.NewRequest(http.MethodPost, "/library/import?dryRun=true", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	rec := httptest.NewRecorder()
+	queries.ImportSongs(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Contains(t, rec.Body.String(), "exceeds 1024 bytes")
+}
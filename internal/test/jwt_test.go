@@ -0,0 +1,205 @@
+package test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ed25519JWK(kid string, key ed25519.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "OKP",
+		"kid": kid,
+		"crv": "Ed25519",
+		"x":   base64.RawURLEncoding.EncodeToString(key),
+	}
+}
+
+func jwksJSON(t *testing.T, keys ...map[string]string) []byte {
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	require.NoError(t, err)
+	return data
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestJWTValidator(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksPath, jwksJSON(t, rsaJWK("rsa-1", &rsaKey.PublicKey), ed25519JWK("ed-1", edPub)), 0o600))
+
+	v, err := auth.NewJWTValidator(context.Background(), auth.JWTOptions{
+		JWKS:        jwksPath,
+		Issuer:      "https://sso.example.com",
+		Audience:    "music-library",
+		RolesClaim:  "realm_access.roles",
+		RoleMapping: map[string]auth.Role{"music-editors": auth.RoleEditor, "music-admins": auth.RoleAdmin},
+		DefaultRole: auth.RoleReader,
+	})
+	require.NoError(t, err)
+
+	claims := func(roles ...interface{}) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":                "https://sso.example.com",
+			"aud":                "music-library",
+			"sub":                "42",
+			"preferred_username": "alice",
+			"exp":                time.Now().Add(time.Hour).Unix(),
+			"realm_access":       map[string]interface{}{"roles": roles},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		wantRole auth.Role
+		wantErr  bool
+	}{
+		{
+			name:     "RSA token with mapped role.",
+			token:    signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims("offline_access", "music-editors")),
+			wantRole: auth.RoleEditor,
+		},
+		{
+			name:     "Ed25519 token picks the highest role.",
+			token:    signToken(t, jwt.SigningMethodEdDSA, "ed-1", edKey, claims("music-editors", "music-admins")),
+			wantRole: auth.RoleAdmin,
+		},
+		{
+			name:     "Token without mapped roles gets the default role.",
+			token:    signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims("admin")),
+			wantRole: auth.RoleReader,
+		},
+		{
+			name: "Expired token.",
+			token: signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, func() jwt.MapClaims {
+				c := claims()
+				c["exp"] = time.Now().Add(-time.Hour).Unix()
+				return c
+			}()),
+			wantErr: true,
+		},
+		{
+			name: "Wrong audience.",
+			token: signToken(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, func() jwt.MapClaims {
+				c := claims()
+				c["aud"] = "another-app"
+				return c
+			}()),
+			wantErr: true,
+		},
+		{
+			name:    "Signed by an unknown key.",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-1", otherKey, claims()),
+			wantErr: true,
+		},
+		{
+			name:    "Unknown key ID.",
+			token:   signToken(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, claims()),
+			wantErr: true,
+		},
+		{
+			name:    "HMAC algorithm is rejected.",
+			token:   signToken(t, jwt.SigningMethodHS256, "rsa-1", []byte("secret"), claims()),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, errVal := v.Validate(context.Background(), tt.token)
+			if tt.wantErr {
+				assert.Error(t, errVal)
+				return
+			}
+			require.NoError(t, errVal)
+			assert.Equal(t, tt.wantRole, p.Role)
+			assert.Equal(t, "42", p.Subject)
+			assert.Equal(t, "alice", p.Name)
+		})
+	}
+}
+
+func TestJWTValidatorRemoteKeyRotation(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var rotated atomic.Bool
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if rotated.Load() {
+			_, _ = w.Write(jwksJSON(t, rsaJWK("new", &newKey.PublicKey)))
+			return
+		}
+		_, _ = w.Write(jwksJSON(t, rsaJWK("old", &oldKey.PublicKey)))
+	}))
+	defer srv.Close()
+
+	v, err := auth.NewJWTValidator(context.Background(), auth.JWTOptions{JWKS: srv.URL})
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{"sub": "7", "exp": time.Now().Add(time.Hour).Unix(), "roles": "reader editor"}
+
+	p, err := v.Validate(context.Background(), signToken(t, jwt.SigningMethodRS256, "old", oldKey, claims))
+	require.NoError(t, err)
+	assert.Equal(t, auth.RoleEditor, p.Role)
+
+	// После смены ключей у поставщика неизвестный kid приводит к повторной загрузке JWKS.
+	rotated.Store(true)
+	_, err = v.Validate(context.Background(), signToken(t, jwt.SigningMethodRS256, "new", newKey, claims))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestParseRoleMapping(t *testing.T) {
+	mapping, err := auth.ParseRoleMapping([]string{"music-admins:admin", " listeners : reader ", ""})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]auth.Role{"music-admins": auth.RoleAdmin, "listeners": auth.RoleReader}, mapping)
+
+	_, err = auth.ParseRoleMapping([]string{"music-admins"})
+	assert.Error(t, err)
+
+	_, err = auth.ParseRoleMapping([]string{"music-admins:root"})
+	assert.ErrorIs(t, err, auth.ErrUnknownRole)
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/logger"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/requestid"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// observeLogs подменяет логгер на время теста и возвращает записанные сообщения.
+func observeLogs(t *testing.T) *observer.ObservedLogs {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	prev := logger.Zap
+	logger.Replace(zap.New(core, zap.AddCallerSkip(1)))
+	t.Cleanup(func() { logger.Zap = prev })
+	return logs
+}
+
+func loggingRouter() http.Handler {
+	queries := hd.NewHandlerQueries(nil, config.Config{})
+
+	r := chi.NewRouter()
+	r.Use(requestid.Middleware)
+	r.Use(queries.AccessLog)
+	r.Get("/logging-test/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.Ctx(r.Context()).Info("inside handler")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("ok"))
+	})
+	r.Post("/logging-test/audio", queries.UploadSongAudio)
+	return r
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	logs := observeLogs(t)
+
+	rec := httptest.NewRecorder()
+	loggingRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logging-test/5", nil))
+
+	id := rec.Header().Get(requestid.Header)
+	require.Len(t, id, 32)
+
+	// Запись из обработчика и строка журнала доступа связаны одним ID.
+	require.Equal(t, 1, logs.FilterMessage("inside handler").Len())
+	assert.Equal(t, id, logs.FilterMessage("inside handler").All()[0].ContextMap()["request_id"])
+
+	access := logs.FilterMessage("request").All()
+	require.Len(t, access, 1)
+	fields := access[0].ContextMap()
+	assert.Equal(t, id, fields["request_id"])
+	assert.Equal(t, "GET", fields["method"])
+	assert.Equal(t, "/logging-test/{id}", fields["route"])
+	assert.Equal(t, int64(http.StatusAccepted), fields["status"])
+	assert.Equal(t, int64(2), fields["size"])
+	assert.Equal(t, "-", fields["caller"])
+}
+
+func TestRequestIDFromClient(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"valid", "client-42.retry:1", true},
+		{"newline", "abc\ninjected", false},
+		{"too long", string(make([]byte, 129)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := observeLogs(t)
+
+			req := httptest.NewRequest(http.MethodGet, "/logging-test/1", nil)
+			req.Header.Set(requestid.Header, tt.incoming)
+			rec := httptest.NewRecorder()
+			loggingRouter().ServeHTTP(rec, req)
+
+			id := rec.Header().Get(requestid.Header)
+			if tt.keep {
+				assert.Equal(t, tt.incoming, id)
+			} else {
+				assert.NotEqual(t, tt.incoming, id)
+				assert.True(t, requestid.Valid(id))
+			}
+			require.Equal(t, 1, logs.FilterMessage("request").Len())
+			assert.Equal(t, id, logs.FilterMessage("request").All()[0].ContextMap()["request_id"])
+		})
+	}
+}
+
+func TestAccessLogOmitsQuery(t *testing.T) {
+	logs := observeLogs(t)
+
+	rec := httptest.NewRecorder()
+	loggingRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logging-test/5?token=secret", nil))
+
+	require.Equal(t, 1, logs.FilterMessage("request").Len())
+	fields := logs.FilterMessage("request").All()[0].ContextMap()
+	assert.Equal(t, "/logging-test/5", fields["path"])
+	for _, v := range fields {
+		assert.NotContains(t, fmt.Sprint(v), "secret")
+	}
+}
+
+func TestHandlerErrorHasRequestID(t *testing.T) {
+	logs := observeLogs(t)
+
+	rec := httptest.NewRecorder()
+	loggingRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/logging-test/audio?id=0", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	errs := logs.FilterLevelExact(zapcore.ErrorLevel).All()
+	require.Len(t, errs, 1)
+	assert.Equal(t, rec.Header().Get(requestid.Header), errs[0].ContextMap()["request_id"])
+}
+
+func TestAccessLogUnmatched(t *testing.T) {
+	logs := observeLogs(t)
+
+	rec := httptest.NewRecorder()
+	loggingRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/logging-unknown", nil))
+
+	require.Equal(t, 1, logs.FilterMessage("request").Len())
+	fields := logs.FilterMessage("request").All()[0].ContextMap()
+	assert.Equal(t, "unmatched", fields["route"])
+	assert.Equal(t, int64(http.StatusNotFound), fields["status"])
+}
+
+func TestRequestIDForwarded(t *testing.T) {
+	var got string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(requestid.Header)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer mockServer.Close()
+
+	ctx := requestid.NewContext(context.Background(), "forward-me")
+	_, err := services.FetchSongDetails(ctx, "Muse", "Supermassive Black Hole", mockServer.URL)
+	require.Error(t, err)
+	assert.Equal(t, "forward-me", got)
+}
@@ -0,0 +1,60 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/metrics"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrapeMetrics(t *testing.T) string {
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(metrics.Middleware)
+	r.Get("/metrics-test/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	for _, url := range []string{"/metrics-test/1", "/metrics-test/2", "/metrics-test-unknown"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+	}
+
+	body := scrapeMetrics(t)
+	// Параметры маршрута не попадают в метки, оба запроса учтены в одном ряду.
+	assert.Contains(t, body, `music_library_http_requests_total{method="GET",route="/metrics-test/{id}",status="418"} 2`)
+	assert.Contains(t, body, `music_library_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `music_library_http_request_duration_seconds_count{method="GET",route="/metrics-test/{id}",status="418"} 2`)
+}
+
+func TestMetricsExternalAPI(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer mockServer.Close()
+
+	before := scrapeMetrics(t)
+	assert.Contains(t, before, `music_library_song_enrichment_total{result="unavailable"}`)
+
+	_, err := services.FetchSongDetails(context.Background(), "Muse", "Supermassive Black Hole", mockServer.URL)
+	require.Error(t, err)
+
+	body := scrapeMetrics(t)
+	assert.Contains(t, body, `music_library_external_api_request_duration_seconds_count{outcome="bad_status"}`)
+	assert.NotContains(t, body, `music_library_external_api_requests_total{outcome="bad_status"} 0`)
+}
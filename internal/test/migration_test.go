@@ -0,0 +1,81 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/migration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	embedded, err := migration.List("")
+	require.NoError(t, err)
+
+	files, err := migration.List(testMigrationPath)
+	require.NoError(t, err)
+
+	// Встроенные миграции совпадают с файлами в db/migration.
+	require.NotEmpty(t, embedded)
+	assert.Equal(t, files, embedded)
+	assert.Equal(t, uint(1), embedded[0].Version)
+	assert.Equal(t, "init_schema", embedded[0].Name)
+}
+
+func TestMigrationStatusErr(t *testing.T) {
+	tests := []struct {
+		name   string
+		status migration.Status
+		want   string
+	}{
+		{"up to date", migration.Status{Version: 8, Latest: 8}, ""},
+		{"pending", migration.Status{Version: 5, Latest: 8}, "schema version 5, expected 8, run 'migrate up'"},
+		{"not migrated", migration.Status{Version: 0, Latest: 8}, "schema version 0, expected 8"},
+		{"dirty", migration.Status{Version: 8, Latest: 8, Dirty: true}, "migration 8 did not complete"},
+		{"ahead", migration.Status{Version: 9, Latest: 8}, "schema version 9 is newer than the latest migration 8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.status.Err()
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestInspectMigrations(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Без таблицы schema_migrations миграции не применялись.
+	mock.ExpectQuery(`SELECT to_regclass`).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+
+	st, err := migration.Inspect(context.Background(), conn, "")
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), st.Version)
+	assert.True(t, st.Pending())
+	for _, e := range st.Migrations {
+		assert.False(t, e.Applied)
+	}
+
+	// Незавершённая миграция не считается применённой.
+	expectSchemaTable(mock)
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(3, true))
+
+	st, err = migration.Inspect(context.Background(), conn, "")
+	require.NoError(t, err)
+	assert.True(t, st.Dirty)
+	assert.True(t, st.Migrations[1].Applied)
+	assert.False(t, st.Migrations[2].Applied)
+	assert.Error(t, st.Err())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,440 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/audit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveID(t *testing.T) {
+	ids := []int32{10, 11, 12, 13}
+
+	tests := []struct {
+		name  string
+		id    int32
+		index int
+		want  []int32
+		ok    bool
+	}{
+		{name: "To the beginning.", id: 12, index: 0, want: []int32{12, 10, 11, 13}, ok: true},
+		{name: "Forward.", id: 10, index: 2, want: []int32{11, 12, 10, 13}, ok: true},
+		{name: "Past the end.", id: 11, index: 100, want: []int32{10, 12, 13, 11}, ok: true},
+		{name: "Negative index.", id: 13, index: -5, want: []int32{13, 10, 11, 12}, ok: true},
+		{name: "Same place.", id: 11, index: 1, want: []int32{10, 11, 12, 13}, ok: true},
+		{name: "Unknown ID.", id: 99, index: 0, want: ids, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := services.MoveID(ids, tt.id, tt.index)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Исходный список не меняется.
+	assert.Equal(t, []int32{10, 11, 12, 13}, ids)
+}
+
+func TestMoveVisibleID(t *testing.T) {
+	all := []int32{10, 11, 12, 13, 14}
+	visible := []int32{10, 12, 14}
+
+	got, ok := services.MoveVisibleID(all, visible, 14, 0)
+	assert.True(t, ok)
+	assert.Equal(t, []int32{14, 11, 10, 13, 12}, got)
+
+	got, ok = services.MoveVisibleID(all, visible, 10, 1)
+	assert.True(t, ok)
+	assert.Equal(t, []int32{12, 11, 10, 13, 14}, got)
+
+	// Скрытую запись переместить нельзя.
+	got, ok = services.MoveVisibleID(all, visible, 11, 0)
+	assert.False(t, ok)
+	assert.Equal(t, all, got)
+}
+
+var playlistColumns = []string{"id", "account_id", "name", "is_public", "share_token", "version", "created_at", "updated_at"}
+
+// expectPlaylistEntries ожидает запрос песен плейлиста 3, в котором одна песня 21.
+func expectPlaylistEntries(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`FROM playlist_entry\s+JOIN`).WithArgs(int32(3)).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "song_id", "group", "song", "added_at"}).
+			AddRow(20, 21, "Muse", "Supermassive Black Hole", time.Now()))
+}
+
+// expectPlaylistEdit ожидает изменение плейлиста 3 владельцем: блокировку, запросы
+// функции edit, запись action в журнал аудита и, если плейлист не удалён, отправку плейлиста.
+func expectPlaylistEdit(mock sqlmock.Sqlmock, action string, edit func(mock sqlmock.Sqlmock)) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR\s+UPDATE`).WithArgs(int32(3)).WillReturnRows(
+		sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", false, nil, 4, time.Now(), time.Now()))
+	expectPlaylistEntries(mock)
+	edit(mock)
+	if action == audit.ActionDelete {
+		expectAudit(mock, audit.EntityPlaylist, 3, action)
+		mock.ExpectCommit()
+		return
+	}
+
+	mock.ExpectExec(`SET version = version \+ 1`).WithArgs(int32(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`FROM playlist`).WithArgs(int32(3)).WillReturnRows(
+		sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", false, nil, 5, time.Now(), time.Now()))
+	expectPlaylistEntries(mock)
+	expectAudit(mock, audit.EntityPlaylist, 3, action)
+	mock.ExpectCommit()
+	mock.ExpectQuery(`FROM playlist`).WithArgs(int32(3)).WillReturnRows(
+		sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", false, nil, 5, time.Now(), time.Now()))
+	expectPlaylistEntries(mock)
+}
+
+func TestMovePlaylistSong(t *testing.T) {
+	owner := auth.Principal{KeyID: 1, AccountID: 7, Name: "alice", Role: auth.RoleReader}
+
+	tests := []struct {
+		name        string
+		principal   auth.Principal
+		ifMatch     string
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+		wantETag    string
+	}{
+		{
+			name:      "Owner moves an entry.",
+			principal: owner,
+			ifMatch:   `"v4"`,
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				expectPlaylistEdit(mock, audit.ActionUpdate, func(mock sqlmock.Sqlmock) {
+					// Запись 21 относится к песне в корзине и не видна в API.
+					mock.ExpectQuery(`SELECT id\s+FROM playlist_entry`).WithArgs(int32(3)).WillReturnRows(
+						sqlmock.NewRows([]string{"id"}).AddRow(20).AddRow(21).AddRow(22).AddRow(23))
+					mock.ExpectQuery(`FROM playlist_entry\s+JOIN`).WithArgs(int32(3)).WillReturnRows(
+						sqlmock.NewRows([]string{"id", "song_id", "group", "song", "added_at"}).
+							AddRow(20, 30, "Muse", "Uprising", time.Now()).
+							AddRow(22, 32, "Muse", "Starlight", time.Now()).
+							AddRow(23, 33, "Muse", "Resistance", time.Now()))
+					for pos, id := range []int32{22, 21, 20, 23} {
+						mock.ExpectExec(`UPDATE playlist_entry`).WithArgs(int32(3), id, int32(pos)).
+							WillReturnResult(sqlmock.NewResult(0, 1))
+					}
+				})
+			},
+			wantStatus: http.StatusOK,
+			wantETag:   `"v5"`,
+		},
+		{
+			name:      "Stale version.",
+			principal: owner,
+			ifMatch:   `"v3"`,
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`FOR\s+UPDATE`).WithArgs(int32(3)).WillReturnRows(
+					sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", false, nil, 4, time.Now(), time.Now()))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:      "Private playlist of another user.",
+			principal: auth.Principal{KeyID: 2, AccountID: 8, Name: "bob", Role: auth.RoleReader},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`FOR\s+UPDATE`).WithArgs(int32(3)).WillReturnRows(
+					sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", false, nil, 4, time.Now(), time.Now()))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:      "Public playlist of another user.",
+			principal: auth.Principal{KeyID: 2, AccountID: 8, Name: "bob", Role: auth.RoleReader},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`FOR\s+UPDATE`).WithArgs(int32(3)).WillReturnRows(
+					sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", true, nil, 4, time.Now(), time.Now()))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			tt.buildEXPECT(mock)
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(http.MethodPatch, "/playlist/songs?id=3&entry=22", strings.NewReader(`{"position": 0}`))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			rec := httptest.NewRecorder()
+			queries.MovePlaylistSong(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantETag != "" {
+				assert.Equal(t, tt.wantETag, rec.Header().Get("ETag"))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAddPlaylist(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO playlist`).WithArgs(int32(7), "Road trip", false).WillReturnRows(
+		sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", false, nil, 1, time.Now(), time.Now()))
+	expectAuditState(mock, audit.EntityPlaylist, 3, audit.ActionCreate, `null`,
+		`{"id":3,"accountId":7,"name":"Road trip","public":false,"shared":false,"version":1,"songs":[]}`)
+	mock.ExpectCommit()
+	mock.ExpectQuery(`FROM playlist_entry`).WithArgs(int32(3)).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "song_id", "group", "song", "added_at"}))
+
+	queries := hd.NewHandlerQueries(conn, config.Config{})
+
+	req := httptest.NewRequest(http.MethodPost, "/playlists", strings.NewReader(`{"name": " Road trip "}`))
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{KeyID: 1, AccountID: 7, Name: "alice", Role: auth.RoleReader}))
+	rec := httptest.NewRecorder()
+	queries.AddPlaylist(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Каждое изменение плейлиста записывается в журнал аудита.
+func TestPlaylistEditAudit(t *testing.T) {
+	owner := auth.Principal{KeyID: 1, AccountID: 7, Name: "alice", Role: auth.RoleReader}
+
+	tests := []struct {
+		name    string
+		handler func(hq *hd.HandleQueries) http.HandlerFunc
+		method  string
+		target  string
+		body    string
+		action  string
+		edit    func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "EditPlaylist.",
+			handler: func(hq *hd.HandleQueries) http.HandlerFunc { return hq.EditPlaylist },
+			method:  http.MethodPatch,
+			target:  "/playlist?id=3",
+			body:    `{"name": "Night drive"}`,
+			action:  audit.ActionUpdate,
+			edit: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`SET name`).WithArgs(int32(3), "Night drive", false).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "RemovePlaylist.",
+			handler: func(hq *hd.HandleQueries) http.HandlerFunc { return hq.RemovePlaylist },
+			method:  http.MethodDelete,
+			target:  "/playlist?id=3",
+			action:  audit.ActionDelete,
+			edit: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`DELETE FROM playlist`).WithArgs(int32(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "AddPlaylistSong.",
+			handler: func(hq *hd.HandleQueries) http.HandlerFunc { return hq.AddPlaylistSong },
+			method:  http.MethodPost,
+			target:  "/playlist/songs?id=3",
+			body:    `{"songId": 22}`,
+			action:  audit.ActionUpdate,
+			edit: func(mock sqlmock.Sqlmock) {
+				expectSong(mock, 22)
+				mock.ExpectQuery(`INSERT INTO playlist_entry`).WithArgs(int32(3), int32(22)).WillReturnRows(
+					sqlmock.NewRows([]string{"id", "playlist_id", "song_id", "position", "added_at"}).AddRow(23, 3, 22, 1, time.Now()))
+			},
+		},
+		{
+			name:    "RemovePlaylistSong.",
+			handler: func(hq *hd.HandleQueries) http.HandlerFunc { return hq.RemovePlaylistSong },
+			method:  http.MethodDelete,
+			target:  "/playlist/songs?id=3&entry=20",
+			action:  audit.ActionUpdate,
+			edit: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`DELETE FROM playlist_entry`).WithArgs(int32(3), int32(20)).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "SharePlaylist.",
+			handler: func(hq *hd.HandleQueries) http.HandlerFunc { return hq.SharePlaylist },
+			method:  http.MethodPost,
+			target:  "/playlist/share?id=3",
+			action:  audit.ActionUpdate,
+			edit: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`SET share_token`).WithArgs(int32(3), sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:    "UnsharePlaylist.",
+			handler: func(hq *hd.HandleQueries) http.HandlerFunc { return hq.UnsharePlaylist },
+			method:  http.MethodDelete,
+			target:  "/playlist/share?id=3",
+			action:  audit.ActionUpdate,
+			edit: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec(`SET share_token`).WithArgs(int32(3), nil).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			expectPlaylistEdit(mock, tt.action, tt.edit)
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), owner))
+			rec := httptest.NewRecorder()
+			tt.handler(queries)(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestShowPlaylistShareURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal auth.Principal
+		wantURL   bool
+	}{
+		{
+			name:      "Owner sees the share URL.",
+			principal: auth.Principal{KeyID: 1, AccountID: 7, Name: "alice", Role: auth.RoleReader},
+			wantURL:   true,
+		},
+		{
+			name:      "Another user does not see the share URL.",
+			principal: auth.Principal{KeyID: 2, AccountID: 8, Name: "bob", Role: auth.RoleReader},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			mock.ExpectQuery(`FROM playlist\s+WHERE id`).WithArgs(int32(3)).WillReturnRows(
+				sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", true, "secret", 4, time.Now(), time.Now()))
+			expectPlaylistEntries(mock)
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(http.MethodGet, "/playlist?id=3", nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			rec := httptest.NewRecorder()
+			queries.ShowPlaylist(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantURL, strings.Contains(rec.Body.String(), "shareUrl"))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestExportSavedPlaylist(t *testing.T) {
+	trackColumns := []string{"id", "group", "song", "link"}
+
+	tests := []struct {
+		name        string
+		target      string
+		principal   auth.Principal
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:      "Owner exports a private playlist in its order.",
+			target:    "/library/playlist?playlist=3",
+			principal: auth.Principal{KeyID: 1, AccountID: 7, Name: "alice", Role: auth.RoleReader},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM playlist\s+WHERE id`).WithArgs(int32(3)).WillReturnRows(
+					sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", false, nil, 4, time.Now(), time.Now()))
+				mock.ExpectQuery(`SELECT library.id`).WithArgs(int32(3)).WillReturnRows(
+					sqlmock.NewRows(trackColumns).
+						AddRow(31, "Muse", "Starlight", "https://example.com/2").
+						AddRow(30, "Muse", "Uprising", "https://example.com/1"))
+			},
+			wantStatus: http.StatusOK,
+			wantBody: "#EXTM3U\n#PLAYLIST:Road trip\n" +
+				"#EXTINF:-1,Muse - Starlight\nhttps://example.com/2\n" +
+				"#EXTINF:-1,Muse - Uprising\nhttps://example.com/1\n",
+		},
+		{
+			name:      "Private playlist of another user.",
+			target:    "/library/playlist?playlist=3",
+			principal: auth.Principal{KeyID: 2, AccountID: 8, Name: "bob", Role: auth.RoleReader},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`FROM playlist\s+WHERE id`).WithArgs(int32(3)).WillReturnRows(
+					sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", false, nil, 4, time.Now(), time.Now()))
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:      "Shared playlist by token.",
+			target:    "/library/playlist?token=secret&title=Mix",
+			principal: auth.Principal{KeyID: 2, AccountID: 8, Name: "bob", Role: auth.RoleReader},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`WHERE share_token`).WithArgs("secret").WillReturnRows(
+					sqlmock.NewRows(playlistColumns).AddRow(3, 7, "Road trip", false, "secret", 4, time.Now(), time.Now()))
+				mock.ExpectQuery(`SELECT library.id`).WithArgs(int32(3)).WillReturnRows(
+					sqlmock.NewRows(trackColumns).AddRow(30, "Muse", "Uprising", "https://example.com/1"))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   "#EXTM3U\n#PLAYLIST:Mix\n#EXTINF:-1,Muse - Uprising\nhttps://example.com/1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			tt.buildEXPECT(mock)
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			rec := httptest.NewRecorder()
+			queries.ExportPlaylist(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
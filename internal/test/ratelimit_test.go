@@ -0,0 +1,149 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := ratelimit.New(2, 3).WithClock(func() time.Time { return now })
+
+	for i := 2; i >= 0; i-- {
+		d := l.Allow("a")
+		require.True(t, d.Allowed)
+		assert.Equal(t, 3, d.Limit)
+		assert.Equal(t, i, d.Remaining)
+	}
+
+	d := l.Allow("a")
+	assert.False(t, d.Allowed)
+	assert.Equal(t, 500*time.Millisecond, d.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, d.Reset)
+
+	// У другого клиента своя корзина.
+	assert.True(t, l.Allow("b").Allowed)
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, l.Allow("a").Allowed)
+
+	// Корзины неактивных клиентов удаляются.
+	now = now.Add(2 * time.Minute)
+	l.Allow("c")
+	assert.Equal(t, 1, l.Len())
+
+	// Без лимита запросы не ограничиваются.
+	unlimited := ratelimit.New(0, 10)
+	for range 20 {
+		assert.Equal(t, ratelimit.Decision{Allowed: true}, unlimited.Allow("a"))
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	queries := hd.NewHandlerQueries(nil, config.Config{})
+	h := queries.RateLimit(ratelimit.New(1, 2))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(remoteAddr string, principal *auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/library/add", nil)
+		req.RemoteAddr = remoteAddr
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), *principal))
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("10.0.0.1:5000", nil)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, "2", first.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", first.Header().Get("RateLimit-Remaining"))
+
+	// Клиент определяется по IP, порт не учитывается.
+	assert.Equal(t, http.StatusOK, send("10.0.0.1:5001", nil).Code)
+	limited := send("10.0.0.1:5002", nil)
+	assert.Equal(t, http.StatusTooManyRequests, limited.Code)
+	assert.Equal(t, "1", limited.Header().Get("Retry-After"))
+	assert.Equal(t, "0", limited.Header().Get("RateLimit-Remaining"))
+
+	// Запросы с API ключом учитываются по ключу, а не по IP.
+	key := &auth.Principal{KeyID: 5, Name: "script", Role: auth.RoleEditor}
+	assert.Equal(t, http.StatusOK, send("10.0.0.1:5003", key).Code)
+	assert.Equal(t, http.StatusOK, send("10.0.0.2:5000", key).Code)
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.3:5000", key).Code)
+
+	// Без ограничителя middleware ничего не меняет.
+	open := queries.RateLimit(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	rec := httptest.NewRecorder()
+	open.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/library/list", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+func TestRateLimitIPBeforeAuth(t *testing.T) {
+	keyColumns := []string{"id", "name", "role", "prefix", "key_hash", "created_at", "revoked_at", "account_id"}
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// Базу данных проверяют только запросы в пределах лимита IP.
+	for range 2 {
+		mock.ExpectQuery(`FROM api_key`).WithArgs(auth.HashKey("eml_guess")).WillReturnRows(sqlmock.NewRows(keyColumns))
+	}
+
+	queries := hd.NewHandlerQueries(conn, config.Config{})
+	h := queries.RateLimitIP(ratelimit.New(1, 2))(queries.RequireRole(auth.RoleReader)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+
+	send := func(remoteAddr string) int {
+		req := httptest.NewRequest(http.MethodGet, "/library/list", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("X-Api-Key", "eml_guess")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send("10.0.0.1:5000"))
+	assert.Equal(t, http.StatusUnauthorized, send("10.0.0.1:5001"))
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.1:5002"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitTrustProxy(t *testing.T) {
+	queries := hd.NewHandlerQueries(nil, config.Config{RateLimitTrustProxy: true})
+	h := queries.RateLimitIP(ratelimit.New(1, 1))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(forwarded string) int {
+		req := httptest.NewRequest(http.MethodGet, "/library/list", nil)
+		req.RemoteAddr = "10.0.0.254:5000"
+		req.Header.Set("X-Forwarded-For", forwarded)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Клиент подставляет свои адреса в начало заголовка, но прокси дописывает в конец его настоящий адрес.
+	assert.Equal(t, http.StatusOK, send("1.1.1.1, 203.0.113.7"))
+	assert.Equal(t, http.StatusTooManyRequests, send("2.2.2.2, 203.0.113.7"))
+	assert.Equal(t, http.StatusOK, send("1.1.1.1, 203.0.113.8"))
+}
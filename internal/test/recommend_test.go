@@ -0,0 +1,111 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/recommend"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func date(year int) time.Time {
+	return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+var recommendSongs = []recommend.Song{
+	{ID: 1, GroupID: 1, Group: "Muse", Song: "Supermassive Black Hole", ReleaseDate: date(2006),
+		Text: "Ooh baby, don't you know I suffer? Ooh baby, can you hear me moan?", Tags: []string{"rock", "alternative"}},
+	{ID: 2, GroupID: 1, Group: "Muse", Song: "Uprising", ReleaseDate: date(2009),
+		Text: "Paranoia is in bloom, the PR transmissions will resume", Tags: []string{"rock"}},
+	{ID: 3, GroupID: 2, Group: "Radiohead", Song: "Creep", ReleaseDate: date(1992),
+		Text: "But I'm a creep, I'm a weirdo, what the hell am I doing here?", Tags: []string{"alternative"}},
+	{ID: 4, GroupID: 3, Group: "Ella Fitzgerald", Song: "Baby Won't You Please Come Home", ReleaseDate: date(1960),
+		Text: "Baby, won't you please come home, baby you know I suffer"},
+	{ID: 5, GroupID: 4, Group: "Louis Armstrong", Song: "La Vie en Rose", ReleaseDate: date(1950),
+		Text: "Hold me close and hold me fast"},
+}
+
+func TestRelated(t *testing.T) {
+	idx := recommend.Build(recommendSongs)
+	require.Equal(t, 5, idx.Len())
+
+	matches, ok := idx.Related(1, 10)
+	require.True(t, ok)
+
+	ids := make([]int32, 0, len(matches))
+	for _, m := range matches {
+		ids = append(ids, m.Song.ID)
+		assert.NotEqual(t, int32(1), m.Song.ID, "the song itself is never related")
+		assert.Greater(t, m.Score, 0.0)
+	}
+	// Тот же исполнитель и тег, затем общий текст, затем общий тег.
+	assert.Equal(t, []int32{2, 4, 3}, ids)
+
+	assert.Equal(t, []string{recommend.ReasonArtist, recommend.ReasonTags, recommend.ReasonEra}, matches[0].Reasons)
+	assert.Equal(t, []string{"rock"}, matches[0].SharedTags)
+	assert.Equal(t, []string{recommend.ReasonLyrics}, matches[1].Reasons)
+	assert.Equal(t, []string{"alternative"}, matches[2].SharedTags)
+
+	limited, ok := idx.Related(1, 1)
+	require.True(t, ok)
+	assert.Len(t, limited, 1)
+
+	// Песня без общих признаков ни с кем не похожа.
+	none, ok := idx.Related(5, 10)
+	require.True(t, ok)
+	assert.Empty(t, none)
+
+	_, ok = idx.Related(99, 10)
+	assert.False(t, ok)
+}
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"don't", "you", "know", "полночь", "2024"},
+		recommend.Tokenize("Don't you know, I... ПОЛНОЧЬ! 2024 'a'"))
+}
+
+func TestRelatedCache(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	loads := 0
+	cache := recommend.NewCache(time.Minute, func(context.Context) ([]recommend.Song, error) {
+		loads++
+		return recommendSongs, nil
+	}).WithClock(func() time.Time { return now })
+
+	ctx := context.Background()
+	first, err := cache.Index(ctx)
+	require.NoError(t, err)
+	second, err := cache.Index(ctx)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, loads)
+
+	cache.Invalidate()
+	_, err = cache.Index(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loads)
+
+	now = now.Add(2 * time.Minute)
+	_, err = cache.Index(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, loads)
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := services.NormalizeTags([]string{" Rock ", "rock", "", "Indie  Pop", "90s"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rock", "indie pop", "90s"}, tags)
+
+	_, err = services.NormalizeTags([]string{"a very long tag that certainly exceeds the limit"})
+	assert.Error(t, err)
+
+	many := make([]string, 21)
+	for i := range many {
+		many[i] = string(rune('a' + i))
+	}
+	_, err = services.NormalizeTags(many)
+	assert.Error(t, err)
+}
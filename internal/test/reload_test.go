@@ -0,0 +1,109 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/ratelimit"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/reload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestReloadRuntimeSettings(t *testing.T) {
+	logs := observeLogs(t)
+	dir := t.TempDir()
+	writeFile(t, dir, ".env", "ADDRESS=127.0.0.1:8000\nPAGINATION_LIMIT=10\nRATE_LIMIT_READ_RPS=20\n")
+	opts := config.Options{Dir: dir}
+
+	cfg, err := config.Load(opts)
+	require.NoError(t, err)
+	live := config.NewLive(cfg)
+
+	var applied []config.Config
+	rl := reload.New(opts, live)
+	rl.OnReload(func(c config.Config) { applied = append(applied, c) })
+
+	writeFile(t, dir, ".env", "ADDRESS=127.0.0.1:9000\nPAGINATION_LIMIT=25\nRATE_LIMIT_READ_RPS=5\n")
+	changes, err := rl.Reload(context.Background(), reload.TriggerSignal)
+	require.NoError(t, err)
+	assert.Equal(t, []config.Change{
+		{Key: "PAGINATION_LIMIT", Old: "10", New: "25"},
+		{Key: "RATE_LIMIT_READ_RPS", Old: "20", New: "5"},
+	}, changes)
+
+	// Адрес сервера меняется только после перезапуска.
+	cur := live.Load()
+	assert.Equal(t, int32(25), cur.PaginationLimit)
+	assert.Equal(t, 5.0, cur.RateLimitReadRPS)
+	assert.Equal(t, "127.0.0.1:8000", cur.ServerHost)
+	require.Len(t, applied, 1)
+	assert.Equal(t, cur, applied[0])
+
+	warn := logs.FilterMessage("configuration changes require a restart").All()
+	require.Len(t, warn, 1)
+	assert.Equal(t, []interface{}{"ADDRESS"}, warn[0].ContextMap()["keys"])
+	info := logs.FilterMessage("configuration reloaded").All()
+	require.Len(t, info, 1)
+	assert.Equal(t, "SIGHUP", info[0].ContextMap()["trigger"])
+	assert.Equal(t, []interface{}{"PAGINATION_LIMIT: 10 -> 25", "RATE_LIMIT_READ_RPS: 20 -> 5"}, info[0].ContextMap()["changes"])
+
+	// С ошибкой в настройках действуют прежние значения.
+	writeFile(t, dir, ".env", "PAGINATION_LIMIT=0\n")
+	_, err = rl.Reload(context.Background(), reload.TriggerSignal)
+	var invalid *config.ValidationError
+	require.ErrorAs(t, err, &invalid)
+	assert.Equal(t, int32(25), live.Load().PaginationLimit)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, 1, logs.FilterLevelExact(zapcore.ErrorLevel).Len())
+}
+
+func TestReloadOnFileChange(t *testing.T) {
+	observeLogs(t)
+	dir := t.TempDir()
+	writeFile(t, dir, ".env", "LOG_LEVEL=info\n")
+	opts := config.Options{Dir: dir}
+
+	cfg, err := config.Load(opts)
+	require.NoError(t, err)
+	live := config.NewLive(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- reload.New(opts, live).WithDebounce(10 * time.Millisecond).Watch(ctx) }()
+
+	// Изменения других файлов папки не учитываются.
+	require.Eventually(t, func() bool {
+		writeFile(t, dir, "notes.txt", "LOG_LEVEL=debug\n")
+		writeFile(t, dir, ".env", "LOG_LEVEL=debug\nEXTERNAL_API_TIMEOUT=3s\n")
+		return live.Load().LogLevel == "debug"
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, 3*time.Second, live.Load().ExternalAPITimeout)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestLimiterSetLimit(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := ratelimit.New(1, 5).WithClock(func() time.Time { return now })
+	l.Allow("a")
+
+	// Накопленные запросы сохраняются, но не больше нового burst.
+	l.SetLimit(1, 2)
+	d := l.Allow("a")
+	assert.Equal(t, 2, d.Limit)
+	assert.Equal(t, 1, d.Remaining)
+
+	l.SetLimit(0, 0)
+	for range 10 {
+		assert.True(t, l.Allow("a").Allowed)
+	}
+
+	l.SetLimit(1, 1)
+	assert.True(t, l.Allow("a").Allowed)
+	assert.False(t, l.Allow("a").Allowed)
+}
@@ -0,0 +1,87 @@
+package test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ra1nz0r/effective_mobile-1/internal/songbook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testBook = songbook.Book{
+	Title:       "Уроки <музыки>",
+	GeneratedAt: time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+	Songs: []songbook.Song{
+		songbook.NewSong(3, "Muse", "Supermassive Black Hole", time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC), "",
+			"Ooh baby, don't you know I suffer?\nOoh baby, can you hear me moan?\n\nI thought I was a fool for no one"),
+		songbook.NewSong(5, "Pink Floyd", "Empty", time.Time{}, "", ""),
+	},
+}
+
+func TestNewSongSplitsCouplets(t *testing.T) {
+	song := testBook.Songs[0]
+	require.Len(t, song.Couplets, 2)
+	assert.Equal(t, "I thought I was a fool for no one", song.Couplets[1])
+	assert.Equal(t, "song-3", song.Anchor())
+	assert.Empty(t, testBook.Songs[1].Couplets)
+}
+
+func TestSongbookHTML(t *testing.T) {
+	r, err := songbook.NewRenderer("")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Render(&buf, songbook.FormatHTML, testBook))
+
+	html := buf.String()
+	assert.Contains(t, html, "<title>Уроки &lt;музыки&gt;</title>")
+	assert.Contains(t, html, `<a href="#song-3">Muse — Supermassive Black Hole</a>`)
+	assert.Contains(t, html, `<section class="song" id="song-3">`)
+	assert.Contains(t, html, "Ooh baby, don&#39;t you know I suffer?<br>Ooh baby, can you hear me moan?</p>")
+	assert.Contains(t, html, "Muse · 16.07.2006")
+	assert.Contains(t, html, "Текст песни отсутствует.")
+}
+
+func TestSongbookMarkdown(t *testing.T) {
+	r, err := songbook.NewRenderer("")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Render(&buf, songbook.FormatMarkdown, testBook))
+
+	md := buf.String()
+	assert.Contains(t, md, "# Уроки \\<музыки\\>\n")
+	assert.Contains(t, md, "1. [Muse — Supermassive Black Hole](#song-3)\n")
+	assert.Contains(t, md, "2. [Pink Floyd — Empty](#song-5)\n")
+	assert.Contains(t, md, "Ooh baby, don't you know I suffer?  \nOoh baby, can you hear me moan?\n\nI thought I was a fool for no one\n")
+}
+
+func TestSongbookTemplateOverride(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := "{{.Title}}:{{range .Songs}} {{.Title}}({{len .Couplets}}){{end}}"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, songbook.MarkdownTemplateName), []byte(tmpl), 0o600))
+
+	r, err := songbook.NewRenderer(dir)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Render(&buf, songbook.FormatMarkdown, testBook))
+	assert.Equal(t, "Уроки <музыки>: Supermassive Black Hole(2) Empty(0)", buf.String())
+
+	// HTML шаблон не переопределён, поэтому используется встроенный.
+	buf.Reset()
+	require.NoError(t, r.Render(&buf, songbook.FormatHTML, testBook))
+	assert.Contains(t, buf.String(), "<!DOCTYPE html>")
+}
+
+func TestSongbookBrokenTemplate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, songbook.HTMLTemplateName), []byte("{{.Title"), 0o600))
+
+	_, err := songbook.NewRenderer(dir)
+	assert.Error(t, err)
+}
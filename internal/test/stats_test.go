@@ -0,0 +1,143 @@
+package test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordSongPlay(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		principal   auth.Principal
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+	}{
+		{
+			name:      "Play by a key linked to an account.",
+			url:       "/song/play?id=21",
+			principal: auth.Principal{KeyID: 3, AccountID: 7, Name: "alice phone", Role: auth.RoleReader},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				expectSong(mock, 21)
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO play_event`).
+					WithArgs(int32(21), sql.NullInt32{Int32: 7, Valid: true}, "play").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO song_daily_stats`).WithArgs(int32(21), int32(1), int32(0)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:      "View by a key without an account.",
+			url:       "/song/play?id=21&kind=view",
+			principal: auth.Principal{KeyID: 4, Name: "kiosk", Role: auth.RoleReader},
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				expectSong(mock, 21)
+				mock.ExpectBegin()
+				mock.ExpectExec(`INSERT INTO play_event`).
+					WithArgs(int32(21), sql.NullInt32{}, "view").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec(`INSERT INTO song_daily_stats`).WithArgs(int32(21), int32(0), int32(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "Unsupported kind.",
+			url:         "/song/play?id=21&kind=skip",
+			principal:   auth.Principal{KeyID: 3, AccountID: 7, Name: "alice phone", Role: auth.RoleReader},
+			buildEXPECT: func(sqlmock.Sqlmock) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			tt.buildEXPECT(mock)
+
+			queries := hd.NewHandlerQueries(conn, config.Config{})
+
+			req := httptest.NewRequest(http.MethodPost, tt.url, nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+			rec := httptest.NewRecorder()
+			queries.RecordSongPlay(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTopChart(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name: "Top artists for all time.",
+			url:  "/stats/top?by=artists&period=all&limit=500",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`GROUP BY artist.id`).WithArgs(sqlmock.AnyArg(), int32(100)).WillReturnRows(
+					sqlmock.NewRows([]string{"id", "group", "plays", "views"}).AddRow(1, "Muse", 12, 30))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"id":1,"group":"Muse","plays":12,"views":30}]`,
+		},
+		{
+			name: "Empty chart.",
+			url:  "/stats/top?limit=5",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`GROUP BY library.id`).WithArgs(sqlmock.AnyArg(), int32(5)).WillReturnRows(
+					sqlmock.NewRows([]string{"id", "group", "song", "plays", "views"}))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[]`,
+		},
+		{
+			name:        "Unsupported period.",
+			url:         "/stats/top?period=decade",
+			buildEXPECT: func(sqlmock.Sqlmock) {},
+			wantStatus:  http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			tt.buildEXPECT(mock)
+
+			queries := hd.NewHandlerQueries(conn, config.Config{PaginationLimit: 10})
+
+			rec := httptest.NewRecorder()
+			queries.TopChart(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	obj, err := store.Put(ctx, "audio/1/song.mp3", strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), obj.Size)
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", obj.SHA256)
+
+	file, err := store.Open(ctx, "audio/1/song.mp3")
+	require.NoError(t, err)
+	data, err := io.ReadAll(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, store.Delete(ctx, "audio/1/song.mp3"))
+	require.NoError(t, store.Delete(ctx, "audio/1/song.mp3"))
+
+	_, err = store.Open(ctx, "audio/1/song.mp3")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestLocalStoreInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	for _, key := range []string{"", "../escape", "audio/../../escape", "/absolute", "audio//double", `audio\win`} {
+		_, err = store.Put(ctx, key, strings.NewReader("x"))
+		assert.Error(t, err, key)
+	}
+}
+
+func TestStreamSongAudioRange(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	obj, err := store.Put(ctx, "audio/7/1.mp3", strings.NewReader("0123456789"))
+	require.NoError(t, err)
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	uploaded := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"song_id", "storage_key", "content_type", "size", "sha256", "uploaded_at"}).
+			AddRow(7, obj.Key, "audio/mpeg", obj.Size, obj.SHA256, uploaded)
+	}
+
+	hq := handlers.NewHandlerQueries(conn, config.Config{})
+	hq.Blobs = store
+
+	// Запрос диапазона байт.
+	mock.ExpectQuery(`FROM audio`).WithArgs(7).WillReturnRows(rows())
+	req := httptest.NewRequest(http.MethodGet, "/song/audio?id=7", http.NoBody)
+	req.Header.Set("Range", "bytes=2-5")
+	rec := httptest.NewRecorder()
+	hq.StreamSongAudio(rec, req)
+
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "2345", rec.Body.String())
+	assert.Equal(t, "bytes 2-5/10", rec.Header().Get("Content-Range"))
+	assert.Equal(t, "audio/mpeg", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `"`+obj.SHA256+`"`, rec.Header().Get("ETag"))
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Repr-Digest"), "sha-256=:"))
+
+	// Повторный запрос с тем же ETag.
+	mock.ExpectQuery(`FROM audio`).WithArgs(7).WillReturnRows(rows())
+	req = httptest.NewRequest(http.MethodGet, "/song/audio?id=7", http.NoBody)
+	req.Header.Set("If-None-Match", `"`+obj.SHA256+`"`)
+	rec = httptest.NewRecorder()
+	hq.StreamSongAudio(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	// Песня без аудиофайла.
+	mock.ExpectQuery(`FROM audio`).WithArgs(8).WillReturnRows(sqlmock.NewRows([]string{"song_id"}))
+	rec = httptest.NewRecorder()
+	hq.StreamSongAudio(rec, httptest.NewRequest(http.MethodGet, "/song/audio?id=8", http.NoBody))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/auth"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/services"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans подменяет глобальный TracerProvider на время теста и возвращает записанные spans.
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+	return recorder
+}
+
+func spansByName(spans []sdktrace.ReadOnlySpan) map[string]sdktrace.ReadOnlySpan {
+	res := make(map[string]sdktrace.ReadOnlySpan, len(spans))
+	for _, s := range spans {
+		res[s.Name()] = s
+	}
+	return res
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+
+	r := chi.NewRouter()
+	r.Use(tracing.Middleware)
+	r.Get("/tracing-test/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/tracing-test/7", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /tracing-test/{id}", spans[0].Name())
+	assert.Equal(t, traceID, spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func TestTracingTransaction(t *testing.T) {
+	recorder := recordSpans(t)
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	expectSong(mock, 21)
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO play_event`).
+		WithArgs(int32(21), sql.NullInt32{Int32: 7, Valid: true}, "play").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO song_daily_stats`).WithArgs(int32(21), int32(1), int32(0)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	queries := hd.NewHandlerQueries(conn, config.Config{})
+
+	ctx, root := otel.Tracer("test").Start(context.Background(), "request")
+	req := httptest.NewRequest(http.MethodPost, "/song/play?id=21", nil).WithContext(
+		auth.WithPrincipal(ctx, auth.Principal{KeyID: 3, AccountID: 7, Name: "alice", Role: auth.RoleReader}))
+	rec := httptest.NewRecorder()
+	queries.RecordSongPlay(rec, req)
+	root.End()
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	spans := spansByName(recorder.Ended())
+	require.Contains(t, spans, "GetOne")
+	require.Contains(t, spans, "db.transaction")
+	require.Contains(t, spans, "AddPlayEvent")
+	require.Contains(t, spans, "IncrementDailyStats")
+
+	rootID := root.SpanContext().SpanID()
+	txID := spans["db.transaction"].SpanContext().SpanID()
+	assert.Equal(t, rootID, spans["GetOne"].Parent().SpanID())
+	assert.Equal(t, rootID, spans["db.transaction"].Parent().SpanID())
+	// Запросы транзакции вложены в её span.
+	assert.Equal(t, txID, spans["AddPlayEvent"].Parent().SpanID())
+	assert.Equal(t, txID, spans["IncrementDailyStats"].Parent().SpanID())
+}
+
+func TestTracingExternalAPI(t *testing.T) {
+	recordSpans(t)
+
+	var traceparent string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer mockServer.Close()
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	_, err := services.FetchSongDetails(ctx, "Muse", "Supermassive Black Hole", mockServer.URL)
+	require.Error(t, err)
+
+	require.NotEmpty(t, traceparent)
+	assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+}
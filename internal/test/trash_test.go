@@ -0,0 +1,202 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/config"
+	hd "github.com/Ra1nz0r/effective_mobile-1/internal/handlers"
+	"github.com/Ra1nz0r/effective_mobile-1/internal/storage"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	deletedSong := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`deleted_at IS NOT NULL`).WithArgs(int32(21)).WillReturnRows(
+			sqlmock.NewRows([]string{"id", "group_id", "song", "releaseDate", "text", "link", "deleted_at", "song_key"}).
+				AddRow(21, 1, "Supermassive Black Hole", time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC), "", "", deletedAt, "supermassive black hole"))
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		buildEXPECT func(mock sqlmock.Sqlmock)
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:   "Delete moves the song to the trash.",
+			method: http.MethodDelete,
+			target: "/library/delete?id=21",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expectSong(mock, 21)
+				mock.ExpectExec(`UPDATE library\s+SET deleted_at = now\(\)`).WithArgs(int32(21)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				deletedSong(mock)
+				expectAudit(mock, "song", 21, "delete")
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{}`,
+		},
+		{
+			name:   "Delete a song that is already in the trash.",
+			method: http.MethodDelete,
+			target: "/library/delete?id=21",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`deleted_at IS NULL`).WithArgs(int32(21)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "Trash lists deleted songs.",
+			method: http.MethodGet,
+			target: "/library/trash?limit=5",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`WHERE library.deleted_at IS NOT NULL`).WithArgs(int32(5), int32(0)).WillReturnRows(
+					sqlmock.NewRows([]string{"id", "group", "song", "deleted_at"}).AddRow(21, "Muse", "Supermassive Black Hole", deletedAt))
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `[{"id":21,"group":"Muse","song":"Supermassive Black Hole","deleted_at":"2024-05-01T12:00:00Z"}]`,
+		},
+		{
+			name:   "Restore a deleted song.",
+			method: http.MethodPost,
+			target: "/library/restore?id=21",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				deletedSong(mock)
+				mock.ExpectQuery(`SELECT EXISTS`).WithArgs(int32(1), "supermassive black hole").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec(`SET deleted_at = NULL`).WithArgs(int32(21)).WillReturnResult(sqlmock.NewResult(0, 1))
+				expectAudit(mock, "song", 21, "restore")
+				mock.ExpectCommit()
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `{}`,
+		},
+		{
+			name:   "Restore a song that was added again.",
+			method: http.MethodPost,
+			target: "/library/restore?id=21",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				deletedSong(mock)
+				mock.ExpectQuery(`SELECT EXISTS`).WithArgs(int32(1), "supermassive black hole").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:   "Restore a song that is added concurrently.",
+			method: http.MethodPost,
+			target: "/library/restore?id=21",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				deletedSong(mock)
+				mock.ExpectQuery(`SELECT EXISTS`).WithArgs(int32(1), "supermassive black hole").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+				mock.ExpectExec(`SET deleted_at = NULL`).WithArgs(int32(21)).WillReturnError(&pgconn.PgError{Code: "23505"})
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:   "Restore a song that is not in the trash.",
+			method: http.MethodPost,
+			target: "/library/restore?id=21",
+			buildEXPECT: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`deleted_at IS NOT NULL`).WithArgs(int32(21)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			tt.buildEXPECT(mock)
+
+			queries := hd.NewHandlerQueries(conn, config.Config{PaginationLimit: 10})
+			handlers := map[string]http.HandlerFunc{
+				http.MethodDelete: queries.DeleteSong,
+				http.MethodGet:    queries.ShowTrash,
+				http.MethodPost:   queries.RestoreSong,
+			}
+
+			rec := httptest.NewRecorder()
+			handlers[tt.method](rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, rec.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	audio, err := store.Put(ctx, "audio/21/1.mp3", strings.NewReader("mp3"))
+	require.NoError(t, err)
+	cover, err := store.Put(ctx, "cover/21/0.jpg", strings.NewReader("jpg"))
+	require.NoError(t, err)
+
+	conn, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	before := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`WHERE deleted_at < \$1`).WithArgs(before, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21).AddRow(22))
+	mock.ExpectQuery(`SELECT storage_key`).WithArgs(int32(21)).
+		WillReturnRows(sqlmock.NewRows([]string{"storage_key"}).AddRow(audio.Key).AddRow(cover.Key))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`deleted_at IS NOT NULL`).WithArgs(int32(21)).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "group_id", "song", "releaseDate", "text", "link", "deleted_at", "song_key"}).
+			AddRow(21, 1, "Supermassive Black Hole", time.Time{}, "", "", before.Add(-time.Hour), "supermassive black hole"))
+	mock.ExpectExec(`DELETE FROM library`).WithArgs(int32(21)).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAudit(mock, "song", 21, "purge")
+	mock.ExpectCommit()
+	// Песню 22 восстановили после выборки, она остаётся в библиотеке.
+	mock.ExpectQuery(`SELECT storage_key`).WithArgs(int32(22)).WillReturnRows(sqlmock.NewRows([]string{"storage_key"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`deleted_at IS NOT NULL`).WithArgs(int32(22)).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	queries := hd.NewHandlerQueries(conn, config.Config{})
+	queries.Blobs = store
+
+	purged, err := queries.PurgeTrash(ctx, before)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	for _, key := range []string{audio.Key, cover.Key} {
+		_, err = store.Open(ctx, key)
+		assert.ErrorIs(t, err, storage.ErrNotFound)
+	}
+
+}
@@ -0,0 +1,173 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryName находит имя запроса sqlc в комментарии "-- name: GetOne :one".
+var queryName = regexp.MustCompile(`^--\s*name:\s*(\w+)`)
+
+// DBTX методы, через которые sqlc выполняет запросы. Совпадает с db.DBTX.
+type DBTX interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PrepareContext(context.Context, string) (*sql.Stmt, error)
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *sql.Row
+}
+
+// DB создаёт span для каждого запроса sqlc. Имя span совпадает с именем запроса в
+// db/query, а текст запроса записывается в атрибут db.query.text без значений параметров.
+type DB struct {
+	conn   DBTX
+	parent trace.Span // span транзакции, к которой относятся запросы
+}
+
+// WrapDB оборачивает подключение или транзакцию для передачи в db.New.
+func WrapDB(conn DBTX) *DB {
+	return &DB{conn: conn}
+}
+
+func (d *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	res, err := d.conn.ExecContext(ctx, query, args...)
+	recordError(span, err)
+	return res, err
+}
+
+func (d *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	stmt, err := d.conn.PrepareContext(ctx, query)
+	recordError(span, err)
+	return stmt, err
+}
+
+// QueryContext учитывает время выполнения запроса, чтение строк в span не входит.
+func (d *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	rows, err := d.conn.QueryContext(ctx, query, args...)
+	recordError(span, err)
+	return rows, err
+}
+
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := d.start(ctx, query)
+	defer span.End()
+
+	row := d.conn.QueryRowContext(ctx, query, args...)
+	// sql.ErrNoRows - обычный результат запроса, а не ошибка.
+	if err := row.Err(); err != sql.ErrNoRows {
+		recordError(span, err)
+	}
+	return row
+}
+
+// start начинает span запроса.
+func (d *DB) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	if d.parent != nil {
+		ctx = trace.ContextWithSpan(ctx, d.parent)
+	}
+
+	name := "db.query"
+	attrs := []attribute.KeyValue{semconv.DBSystemPostgreSQL, semconv.DBQueryText(query)}
+	if m := queryName.FindStringSubmatch(query); m != nil {
+		name = m[1]
+		attrs = append(attrs, semconv.DBOperationName(m[1]))
+	}
+
+	return tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// Tx транзакция со span на всё время её выполнения. Запросы внутри транзакции
+// становятся дочерними spans транзакции.
+type Tx struct {
+	*sql.Tx
+	*DB
+	span trace.Span
+}
+
+// Beginner начинает транзакции, например *sql.DB.
+type Beginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// BeginTx начинает транзакцию и span "db.transaction", который завершается в Commit или Rollback.
+func BeginTx(ctx context.Context, conn Beginner, opts *sql.TxOptions) (*Tx, error) {
+	ctx, span := tracer().Start(ctx, "db.transaction",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
+
+	tx, err := conn.BeginTx(ctx, opts)
+	if err != nil {
+		recordError(span, err)
+		span.End()
+		return nil, err
+	}
+	return &Tx{Tx: tx, DB: &DB{conn: tx, parent: span}, span: span}, nil
+}
+
+// Commit фиксирует транзакцию и завершает её span.
+func (t *Tx) Commit() error {
+	err := t.Tx.Commit()
+	t.end("commit", err)
+	return err
+}
+
+// Rollback откатывает транзакцию и завершает её span. Повторный вызов после Commit
+// возвращает sql.ErrTxDone и span не меняет.
+func (t *Tx) Rollback() error {
+	err := t.Tx.Rollback()
+	if err != sql.ErrTxDone {
+		t.end("rollback", err)
+	}
+	return err
+}
+
+// Методы запросов берутся из DB, чтобы они попадали в span транзакции.
+
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.DB.ExecContext(ctx, query, args...)
+}
+
+func (t *Tx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.DB.PrepareContext(ctx, query)
+}
+
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.DB.QueryContext(ctx, query, args...)
+}
+
+func (t *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.DB.QueryRowContext(ctx, query, args...)
+}
+
+func (t *Tx) end(outcome string, err error) {
+	t.span.SetAttributes(attribute.String("db.transaction.outcome", outcome))
+	recordError(t.span, err)
+	t.span.End()
+}
+
+// recordError отмечает span как завершившийся ошибкой.
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,127 @@
+// Package tracing настраивает OpenTelemetry: spans входящих запросов, запросов к базе
+// данных, транзакций и обращений к внешнему API с передачей контекста трассировки.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName имя библиотеки инструментирования в spans приложения.
+const instrumentationName = "github.com/Ra1nz0r/effective_mobile-1/internal/tracing"
+
+// Куда отправлять spans.
+const (
+	ExporterNone   = "none"   // трассировка выключена
+	ExporterStdout = "stdout" // spans выводятся в stdout в формате JSON, для локальной отладки
+	ExporterOTLP   = "otlp"   // spans отправляются в коллектор по OTLP/HTTP
+)
+
+// ErrUnknownExporter возвращается при неизвестном значении Options.Exporter.
+var ErrUnknownExporter = errors.New("unknown tracing exporter, expected none, stdout or otlp")
+
+// Options настройки трассировки.
+type Options struct {
+	Exporter     string  // none, stdout или otlp
+	OTLPEndpoint string  // адрес коллектора host:port, пусто - из OTEL_EXPORTER_OTLP_ENDPOINT или localhost:4318
+	OTLPInsecure bool    // отправлять spans по HTTP без TLS
+	ServiceName  string  // имя сервиса в spans
+	SampleRatio  float64 // доля записываемых трассировок от 0 до 1
+}
+
+// Setup настраивает глобальные TracerProvider и propagator. Возвращает функцию,
+// которая отправляет оставшиеся spans и останавливает экспорт при завершении сервера.
+// Контекст трассировки из входящих запросов учитывается и при выключенном экспорте.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	switch opts.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		var clientOpts []otlptracehttp.Option
+		if opts.OTLPEndpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(opts.OTLPEndpoint))
+		}
+		if opts.OTLPInsecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, clientOpts...)
+	default:
+		return nil, fmt.Errorf("%w: '%s'", ErrUnknownExporter, opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s exporter: %w", opts.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(opts.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// tracer возвращает tracer приложения из глобального TracerProvider.
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Middleware (middleware) создаёт span для каждого входящего запроса и продолжает трассировку
+// из заголовка traceparent. Имя span содержит шаблон маршрута chi, например "GET /song/couplet".
+func Middleware(h http.Handler) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r)
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+		}
+	})
+
+	return otelhttp.NewHandler(named, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}),
+	)
+}
+
+// Transport оборачивает base, создаёт span для исходящих запросов и добавляет
+// в них заголовок traceparent. При base == nil используется http.DefaultTransport.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return otelhttp.NewTransport(base)
+}